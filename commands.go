@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"ebookstore/db"
+	"ebookstore/internal/bootstrap"
+	"ebookstore/internal/httpservice"
+	"ebookstore/internal/httpservice/httperror"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/repository"
+	"ebookstore/utils/config"
+	"ebookstore/utils/migrator"
+	"ebookstore/utils/tracing"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+// withDB opens the database for the duration of a command.
+func withDB(ctx context.Context, cfg *config.Config, fn func(conn *sqlx.DB) error) error {
+	conn, err := repository.ConnectPostgres(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return fn(conn)
+}
+
+// withContainer runs fn with the same repositories and services as the API.
+// Notifications queued by a command are sent by the dispatcher of the running
+// server.
+func withContainer(ctx context.Context, cfg *config.Config, fn func(c *bootstrap.Container) error) error {
+	return withDB(ctx, cfg, func(conn *sqlx.DB) error {
+		c, err := bootstrap.New(conn, cfg)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		return fn(c)
+	})
+}
+
+// serve runs the API and the notification dispatcher until SIGINT or SIGTERM.
+// It then stops accepting connections and lets in-flight requests and the
+// notification batch being sent finish within the shutdown timeout.
+func serve(ctx context.Context, cfg *config.Config, args []string) error {
+	newFlagSet("serve").Parse(args)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	return withContainer(ctx, cfg, func(c *bootstrap.Container) error {
+		app := fiber.New(fiber.Config{
+			DisableStartupMessage: true,
+			ErrorHandler:          httperror.Handler,
+		})
+		httpservice.InitRoutes(app, c)
+
+		dispatchCtx, stopDispatch := context.WithCancel(ctx)
+		defer stopDispatch()
+		dispatched := make(chan struct{})
+		go func() {
+			defer close(dispatched)
+			c.OutboxService.Run(dispatchCtx)
+		}()
+
+		if c.Redis != nil {
+			go c.Redis.Listen(dispatchCtx)
+		}
+
+		listenErr := make(chan error, 1)
+		go func() {
+			slog.Info("listening", "port", cfg.Server.Port)
+			listenErr <- app.Listen(fmt.Sprintf(":%d", cfg.Server.Port))
+		}()
+
+		select {
+		case err := <-listenErr:
+			return err
+		case <-ctx.Done():
+		}
+
+		//a second signal kills the process right away
+		stop()
+		c.HealthService.SetShuttingDown()
+		if cfg.Server.ShutdownDelay > 0 {
+			slog.Info("not ready, waiting before shutting down", "delay", cfg.Server.ShutdownDelay)
+			time.Sleep(cfg.Server.ShutdownDelay)
+		}
+		slog.Info("shutting down, waiting for in-flight requests", "timeout", cfg.Server.ShutdownTimeout)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+
+		err := app.ShutdownWithContext(shutdownCtx)
+		if err != nil {
+			return fmt.Errorf("failed to shut down: %s", err.Error())
+		}
+
+		//messages of an unfinished batch are sent again once their claim expires
+		stopDispatch()
+		select {
+		case <-dispatched:
+		case <-shutdownCtx.Done():
+			slog.Warn("stopped while sending notifications, they will be retried")
+		}
+
+		return <-listenErr
+	})
+}
+
+func migrate(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("migrate")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: ebookstore migrate up | down [n] | status")
+	}
+	fs.Parse(args)
+	args = fs.Args()
+
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	return withDB(ctx, cfg, func(conn *sqlx.DB) error {
+		m, err := migrator.New(conn.DB, db.Migrations, "migrations")
+		if err != nil {
+			return err
+		}
+
+		switch args[0] {
+		case "up":
+			done, err := m.Up(ctx)
+			for _, migration := range done {
+				slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+			}
+			if err == nil && len(done) == 0 {
+				slog.Info("database is up to date")
+			}
+			return err
+		case "down":
+			steps := 1
+			if len(args) > 1 {
+				steps, err = strconv.Atoi(args[1])
+				if err != nil {
+					return fmt.Errorf("invalid number of steps %q", args[1])
+				}
+			}
+
+			done, err := m.Down(ctx, steps)
+			for _, migration := range done {
+				slog.Info("rolled back migration", "version", migration.Version, "name", migration.Name)
+			}
+			return err
+		case "status":
+			statuses, err := m.Status(ctx)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+			for _, status := range statuses {
+				appliedAt := "-"
+				if status.AppliedAt != nil {
+					appliedAt = status.AppliedAt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt)
+			}
+			return w.Flush()
+		default:
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		return nil
+	})
+}
+
+func seed(ctx context.Context, cfg *config.Config, args []string) error {
+	newFlagSet("seed").Parse(args)
+
+	return withDB(ctx, cfg, func(conn *sqlx.DB) error {
+		names, err := migrator.Seed(ctx, conn.DB, db.Seeds, "seeds")
+		for _, name := range names {
+			slog.Info("seeded", "file", name)
+		}
+
+		return err
+	})
+}
+
+func createAdmin(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("create-admin")
+	email := fs.String("email", "", "email of the admin, an existing account is promoted")
+	username := fs.String("username", "", "username, required for a new account")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "password, required for a new account (default $ADMIN_PASSWORD)")
+	fs.Parse(args)
+
+	if *email == "" {
+		return errors.New("--email is required")
+	}
+
+	return withContainer(ctx, cfg, func(c *bootstrap.Container) error {
+		id, err := c.CustomerService.CreateAdmin(ctx, request.Register{
+			Email:    *email,
+			Username: *username,
+			Password: *password,
+		})
+		if err != nil {
+			return err
+		}
+
+		slog.Info("customer is now an admin", "customer_id", id, "email", *email)
+		return nil
+	})
+}
+
+func importBooks(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("import-books")
+	file := fs.String("file", "", "CSV file with a title,author,price,category header")
+	fs.Parse(args)
+
+	if *file == "" {
+		return errors.New("--file is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	books, err := readBooksCSV(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", *file, err.Error())
+	}
+
+	return withContainer(ctx, cfg, func(c *bootstrap.Container) error {
+		data, err := c.BookService.ImportBooks(ctx, books)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("imported books", "imported", data.Imported, "skipped", data.Skipped)
+		return nil
+	})
+}
+
+// readBooksCSV reads books from a CSV file. Columns are matched by the header
+// name, so their order does not matter.
+func readBooksCSV(r io.Reader) ([]request.ImportBook, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %s", err.Error())
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"title", "author", "price", "category"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %s column", name)
+		}
+	}
+
+	var books []request.ImportBook
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		price, err := strconv.ParseFloat(strings.TrimSpace(record[columns["price"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price on line %d", line)
+		}
+
+		books = append(books, request.ImportBook{
+			Title:    record[columns["title"]],
+			Author:   record[columns["author"]],
+			Price:    price,
+			Category: record[columns["category"]],
+		})
+	}
+
+	return books, nil
+}
+
+func reindexSearch(ctx context.Context, cfg *config.Config, args []string) error {
+	newFlagSet("reindex-search").Parse(args)
+
+	return withContainer(ctx, cfg, func(c *bootstrap.Container) error {
+		count, err := c.BookService.ReindexSearch(ctx)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("reindexed books", "count", count)
+		return nil
+	})
+}
+
+// invalidateCache reaches the running servers through Redis, the memory cache
+// of a server can only be invalidated with its admin endpoint.
+func invalidateCache(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("invalidate-cache")
+	bookID := fs.Uint("book", 0, "id of the book, every book and category when omitted")
+	fs.Parse(args)
+
+	if cfg.Cache.Backend != "redis" {
+		return errors.New("invalidate-cache needs the redis cache backend, use DELETE /api/admin/books/cache on each server instead")
+	}
+
+	return withContainer(ctx, cfg, func(c *bootstrap.Container) error {
+		var bookIDs []uint
+		if *bookID != 0 {
+			bookIDs = append(bookIDs, uint(*bookID))
+		}
+
+		c.BookService.InvalidateCache(ctx, bookIDs...)
+		slog.Info("invalidated cache", "books", bookIDs)
+		return nil
+	})
+}
+
+func resendNotification(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("resend-notification")
+	orderID := fs.Uint("order", 0, "id of the order")
+	fs.Parse(args)
+
+	if *orderID == 0 {
+		return errors.New("--order is required")
+	}
+
+	return withContainer(ctx, cfg, func(c *bootstrap.Container) error {
+		err := c.OrderService.ResendOrderConfirmation(ctx, uint(*orderID))
+		if err != nil {
+			return err
+		}
+
+		slog.Info("order confirmation sent", "order_id", *orderID)
+		return nil
+	})
+}
+
+// purgeIdempotencyKeys is meant to run periodically, e.g. from cron, expired
+// keys are never read again.
+func purgeIdempotencyKeys(ctx context.Context, cfg *config.Config, args []string) error {
+	newFlagSet("purge-idempotency-keys").Parse(args)
+
+	return withContainer(ctx, cfg, func(c *bootstrap.Container) error {
+		count, err := c.OrderService.PurgeIdempotencyKeys(ctx)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("purged expired idempotency keys", "count", count)
+		return nil
+	})
+}
+
+// purgeOutbox is meant to run periodically, e.g. from cron, sent and dead
+// notifications hold whole emails and are only kept for OUTBOX_RETENTION.
+func purgeOutbox(ctx context.Context, cfg *config.Config, args []string) error {
+	newFlagSet("purge-outbox").Parse(args)
+
+	return withContainer(ctx, cfg, func(c *bootstrap.Container) error {
+		count, err := c.OutboxService.PurgeMessages(ctx)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("purged old notifications", "count", count)
+		return nil
+	})
+}
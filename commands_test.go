@@ -0,0 +1,45 @@
+package main
+
+import (
+	"ebookstore/internal/model/request"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readBooksCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []request.ImportBook
+		wantErr bool
+	}{
+		{
+			name:  "columns in any order",
+			input: "category,title,author,price\nFantasy,The Hobbit,J.R.R. Tolkien,20.5\n\"Science Fiction\",\"Dune, Part One\",Frank Herbert,15\n",
+			want: []request.ImportBook{
+				{Title: "The Hobbit", Author: "J.R.R. Tolkien", Price: 20.5, Category: "Fantasy"},
+				{Title: "Dune, Part One", Author: "Frank Herbert", Price: 15, Category: "Science Fiction"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "missing column",
+			input:   "title,author,price\nThe Hobbit,J.R.R. Tolkien,20\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid price",
+			input:   "title,author,price,category\nThe Hobbit,J.R.R. Tolkien,twenty,Fantasy\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBooksCSV(strings.NewReader(tt.input))
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+// Package db embeds the SQL files so the binary can migrate and seed a
+// database without the source tree next to it.
+package db
+
+import "embed"
+
+// Migrations holds the versioned <version>_<name>.up.sql and .down.sql files.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// Seeds holds idempotent data seeders, applied in file name order.
+//
+//go:embed seeds/*.sql
+var Seeds embed.FS
@@ -0,0 +1,6 @@
+-- Rollback for creating the base tables
+DROP TABLE IF EXISTS Items;
+DROP TABLE IF EXISTS Orders;
+DROP TABLE IF EXISTS Books;
+DROP TABLE IF EXISTS Categories;
+DROP TABLE IF EXISTS Customers;
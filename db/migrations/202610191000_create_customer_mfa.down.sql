@@ -0,0 +1,3 @@
+-- Rollback for creating Customer_MFA and Customer_Recovery_Codes tables
+DROP TABLE IF EXISTS Customer_Recovery_Codes;
+DROP TABLE IF EXISTS Customer_MFA;
@@ -0,0 +1,19 @@
+-- Migration for creating Customer_MFA table if not exists
+CREATE TABLE IF NOT EXISTS Customer_MFA (
+    customer_id INTEGER PRIMARY KEY REFERENCES Customers(id),
+    totp_secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    enabled_at TIMESTAMP
+);
+
+-- Migration for creating Customer_Recovery_Codes table if not exists
+CREATE TABLE IF NOT EXISTS Customer_Recovery_Codes (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER REFERENCES Customers(id),
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_recovery_codes_customer_id ON Customer_Recovery_Codes (customer_id);
@@ -0,0 +1,2 @@
+-- Rollback for creating Customer_Identities table
+DROP TABLE IF EXISTS Customer_Identities;
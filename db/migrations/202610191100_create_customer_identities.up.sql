@@ -0,0 +1,10 @@
+-- Migration for creating Customer_Identities table if not exists
+CREATE TABLE IF NOT EXISTS Customer_Identities (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER NOT NULL REFERENCES Customers(id),
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (issuer, subject)
+);
@@ -0,0 +1,6 @@
+-- Rollback for adding email change verification columns to Customers table
+DROP INDEX IF EXISTS idx_customers_email_verification_token;
+ALTER TABLE Customers DROP COLUMN IF EXISTS pending_email;
+ALTER TABLE Customers DROP COLUMN IF EXISTS email_verification_token;
+ALTER TABLE Customers DROP COLUMN IF EXISTS email_verification_expires_at;
+ALTER TABLE Customers DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,7 @@
+-- Migration for adding email change verification columns to Customers table
+ALTER TABLE Customers ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255);
+ALTER TABLE Customers ADD COLUMN IF NOT EXISTS email_verification_token VARCHAR(64);
+ALTER TABLE Customers ADD COLUMN IF NOT EXISTS email_verification_expires_at TIMESTAMP;
+ALTER TABLE Customers ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_customers_email_verification_token ON Customers (email_verification_token);
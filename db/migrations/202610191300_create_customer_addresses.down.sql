@@ -0,0 +1,2 @@
+-- Rollback for creating Customer_Addresses table
+DROP TABLE IF EXISTS Customer_Addresses;
@@ -0,0 +1,20 @@
+-- Migration for creating Customer_Addresses table if not exists
+CREATE TABLE IF NOT EXISTS Customer_Addresses (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER NOT NULL REFERENCES Customers(id),
+    label VARCHAR(255),
+    receiver_name VARCHAR(255),
+    address VARCHAR(255) NOT NULL,
+    city VARCHAR(255) NOT NULL,
+    district VARCHAR(255) NOT NULL,
+    postal_code VARCHAR(20) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_addresses_customer_id ON Customer_Addresses (customer_id);
+
+-- a customer has at most one default address
+CREATE UNIQUE INDEX IF NOT EXISTS uq_customer_addresses_default ON Customer_Addresses (customer_id) WHERE is_default AND deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- Rollback for marking anonymized customers
+ALTER TABLE Customers DROP COLUMN IF EXISTS deleted_at;
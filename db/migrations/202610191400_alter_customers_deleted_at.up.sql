@@ -0,0 +1,2 @@
+-- Migration for marking anonymized customers
+ALTER TABLE Customers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
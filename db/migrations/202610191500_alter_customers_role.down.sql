@@ -0,0 +1,2 @@
+-- Rollback for adding roles to Customers table
+ALTER TABLE Customers DROP COLUMN IF EXISTS role;
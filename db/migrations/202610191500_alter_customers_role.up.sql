@@ -0,0 +1,2 @@
+-- Migration for adding roles to Customers table
+ALTER TABLE Customers ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'customer';
@@ -0,0 +1,3 @@
+-- Rollback for adding full text search to Books table
+DROP INDEX IF EXISTS idx_books_search_vector;
+ALTER TABLE Books DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,4 @@
+-- Migration for adding full text search to Books table
+ALTER TABLE Books ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+CREATE INDEX IF NOT EXISTS idx_books_search_vector ON Books USING GIN (search_vector);
@@ -0,0 +1,2 @@
+-- Rollback for creating Notification_Outbox table
+DROP TABLE IF EXISTS Notification_Outbox;
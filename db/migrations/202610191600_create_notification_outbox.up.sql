@@ -0,0 +1,16 @@
+-- Migration for creating Notification_Outbox table if not exists
+CREATE TABLE IF NOT EXISTS Notification_Outbox (
+    id SERIAL PRIMARY KEY,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+-- the dispatcher only ever looks at pending messages that are due
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_due ON Notification_Outbox (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_status ON Notification_Outbox (status, id);
@@ -0,0 +1,2 @@
+-- Rollback for creating Customer_Notification_Preferences table
+DROP TABLE IF EXISTS Customer_Notification_Preferences;
@@ -0,0 +1,10 @@
+-- Migration for creating Customer_Notification_Preferences table if not exists
+-- a missing row means the default of the category
+CREATE TABLE IF NOT EXISTS Customer_Notification_Preferences (
+    customer_id INTEGER NOT NULL REFERENCES Customers(id),
+    category VARCHAR(32) NOT NULL,
+    channel VARCHAR(32) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (customer_id, category, channel)
+);
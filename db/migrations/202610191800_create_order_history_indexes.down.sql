@@ -0,0 +1,3 @@
+-- Rollback for creating the order history indexes
+DROP INDEX IF EXISTS idx_items_order_id;
+DROP INDEX IF EXISTS idx_orders_customer_id_order_date;
@@ -0,0 +1,4 @@
+-- Order history reads the orders of a customer newest first, then the items of
+-- all of them at once
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id_order_date ON Orders (customer_id, order_date DESC);
+CREATE INDEX IF NOT EXISTS idx_items_order_id ON Items (order_id);
@@ -0,0 +1,3 @@
+-- Rollback for adding a status to Orders table
+DROP INDEX IF EXISTS idx_orders_customer_id_status_order_date;
+ALTER TABLE Orders DROP COLUMN IF EXISTS status;
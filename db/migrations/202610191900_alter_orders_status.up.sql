@@ -0,0 +1,4 @@
+-- Migration for adding a status to Orders table, existing orders are placed
+ALTER TABLE Orders ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'placed';
+-- Order history filters on the status of a customer's orders
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id_status_order_date ON Orders (customer_id, status, order_date DESC);
@@ -0,0 +1,2 @@
+-- Rollback for creating Idempotency_Keys table
+DROP TABLE IF EXISTS Idempotency_Keys;
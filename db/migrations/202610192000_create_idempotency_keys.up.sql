@@ -0,0 +1,13 @@
+-- Migration for creating Idempotency_Keys table if not exists
+-- a key replays the stored response of the request that first used it until
+-- it expires, then it can be used again
+CREATE TABLE IF NOT EXISTS Idempotency_Keys (
+    customer_id INTEGER NOT NULL REFERENCES Customers(id),
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    response JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (customer_id, key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON Idempotency_Keys (expires_at);
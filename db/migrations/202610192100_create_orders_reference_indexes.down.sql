@@ -0,0 +1,3 @@
+-- Rollback for creating the order reference indexes, renamed duplicates are kept
+DROP INDEX IF EXISTS idx_orders_airwaybill_number;
+DROP INDEX IF EXISTS idx_orders_customer_reference;
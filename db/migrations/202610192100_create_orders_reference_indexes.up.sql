@@ -0,0 +1,9 @@
+-- References generated in the same nanosecond could be the same, the later
+-- duplicates get their order id appended so the unique indexes can be built
+UPDATE Orders o SET customer_reference = o.customer_reference || '-' || o.id
+WHERE EXISTS (SELECT 1 FROM Orders d WHERE d.customer_reference = o.customer_reference AND d.id < o.id);
+UPDATE Orders o SET airwaybill_number = o.airwaybill_number || '-' || o.id
+WHERE EXISTS (SELECT 1 FROM Orders d WHERE d.airwaybill_number = o.airwaybill_number AND d.id < o.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_customer_reference ON Orders (customer_reference);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_airwaybill_number ON Orders (airwaybill_number);
@@ -0,0 +1,4 @@
+-- Rollback for limiting MFA attempts and making login challenges single use
+ALTER TABLE Customer_MFA DROP COLUMN IF EXISTS challenge_id;
+ALTER TABLE Customer_MFA DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE Customer_MFA DROP COLUMN IF EXISTS failed_attempts;
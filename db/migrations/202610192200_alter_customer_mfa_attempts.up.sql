@@ -0,0 +1,4 @@
+-- Migration for limiting MFA attempts and making login challenges single use
+ALTER TABLE Customer_MFA ADD COLUMN IF NOT EXISTS failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE Customer_MFA ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+ALTER TABLE Customer_MFA ADD COLUMN IF NOT EXISTS challenge_id VARCHAR(64);
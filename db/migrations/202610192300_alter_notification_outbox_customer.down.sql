@@ -0,0 +1,4 @@
+-- Rollback for linking outbox messages to their customer and purging old ones
+DROP INDEX IF EXISTS idx_notification_outbox_finished;
+DROP INDEX IF EXISTS idx_notification_outbox_customer_id;
+ALTER TABLE Notification_Outbox DROP COLUMN IF EXISTS customer_id;
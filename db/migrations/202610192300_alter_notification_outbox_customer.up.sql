@@ -0,0 +1,9 @@
+-- Migration for linking outbox messages to their customer and purging old ones
+ALTER TABLE Notification_Outbox ADD COLUMN IF NOT EXISTS customer_id INTEGER;
+
+UPDATE Notification_Outbox SET customer_id = (payload->>'customer_id')::INTEGER
+WHERE customer_id IS NULL AND payload ? 'customer_id';
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_customer_id ON Notification_Outbox (customer_id);
+-- the retention purge only looks at finished messages
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_finished ON Notification_Outbox (updated_at) WHERE status IN ('sent', 'dead');
@@ -0,0 +1,2 @@
+-- Rollback for remembering the channels an outbox message was delivered to
+ALTER TABLE Notification_Outbox DROP COLUMN IF EXISTS delivered_channels;
@@ -0,0 +1,2 @@
+-- Migration for remembering the channels an outbox message was delivered to
+ALTER TABLE Notification_Outbox ADD COLUMN IF NOT EXISTS delivered_channels TEXT[] NOT NULL DEFAULT '{}';
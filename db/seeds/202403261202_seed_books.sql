@@ -28,3 +28,7 @@ SELECT * FROM (
 WHERE NOT EXISTS (
     SELECT 1 FROM books b WHERE b.title = book.title
 );
+
+-- Index the seeded books for search
+UPDATE books b SET search_vector = to_tsvector('simple', b.title || ' ' || b.author || ' ' || COALESCE((SELECT c.name FROM categories c WHERE c.id = b.category_id), ''))
+WHERE b.search_vector IS NULL;
@@ -0,0 +1,61 @@
+// Package apperror defines the kinds of domain errors the services return, so
+// the HTTP layer can answer with the right status code. Services wrap them with
+// %w like any other error, errors.Is still finds the kind.
+package apperror
+
+import "errors"
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// FieldError points at the request field a validation error is about.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error with a message safe to show to the client.
+type Error struct {
+	kind    error
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+func NotFound(message string) error {
+	return &Error{kind: ErrNotFound, Message: message}
+}
+
+func Conflict(message string) error {
+	return &Error{kind: ErrConflict, Message: message}
+}
+
+// Validation reports invalid input, with the offending fields when they are known.
+func Validation(message string, fields ...FieldError) error {
+	return &Error{kind: ErrValidation, Message: message, Fields: fields}
+}
+
+func Unauthorized(message string) error {
+	return &Error{kind: ErrUnauthorized, Message: message}
+}
+
+func Forbidden(message string) error {
+	return &Error{kind: ErrForbidden, Message: message}
+}
+
+// Field is a Validation error about a single field.
+func Field(field, message string) error {
+	return Validation(field+" "+message, FieldError{Field: field, Message: message})
+}
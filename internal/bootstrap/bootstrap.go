@@ -0,0 +1,146 @@
+// Package bootstrap wires the repositories and services together, so the HTTP
+// server and the CLI commands share the same setup.
+package bootstrap
+
+import (
+	dbfs "ebookstore/db"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository"
+	"ebookstore/internal/repository/postgresql"
+	"ebookstore/internal/service"
+	addressService "ebookstore/internal/service/address"
+	bookService "ebookstore/internal/service/book"
+	customerService "ebookstore/internal/service/customer"
+	healthService "ebookstore/internal/service/health"
+	orderService "ebookstore/internal/service/order"
+	outboxService "ebookstore/internal/service/outbox"
+	preferenceService "ebookstore/internal/service/preference"
+	privacyService "ebookstore/internal/service/privacy"
+	"ebookstore/internal/validator"
+	"ebookstore/utils/cache"
+	"ebookstore/utils/config"
+	"ebookstore/utils/metrics"
+	authentication "ebookstore/utils/middleware"
+	"ebookstore/utils/migrator"
+	"ebookstore/utils/notification"
+	"ebookstore/utils/oidc"
+	"ebookstore/utils/transactioner"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type Container struct {
+	Config *config.Config
+	DB     *sqlx.DB
+
+	BookRepository        repository.IBookRepository
+	CustomerRepository    repository.ICustomerRepository
+	MFARepository         repository.IMFARepository
+	AddressRepository     repository.IAddressRepository
+	OrderRepository       repository.IOrderRepository
+	OutboxRepository      repository.IOutboxRepository
+	PreferenceRepository  repository.IPreferenceRepository
+	IdempotencyRepository repository.IIdempotencyRepository
+
+	NotificationService notification.INotificationService
+
+	// Redis is only set with the redis cache backend.
+	Redis         *cache.Redis
+	BookCache     cache.ICache[model.Book]
+	CategoryCache cache.ICache[model.Category]
+
+	OutboxService     service.IOutboxService
+	BookService       service.IBookService
+	CustomerService   service.ICustomerService
+	AddressService    service.IAddressService
+	OrderService      service.IOrderService
+	PrivacyService    service.IPrivacyService
+	PreferenceService service.IPreferenceService
+	HealthService     service.IHealthService
+}
+
+func New(db *sqlx.DB, cfg *config.Config) (*Container, error) {
+	migrations, err := migrator.New(db.DB, dbfs.Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	err = metrics.RegisterDB(db.DB, "postgres")
+	if err != nil {
+		return nil, err
+	}
+
+	authentication.SetSecretKey(cfg.Auth.JWTSecret)
+	validator.Register("shipper", validator.OneOf(cfg.Order.Shippers...))
+	validator.Register("notification_channel", validator.OneOf(cfg.Notification.Channels...))
+
+	c := &Container{
+		Config:                cfg,
+		DB:                    db,
+		BookRepository:        postgresql.NewBookRepository(db),
+		CustomerRepository:    postgresql.NewCustomerRepository(db),
+		MFARepository:         postgresql.NewMFARepository(db),
+		AddressRepository:     postgresql.NewAddressRepository(db),
+		OrderRepository:       postgresql.NewOrderRepository(db),
+		OutboxRepository:      postgresql.NewOutboxRepository(db),
+		PreferenceRepository:  postgresql.NewPreferenceRepository(db),
+		IdempotencyRepository: postgresql.NewIdempotencyRepository(db),
+	}
+
+	//the notifications check the preferences before every send
+	c.PreferenceService = preferenceService.NewPreferenceService(c.PreferenceRepository, c.CustomerRepository, transactioner.NewTransactionProvider(db), cfg)
+	c.NotificationService, err = notification.New(cfg, c.PreferenceService)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheOptions := cache.Options{Size: cfg.Cache.Size, Shards: cfg.Cache.Shards, TTL: cfg.Cache.TTL}
+	if cfg.Cache.Backend == "redis" {
+		redisOptions, err := redis.ParseURL(cfg.Cache.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+		//a slow Redis must not be slower than the database it stands in front of
+		redisOptions.DialTimeout = cfg.Cache.RedisTimeout
+		redisOptions.ReadTimeout = cfg.Cache.RedisTimeout
+		redisOptions.WriteTimeout = cfg.Cache.RedisTimeout
+
+		c.Redis = cache.NewRedis(redis.NewClient(redisOptions))
+		c.BookCache = cache.NewRedisCache[model.Book](c.Redis, "books", cacheOptions)
+		c.CategoryCache = cache.NewRedisCache[model.Category](c.Redis, "categories", cacheOptions)
+	} else {
+		c.BookCache = cache.NewMemory[model.Book]("books", cacheOptions)
+		c.CategoryCache = cache.NewMemory[model.Category]("categories", cacheOptions)
+	}
+
+	var oidcProvider oidc.IProvider
+	if cfg.OIDC.Enabled {
+		oidcProvider = oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+		})
+	}
+
+	c.OutboxService = outboxService.NewOutboxService(c.OutboxRepository, c.NotificationService, cfg)
+	c.BookService = bookService.NewBookService(c.BookRepository, transactioner.NewTransactionProvider(db), c.BookCache, c.CategoryCache)
+	c.CustomerService = customerService.NewCustomerService(c.CustomerRepository, c.MFARepository, transactioner.NewTransactionProvider(db), c.OutboxService, oidcProvider, cfg)
+	c.AddressService = addressService.NewAddressService(c.AddressRepository, transactioner.NewTransactionProvider(db))
+	c.OrderService = orderService.NewOrderService(c.OrderRepository, c.AddressRepository, c.CustomerRepository, c.BookRepository, c.IdempotencyRepository, c.BookCache, transactioner.NewTransactionProvider(db), c.NotificationService, c.OutboxService, cfg)
+	c.PrivacyService = privacyService.NewPrivacyService(c.CustomerRepository, c.MFARepository, c.AddressRepository, c.OrderRepository, c.PreferenceRepository, c.IdempotencyRepository, transactioner.NewTransactionProvider(db), c.CustomerService, c.OrderService, c.OutboxService, cfg)
+	c.HealthService = healthService.NewHealthService(db, migrations, cfg)
+
+	return c, nil
+}
+
+// Close releases the connections opened by New, except the database.
+func (c *Container) Close() error {
+	if c.Redis != nil {
+		return c.Redis.Close()
+	}
+
+	return nil
+}
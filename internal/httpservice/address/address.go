@@ -0,0 +1,132 @@
+package address
+
+import (
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+	"ebookstore/internal/validator"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AddressHandler struct {
+	addressService service.IAddressService
+}
+
+func NewAddressHandler(addressService service.IAddressService) *AddressHandler {
+	return &AddressHandler{
+		addressService: addressService,
+	}
+}
+
+func (h *AddressHandler) GetAddresses(c *fiber.Ctx) error {
+	addresses, err := h.addressService.GetAddresses(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.GetAddresses{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       addresses,
+	})
+}
+
+func (h *AddressHandler) CreateAddress(c *fiber.Ctx) error {
+	req := request.Address{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid request body")
+	}
+
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	data, err := h.addressService.CreateAddress(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Address{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       data,
+	})
+}
+
+func (h *AddressHandler) UpdateAddress(c *fiber.Ctx) error {
+	id, err := addressID(c)
+	if err != nil {
+		return err
+	}
+
+	req := request.Address{}
+	err = c.BodyParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid request body")
+	}
+
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	data, err := h.addressService.UpdateAddress(c.UserContext(), id, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Address{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       data,
+	})
+}
+
+func (h *AddressHandler) SetDefaultAddress(c *fiber.Ctx) error {
+	id, err := addressID(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := h.addressService.SetDefaultAddress(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Address{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       data,
+	})
+}
+
+func (h *AddressHandler) DeleteAddress(c *fiber.Ctx) error {
+	id, err := addressID(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.addressService.DeleteAddress(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Address{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+	})
+}
+
+func addressID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, apperror.Validation("invalid address id", apperror.FieldError{Field: "id", Message: "must be a positive number"})
+	}
+
+	return uint(id), nil
+}
@@ -0,0 +1,169 @@
+package address_test
+
+import (
+	"bytes"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/httpservice/address"
+	"ebookstore/internal/httpservice/httperror"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+	"ebookstore/internal/service/mocks"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAddressHandler_CreateAddress(t *testing.T) {
+	req := request.Address{
+		Label:      "home",
+		Address:    "address",
+		City:       "city",
+		District:   "district",
+		PostalCode: "12345",
+	}
+
+	data := response.AddressData{
+		ID:         1,
+		Label:      "home",
+		Address:    "address",
+		City:       "city",
+		District:   "district",
+		PostalCode: "12345",
+		IsDefault:  true,
+	}
+
+	type fields struct {
+		addressService service.IAddressService
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		request    request.Address
+		wantStatus int
+		wantMsg    string
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				addressService: func() *mocks.IAddressService {
+					m := mocks.IAddressService{}
+					m.On("CreateAddress", mock.Anything, req).Return(data, nil)
+					return &m
+				}(),
+			},
+			request:    req,
+			wantStatus: 200,
+			wantMsg:    "success",
+		},
+		{
+			name: "invalid request, city empty",
+			request: request.Address{
+				Address: "address",
+			},
+			wantStatus: 400,
+			wantMsg:    "city",
+		},
+		{
+			name: "CreateAddress error",
+			fields: fields{
+				addressService: func() *mocks.IAddressService {
+					m := mocks.IAddressService{}
+					m.On("CreateAddress", mock.Anything, req).Return(response.AddressData{}, errors.New("CreateAddress error"))
+					return &m
+				}(),
+			},
+			request:    req,
+			wantStatus: 500,
+			wantMsg:    "error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := address.NewAddressHandler(tt.fields.addressService)
+			bodyBytes, _ := json.Marshal(tt.request)
+
+			req := httptest.NewRequest("POST", "/addresses", bytes.NewBuffer(bodyBytes))
+			req.Header.Add("Content-Type", "application/json")
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Post("/addresses", h.CreateAddress)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			mockResp := response.Address{}
+			json.Unmarshal(bodyRespBytes, &mockResp)
+
+			assert.Contains(t, mockResp.Message, tt.wantMsg)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestAddressHandler_DeleteAddress(t *testing.T) {
+	type fields struct {
+		addressService service.IAddressService
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		path       string
+		wantStatus int
+		wantMsg    string
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				addressService: func() *mocks.IAddressService {
+					m := mocks.IAddressService{}
+					m.On("DeleteAddress", mock.Anything, uint(3)).Return(nil)
+					return &m
+				}(),
+			},
+			path:       "/addresses/3",
+			wantStatus: 200,
+			wantMsg:    "success",
+		},
+		{
+			name:       "invalid id",
+			path:       "/addresses/abc",
+			wantStatus: 400,
+			wantMsg:    "invalid address id",
+		},
+		{
+			name: "DeleteAddress error",
+			fields: fields{
+				addressService: func() *mocks.IAddressService {
+					m := mocks.IAddressService{}
+					m.On("DeleteAddress", mock.Anything, uint(3)).Return(apperror.NotFound("address not found"))
+					return &m
+				}(),
+			},
+			path:       "/addresses/3",
+			wantStatus: 404,
+			wantMsg:    "not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := address.NewAddressHandler(tt.fields.addressService)
+
+			req := httptest.NewRequest("DELETE", tt.path, nil)
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Delete("/addresses/:id", h.DeleteAddress)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			mockResp := response.Address{}
+			json.Unmarshal(bodyRespBytes, &mockResp)
+
+			assert.Contains(t, mockResp.Message, tt.wantMsg)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
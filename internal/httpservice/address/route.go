@@ -0,0 +1,12 @@
+package address
+
+import "github.com/gofiber/fiber/v2"
+
+func (h *AddressHandler) SetupRoutes(app *fiber.App, auth fiber.Handler) {
+	addressGroup := app.Group("/api/customer/me/addresses")
+	addressGroup.Get("/", auth, h.GetAddresses)
+	addressGroup.Post("/", auth, h.CreateAddress)
+	addressGroup.Put("/:id", auth, h.UpdateAddress)
+	addressGroup.Delete("/:id", auth, h.DeleteAddress)
+	addressGroup.Post("/:id/default", auth, h.SetDefaultAddress)
+}
@@ -1,8 +1,11 @@
 package book
 
 import (
+	"ebookstore/internal/apperror"
 	"ebookstore/internal/model/response"
 	"ebookstore/internal/service"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -17,13 +20,18 @@ func NewBookHandler(bookService service.IBookService) *BookHandler {
 	}
 }
 
+// GetBooks lists every book, or searches title, author and category when the
+// q query parameter is set.
 func (h *BookHandler) GetBooks(c *fiber.Ctx) error {
-	books, err := h.bookService.GetBooks(c.Context())
+	var books []response.Book
+	var err error
+	if query := strings.TrimSpace(c.Query("q")); query != "" {
+		books, err = h.bookService.SearchBooks(c.UserContext(), query)
+	} else {
+		books, err = h.bookService.GetBooks(c.UserContext())
+	}
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(response.GetBooks{
-			StatusCode: fiber.StatusInternalServerError,
-			Message:    err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response.GetBooks{
@@ -32,3 +40,23 @@ func (h *BookHandler) GetBooks(c *fiber.Ctx) error {
 		Data:       books,
 	})
 }
+
+// InvalidateCache drops the cached book of the id parameter, or every cached
+// book and category without it, e.g. after a price was changed in the database.
+func (h *BookHandler) InvalidateCache(c *fiber.Ctx) error {
+	var bookIDs []uint
+	if param := c.Params("id"); param != "" {
+		id, err := strconv.ParseUint(param, 10, 64)
+		if err != nil || id == 0 {
+			return apperror.Validation("invalid book id", apperror.FieldError{Field: "id", Message: "must be a positive number"})
+		}
+		bookIDs = append(bookIDs, uint(id))
+	}
+
+	h.bookService.InvalidateCache(c.UserContext(), bookIDs...)
+
+	return c.Status(fiber.StatusOK).JSON(response.InvalidateCache{
+		StatusCode: fiber.StatusOK,
+		Message:    "cache invalidated",
+	})
+}
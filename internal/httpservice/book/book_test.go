@@ -2,6 +2,7 @@ package book_test
 
 import (
 	"ebookstore/internal/httpservice/book"
+	"ebookstore/internal/httpservice/httperror"
 	"ebookstore/internal/model/response"
 	"ebookstore/internal/service/mocks"
 	"errors"
@@ -15,7 +16,7 @@ import (
 
 func TestBookHandler_GetBooks(t *testing.T) {
 	type fields struct {
-		bookService mocks.IBookService
+		bookService *mocks.IBookService
 	}
 	type args struct {
 		c *fiber.Ctx
@@ -24,12 +25,13 @@ func TestBookHandler_GetBooks(t *testing.T) {
 		name       string
 		fields     fields
 		args       args
+		target     string
 		wantStatus int
 	}{
 		{
 			name: "best case",
 			fields: fields{
-				bookService: func() mocks.IBookService {
+				bookService: func() *mocks.IBookService {
 					m := mocks.IBookService{}
 					m.On("GetBooks", mock.Anything).Return([]response.Book{
 						{
@@ -40,7 +42,7 @@ func TestBookHandler_GetBooks(t *testing.T) {
 							Category: "category",
 						},
 					}, nil)
-					return m
+					return &m
 				}(),
 			},
 			args: args{
@@ -51,10 +53,10 @@ func TestBookHandler_GetBooks(t *testing.T) {
 		{
 			name: "GetBooks error",
 			fields: fields{
-				bookService: func() mocks.IBookService {
+				bookService: func() *mocks.IBookService {
 					m := mocks.IBookService{}
 					m.On("GetBooks", mock.Anything).Return([]response.Book{}, errors.New("error"))
-					return m
+					return &m
 				}(),
 			},
 			args: args{
@@ -62,13 +64,32 @@ func TestBookHandler_GetBooks(t *testing.T) {
 			},
 			wantStatus: 500,
 		},
+		{
+			name: "search",
+			fields: fields{
+				bookService: func() *mocks.IBookService {
+					m := mocks.IBookService{}
+					m.On("SearchBooks", mock.Anything, "tolkien").Return([]response.Book{}, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				c: &fiber.Ctx{},
+			},
+			target:     "/book?q=tolkien",
+			wantStatus: 200,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			h := book.NewBookHandler(&tt.fields.bookService)
-			req := httptest.NewRequest("GET", "/book", nil)
+			h := book.NewBookHandler(tt.fields.bookService)
+			target := tt.target
+			if target == "" {
+				target = "/book"
+			}
+			req := httptest.NewRequest("GET", target, nil)
 			req.Header.Add("Content-Type", "application/json")
-			srv := fiber.New()
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
 			srv.Get("/book", h.GetBooks)
 
 			resp, err := srv.Test(req, 1000)
@@ -77,6 +98,58 @@ func TestBookHandler_GetBooks(t *testing.T) {
 			}
 
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			tt.fields.bookService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBookHandler_InvalidateCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		bookService *mocks.IBookService
+		target      string
+		wantStatus  int
+	}{
+		{
+			name: "every book",
+			bookService: func() *mocks.IBookService {
+				m := mocks.IBookService{}
+				m.On("InvalidateCache", mock.Anything).Return()
+				return &m
+			}(),
+			target:     "/api/admin/books/cache",
+			wantStatus: 200,
+		},
+		{
+			name: "one book",
+			bookService: func() *mocks.IBookService {
+				m := mocks.IBookService{}
+				m.On("InvalidateCache", mock.Anything, uint(3)).Return()
+				return &m
+			}(),
+			target:     "/api/admin/books/3/cache",
+			wantStatus: 200,
+		},
+		{
+			name:        "invalid id",
+			bookService: &mocks.IBookService{},
+			target:      "/api/admin/books/abc/cache",
+			wantStatus:  400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := book.NewBookHandler(tt.bookService)
+			req := httptest.NewRequest("DELETE", tt.target, nil)
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Delete("/api/admin/books/cache", h.InvalidateCache)
+			srv.Delete("/api/admin/books/:id/cache", h.InvalidateCache)
+
+			resp, err := srv.Test(req, 1000)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			tt.bookService.AssertExpectations(t)
 		})
 	}
 }
@@ -4,7 +4,10 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func (h *BookHandler) SetupRoutes(app *fiber.App) {
+func (h *BookHandler) SetupRoutes(app *fiber.App, auth, admin fiber.Handler) {
 	bookGroup := app.Group("/api/book")
 	bookGroup.Get("/", h.GetBooks)
+
+	app.Delete("/api/admin/books/cache", auth, admin, h.InvalidateCache)
+	app.Delete("/api/admin/books/:id/cache", auth, admin, h.InvalidateCache)
 }
@@ -1,16 +1,18 @@
 package customer
 
 import (
+	"ebookstore/internal/apperror"
 	"ebookstore/internal/model/request"
 	"ebookstore/internal/model/response"
 	"ebookstore/internal/service"
-	"errors"
-	"regexp"
-	"unicode"
+	"ebookstore/internal/validator"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const oidcStateCookie = "oidc_state"
+
 type CustomerHandler struct {
 	customerService service.ICustomerService
 }
@@ -25,26 +27,17 @@ func (h *CustomerHandler) Register(c *fiber.Ctx) error {
 	customer := request.Register{}
 	err := c.BodyParser(&customer)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(response.Customer{
-			StatusCode: fiber.StatusBadRequest,
-			Message:    err.Error(),
-		})
+		return apperror.Validation("invalid request body")
 	}
 
-	err = isValidUsernameEmailPassword(customer)
+	err = validator.Struct(customer)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(response.Customer{
-			StatusCode: fiber.StatusBadRequest,
-			Message:    err.Error(),
-		})
+		return err
 	}
 
-	token, err := h.customerService.Register(c.Context(), customer)
+	token, err := h.customerService.Register(c.UserContext(), customer)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(response.Customer{
-			StatusCode: fiber.StatusInternalServerError,
-			Message:    err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response.Customer{
@@ -58,20 +51,52 @@ func (h *CustomerHandler) Login(c *fiber.Ctx) error {
 	login := request.Login{}
 	err := c.BodyParser(&login)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(response.Customer{
-			StatusCode: fiber.StatusBadRequest,
-			Message:    err.Error(),
-		})
+		return apperror.Validation("invalid request body")
+	}
+
+	err = validator.Struct(login)
+	if err != nil {
+		return err
 	}
 
-	token, err := h.customerService.Login(c.Context(), login)
+	data, err := h.customerService.Login(c.UserContext(), login)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(response.Customer{
-			StatusCode: fiber.StatusInternalServerError,
-			Message:    err.Error(),
+		return err
+	}
+
+	if data.MFAToken != "" {
+		return c.Status(fiber.StatusOK).JSON(response.Customer{
+			StatusCode:  fiber.StatusOK,
+			Message:     "mfa required",
+			MFARequired: true,
+			MFAToken:    data.MFAToken,
 		})
 	}
 
+	return c.Status(fiber.StatusOK).JSON(response.Customer{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Token:      data.Token,
+	})
+}
+
+func (h *CustomerHandler) VerifyMFALogin(c *fiber.Ctx) error {
+	req := request.LoginMFA{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid request body")
+	}
+
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	token, err := h.customerService.VerifyMFALogin(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
 	return c.Status(fiber.StatusOK).JSON(response.Customer{
 		StatusCode: fiber.StatusOK,
 		Message:    "success",
@@ -79,69 +104,191 @@ func (h *CustomerHandler) Login(c *fiber.Ctx) error {
 	})
 }
 
-func isValidUsernameEmailPassword(customer request.Register) error {
-	if !isValidUsername(customer.Username) {
-		return errors.New("invalid username, between 4 and 16 characters in length contains only alphanumeric characters or underscores")
+// OIDCLogin redirects to the identity provider, the PKCE verifier and nonce
+// travel in a signed cookie that only the callback reads.
+func (h *CustomerHandler) OIDCLogin(c *fiber.Ctx) error {
+	data, err := h.customerService.OIDCLogin(c.UserContext())
+	if err != nil {
+		return err
 	}
 
-	if !isValidEmail(customer.Email) {
-		return errors.New("invalid email combination")
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    data.StateToken,
+		Path:     "/api/customer/oidc",
+		Expires:  time.Now().Add(10 * time.Minute),
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.Redirect(data.AuthURL, fiber.StatusFound)
+}
+
+func (h *CustomerHandler) OIDCCallback(c *fiber.Ctx) error {
+	req := request.OIDCCallback{}
+	err := c.QueryParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid request body")
+	}
+
+	req.StateToken = c.Cookies(oidcStateCookie)
+	c.ClearCookie(oidcStateCookie)
+
+	data, err := h.customerService.OIDCCallback(c.UserContext(), req)
+	if err != nil {
+		return err
 	}
 
-	if !isValidPassword(customer.Password) {
-		return errors.New("invalid password, at least 8 characters in length contains at least one lowercase letter, one uppercase letter, one digit, and one special character")
+	if data.MFAToken != "" {
+		return c.Status(fiber.StatusOK).JSON(response.Customer{
+			StatusCode:  fiber.StatusOK,
+			Message:     "mfa required",
+			MFARequired: true,
+			MFAToken:    data.MFAToken,
+		})
 	}
 
-	return nil
+	return c.Status(fiber.StatusOK).JSON(response.Customer{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Token:      data.Token,
+	})
 }
 
-func isValidUsername(username string) bool {
-	// Validate username format
-	match, err := regexp.MatchString("^[a-zA-Z0-9_]{4,16}$", username)
+func (h *CustomerHandler) EnrollMFA(c *fiber.Ctx) error {
+	data, err := h.customerService.EnrollMFA(c.UserContext())
 	if err != nil {
-		return false
+		return err
 	}
 
-	return match
+	return c.Status(fiber.StatusOK).JSON(response.MFAEnrollment{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       data,
+	})
 }
 
-// CheckPasswordStrength checks if a password meets the following criteria:
-// - At least 8 characters in length
-// - Contains at least one lowercase letter, one uppercase letter, one digit, and one special character
-func isValidPassword(password string) bool {
-	var (
-		hasLower   = false
-		hasUpper   = false
-		hasDigit   = false
-		hasSpecial = false
-	)
-
-	if len(password) < 8 {
-		return false
-	}
-
-	for _, c := range password {
-		switch {
-		case unicode.IsLower(c):
-			hasLower = true
-		case unicode.IsUpper(c):
-			hasUpper = true
-		case unicode.IsDigit(c):
-			hasDigit = true
-		case unicode.IsPunct(c) || unicode.IsSymbol(c):
-			hasSpecial = true
-		}
-	}
-
-	return hasLower && hasUpper && hasDigit && hasSpecial
+func (h *CustomerHandler) ActivateMFA(c *fiber.Ctx) error {
+	req := request.MFACode{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid request body")
+	}
+
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	codes, err := h.customerService.ActivateMFA(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.MFARecoveryCodes{
+		StatusCode:    fiber.StatusOK,
+		Message:       "success",
+		RecoveryCodes: codes,
+	})
 }
 
-func isValidEmail(email string) bool {
-	// Validate email format
-	match, err := regexp.MatchString(`^([a-zA-Z0-9_\-\.]+)@([a-zA-Z0-9-]+\.)+([a-zA-Z]{2,10})$`, email)
+func (h *CustomerHandler) DisableMFA(c *fiber.Ctx) error {
+	req := request.MFACode{}
+	err := c.BodyParser(&req)
 	if err != nil {
-		return false
+		return apperror.Validation("invalid request body")
 	}
 
-	return match
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	err = h.customerService.DisableMFA(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Customer{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+	})
+}
+
+func (h *CustomerHandler) GetProfile(c *fiber.Ctx) error {
+	data, err := h.customerService.GetProfile(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Profile{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       data,
+	})
+}
+
+func (h *CustomerHandler) UpdateProfile(c *fiber.Ctx) error {
+	req := request.UpdateProfile{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid request body")
+	}
+
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	data, err := h.customerService.UpdateProfile(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	message := "success"
+	if data.PendingEmail != "" {
+		message = "success, check the new email address to confirm the change"
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Profile{
+		StatusCode: fiber.StatusOK,
+		Message:    message,
+		Data:       data,
+	})
+}
+
+func (h *CustomerHandler) VerifyEmail(c *fiber.Ctx) error {
+	err := h.customerService.VerifyEmail(c.UserContext(), c.Query("token"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Customer{
+		StatusCode: fiber.StatusOK,
+		Message:    "email verified",
+	})
+}
+
+func (h *CustomerHandler) ChangePassword(c *fiber.Ctx) error {
+	req := request.ChangePassword{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid request body")
+	}
+
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	err = h.customerService.ChangePassword(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Customer{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+	})
 }
@@ -2,7 +2,9 @@ package customer_test
 
 import (
 	"bytes"
+	"ebookstore/internal/apperror"
 	"ebookstore/internal/httpservice/customer"
+	"ebookstore/internal/httpservice/httperror"
 	"ebookstore/internal/model/request"
 	"ebookstore/internal/model/response"
 	"ebookstore/internal/service/mocks"
@@ -27,7 +29,7 @@ func TestCustomerHandler_Register(t *testing.T) {
 	mockResp := response.Customer{}
 
 	type fields struct {
-		customerService mocks.ICustomerService
+		customerService *mocks.ICustomerService
 	}
 	type args struct {
 		c       *fiber.Ctx
@@ -43,10 +45,10 @@ func TestCustomerHandler_Register(t *testing.T) {
 		{
 			name: "best case",
 			fields: fields{
-				customerService: func() mocks.ICustomerService {
+				customerService: func() *mocks.ICustomerService {
 					m := mocks.ICustomerService{}
 					m.On("Register", mock.Anything, mock.Anything).Return("token", nil)
-					return m
+					return &m
 				}(),
 			},
 			args: args{
@@ -59,10 +61,10 @@ func TestCustomerHandler_Register(t *testing.T) {
 		{
 			name: "Register error",
 			fields: fields{
-				customerService: func() mocks.ICustomerService {
+				customerService: func() *mocks.ICustomerService {
 					m := mocks.ICustomerService{}
 					m.On("Register", mock.Anything, mock.Anything).Return("", errors.New("error"))
-					return m
+					return &m
 				}(),
 			},
 			args: args{
@@ -111,13 +113,13 @@ func TestCustomerHandler_Register(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			h := customer.NewCustomerHandler(&tt.fields.customerService)
+			h := customer.NewCustomerHandler(tt.fields.customerService)
 			bodyBytes, _ := json.Marshal(tt.args.request)
 			bodyIO := bytes.NewBuffer(bodyBytes)
 
 			req := httptest.NewRequest("POST", "/customer/register", bodyIO)
 			req.Header.Add("Content-Type", "application/json")
-			srv := fiber.New()
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
 			srv.Post("/customer/register", h.Register)
 
 			resp, _ := srv.Test(req, 1000)
@@ -133,7 +135,7 @@ func TestCustomerHandler_Register(t *testing.T) {
 
 func TestCustomerHandler_Login(t *testing.T) {
 	type fields struct {
-		customerService mocks.ICustomerService
+		customerService *mocks.ICustomerService
 	}
 	type args struct {
 		c   *fiber.Ctx
@@ -148,10 +150,25 @@ func TestCustomerHandler_Login(t *testing.T) {
 		{
 			name: "best case",
 			fields: fields{
-				customerService: func() mocks.ICustomerService {
+				customerService: func() *mocks.ICustomerService {
 					m := mocks.ICustomerService{}
-					m.On("Login", mock.Anything, mock.Anything).Return("token", nil)
-					return m
+					m.On("Login", mock.Anything, mock.Anything).Return(response.LoginData{Token: "token"}, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				c:   &fiber.Ctx{},
+				req: request.Login{Email: "username", Password: "Passw0rd."},
+			},
+			wantStatus: 200,
+		},
+		{
+			name: "mfa required",
+			fields: fields{
+				customerService: func() *mocks.ICustomerService {
+					m := mocks.ICustomerService{}
+					m.On("Login", mock.Anything, mock.Anything).Return(response.LoginData{MFAToken: "mfa-token"}, nil)
+					return &m
 				}(),
 			},
 			args: args{
@@ -163,10 +180,10 @@ func TestCustomerHandler_Login(t *testing.T) {
 		{
 			name: "Login error",
 			fields: fields{
-				customerService: func() mocks.ICustomerService {
+				customerService: func() *mocks.ICustomerService {
 					m := mocks.ICustomerService{}
-					m.On("Login", mock.Anything, mock.Anything).Return("", errors.New("error"))
-					return m
+					m.On("Login", mock.Anything, mock.Anything).Return(response.LoginData{}, errors.New("error"))
+					return &m
 				}(),
 			},
 			args: args{
@@ -178,13 +195,13 @@ func TestCustomerHandler_Login(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			h := customer.NewCustomerHandler(&tt.fields.customerService)
+			h := customer.NewCustomerHandler(tt.fields.customerService)
 			bodyBytes, _ := json.Marshal(tt.args.req)
 			bodyIO := bytes.NewBuffer(bodyBytes)
 
 			req := httptest.NewRequest("POST", "/customer/login", bodyIO)
 			req.Header.Add("Content-Type", "application/json")
-			srv := fiber.New()
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
 			srv.Post("/customer/login", h.Login)
 
 			resp, _ := srv.Test(req, 1000)
@@ -193,3 +210,267 @@ func TestCustomerHandler_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomerHandler_VerifyMFALogin(t *testing.T) {
+	mockResp := response.Customer{}
+
+	type fields struct {
+		customerService *mocks.ICustomerService
+	}
+	type args struct {
+		req request.LoginMFA
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantStatus int
+		wantMsg    string
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				customerService: func() *mocks.ICustomerService {
+					m := mocks.ICustomerService{}
+					m.On("VerifyMFALogin", mock.Anything, request.LoginMFA{MFAToken: "mfa-token", Code: "123456"}).Return("token", nil)
+					return &m
+				}(),
+			},
+			args:       args{req: request.LoginMFA{MFAToken: "mfa-token", Code: "123456"}},
+			wantStatus: 200,
+			wantMsg:    "success",
+		},
+		{
+			name:       "empty code",
+			args:       args{req: request.LoginMFA{MFAToken: "mfa-token"}},
+			wantStatus: 400,
+			wantMsg:    "code is required",
+		},
+		{
+			name: "VerifyMFALogin error",
+			fields: fields{
+				customerService: func() *mocks.ICustomerService {
+					m := mocks.ICustomerService{}
+					m.On("VerifyMFALogin", mock.Anything, mock.Anything).Return("", apperror.Unauthorized("invalid mfa code"))
+					return &m
+				}(),
+			},
+			args:       args{req: request.LoginMFA{MFAToken: "mfa-token", Code: "123456"}},
+			wantStatus: 401,
+			wantMsg:    "invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := customer.NewCustomerHandler(tt.fields.customerService)
+			bodyBytes, _ := json.Marshal(tt.args.req)
+			bodyIO := bytes.NewBuffer(bodyBytes)
+
+			req := httptest.NewRequest("POST", "/customer/login/mfa", bodyIO)
+			req.Header.Add("Content-Type", "application/json")
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Post("/customer/login/mfa", h.VerifyMFALogin)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			json.Unmarshal(bodyRespBytes, &mockResp)
+
+			assert.Contains(t, mockResp.Message, tt.wantMsg)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestCustomerHandler_ActivateMFA(t *testing.T) {
+	mockResp := response.MFARecoveryCodes{}
+
+	type fields struct {
+		customerService *mocks.ICustomerService
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		wantStatus int
+		wantCodes  int
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				customerService: func() *mocks.ICustomerService {
+					m := mocks.ICustomerService{}
+					m.On("ActivateMFA", mock.Anything, request.MFACode{Code: "123456"}).Return([]string{"abcde-fghjk"}, nil)
+					return &m
+				}(),
+			},
+			wantStatus: 200,
+			wantCodes:  1,
+		},
+		{
+			name: "ActivateMFA error",
+			fields: fields{
+				customerService: func() *mocks.ICustomerService {
+					m := mocks.ICustomerService{}
+					m.On("ActivateMFA", mock.Anything, mock.Anything).Return(nil, apperror.Validation("invalid mfa code"))
+					return &m
+				}(),
+			},
+			wantStatus: 400,
+			wantCodes:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockResp = response.MFARecoveryCodes{}
+			h := customer.NewCustomerHandler(tt.fields.customerService)
+			bodyBytes, _ := json.Marshal(request.MFACode{Code: "123456"})
+			bodyIO := bytes.NewBuffer(bodyBytes)
+
+			req := httptest.NewRequest("POST", "/customer/mfa/activate", bodyIO)
+			req.Header.Add("Content-Type", "application/json")
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Post("/customer/mfa/activate", func(c *fiber.Ctx) error {
+				c.Locals("id", uint(1))
+				return c.Next()
+			}, h.ActivateMFA)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			json.Unmarshal(bodyRespBytes, &mockResp)
+
+			assert.Len(t, mockResp.RecoveryCodes, tt.wantCodes)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestCustomerHandler_OIDCCallback(t *testing.T) {
+	mockResp := response.Customer{}
+
+	type fields struct {
+		customerService *mocks.ICustomerService
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		wantStatus int
+		wantMsg    string
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				customerService: func() *mocks.ICustomerService {
+					m := mocks.ICustomerService{}
+					m.On("OIDCCallback", mock.Anything, request.OIDCCallback{Code: "code", State: "state", StateToken: "state-token"}).Return(response.LoginData{Token: "token"}, nil)
+					return &m
+				}(),
+			},
+			wantStatus: 200,
+			wantMsg:    "success",
+		},
+		{
+			name: "OIDCCallback error",
+			fields: fields{
+				customerService: func() *mocks.ICustomerService {
+					m := mocks.ICustomerService{}
+					m.On("OIDCCallback", mock.Anything, mock.Anything).Return(response.LoginData{}, apperror.Unauthorized("invalid login state"))
+					return &m
+				}(),
+			},
+			wantStatus: 401,
+			wantMsg:    "invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := customer.NewCustomerHandler(tt.fields.customerService)
+
+			req := httptest.NewRequest("GET", "/customer/oidc/callback?code=code&state=state", nil)
+			req.Header.Add("Cookie", "oidc_state=state-token")
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Get("/customer/oidc/callback", h.OIDCCallback)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			json.Unmarshal(bodyRespBytes, &mockResp)
+
+			assert.Contains(t, mockResp.Message, tt.wantMsg)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestCustomerHandler_UpdateProfile(t *testing.T) {
+	mockResp := response.Profile{}
+
+	type fields struct {
+		customerService *mocks.ICustomerService
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		req        request.UpdateProfile
+		wantStatus int
+		wantMsg    string
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				customerService: func() *mocks.ICustomerService {
+					m := mocks.ICustomerService{}
+					m.On("UpdateProfile", mock.Anything, request.UpdateProfile{Email: "new@mail.com"}).Return(response.ProfileData{ID: 1, Email: "old@mail.com", PendingEmail: "new@mail.com"}, nil)
+					return &m
+				}(),
+			},
+			req:        request.UpdateProfile{Email: "new@mail.com"},
+			wantStatus: 200,
+			wantMsg:    "confirm",
+		},
+		{
+			name:       "empty request",
+			req:        request.UpdateProfile{},
+			wantStatus: 400,
+			wantMsg:    "required",
+		},
+		{
+			name:       "invalid username",
+			req:        request.UpdateProfile{Username: "inv"},
+			wantStatus: 400,
+			wantMsg:    "username",
+		},
+		{
+			name: "UpdateProfile error",
+			fields: fields{
+				customerService: func() *mocks.ICustomerService {
+					m := mocks.ICustomerService{}
+					m.On("UpdateProfile", mock.Anything, mock.Anything).Return(response.ProfileData{}, errors.New("error"))
+					return &m
+				}(),
+			},
+			req:        request.UpdateProfile{Username: "username"},
+			wantStatus: 500,
+			wantMsg:    "error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := customer.NewCustomerHandler(tt.fields.customerService)
+			bodyBytes, _ := json.Marshal(tt.req)
+			bodyIO := bytes.NewBuffer(bodyBytes)
+
+			req := httptest.NewRequest("PATCH", "/customer/me", bodyIO)
+			req.Header.Add("Content-Type", "application/json")
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Patch("/customer/me", func(c *fiber.Ctx) error {
+				c.Locals("id", uint(1))
+				return c.Next()
+			}, h.UpdateProfile)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			json.Unmarshal(bodyRespBytes, &mockResp)
+
+			assert.Contains(t, mockResp.Message, tt.wantMsg)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
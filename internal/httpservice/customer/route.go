@@ -2,8 +2,20 @@ package customer
 
 import "github.com/gofiber/fiber/v2"
 
-func (h *CustomerHandler) SetupRoutes(app *fiber.App) {
+func (h *CustomerHandler) SetupRoutes(app *fiber.App, auth fiber.Handler) {
 	orderGroup := app.Group("/api/customer")
 	orderGroup.Post("/register", h.Register)
 	orderGroup.Post("/login", h.Login)
+	orderGroup.Post("/login/mfa", h.VerifyMFALogin)
+	orderGroup.Get("/oidc/login", h.OIDCLogin)
+	orderGroup.Get("/oidc/callback", h.OIDCCallback)
+	orderGroup.Get("/email/verify", h.VerifyEmail)
+
+	orderGroup.Get("/me", auth, h.GetProfile)
+	orderGroup.Patch("/me", auth, h.UpdateProfile)
+	orderGroup.Post("/me/password", auth, h.ChangePassword)
+
+	orderGroup.Post("/mfa/enroll", auth, h.EnrollMFA)
+	orderGroup.Post("/mfa/activate", auth, h.ActivateMFA)
+	orderGroup.Post("/mfa/disable", auth, h.DisableMFA)
 }
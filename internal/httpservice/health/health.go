@@ -0,0 +1,33 @@
+package health
+
+import (
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthHandler struct {
+	healthService service.IHealthService
+}
+
+func NewHealthHandler(healthService service.IHealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Live only tells that the process is up and serving, it never checks
+// dependencies so a database outage does not get the pod restarted.
+func (h *HealthHandler) Live(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(response.Health{Status: "ok"})
+}
+
+func (h *HealthHandler) Ready(c *fiber.Ctx) error {
+	resp, ready := h.healthService.Ready(c.UserContext())
+	if !ready {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(resp)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
@@ -0,0 +1,63 @@
+package health_test
+
+import (
+	"ebookstore/internal/httpservice/health"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service/mocks"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHealthHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		healthService *mocks.IHealthService
+		target        string
+		wantStatus    int
+	}{
+		{
+			name:          "live",
+			healthService: &mocks.IHealthService{},
+			target:        "/healthz",
+			wantStatus:    200,
+		},
+		{
+			name: "ready",
+			healthService: func() *mocks.IHealthService {
+				m := mocks.IHealthService{}
+				m.On("Ready", mock.Anything).Return(response.Readiness{Status: "ok"}, true)
+				return &m
+			}(),
+			target:     "/readyz",
+			wantStatus: 200,
+		},
+		{
+			name: "not ready",
+			healthService: func() *mocks.IHealthService {
+				m := mocks.IHealthService{}
+				m.On("Ready", mock.Anything).Return(response.Readiness{Status: "unavailable"}, false)
+				return &m
+			}(),
+			target:     "/readyz",
+			wantStatus: 503,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := health.NewHealthHandler(tt.healthService)
+			srv := fiber.New()
+			h.SetupRoutes(srv)
+
+			resp, err := srv.Test(httptest.NewRequest("GET", tt.target, nil), 1000)
+			if err != nil {
+				println(err)
+			}
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package health
+
+import "github.com/gofiber/fiber/v2"
+
+func (h *HealthHandler) SetupRoutes(app *fiber.App) {
+	app.Get("/healthz", h.Live)
+	app.Get("/readyz", h.Ready)
+}
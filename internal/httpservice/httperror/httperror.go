@@ -0,0 +1,71 @@
+// Package httperror turns the errors returned by handlers into JSON responses.
+package httperror
+
+import (
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model/response"
+	"ebookstore/utils/logger"
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const internalMessage = "internal server error"
+
+// Handler writes the error response for err. Domain errors keep their message,
+// anything else is logged and answered with a generic 500.
+func Handler(c *fiber.Ctx, err error) error {
+	status := Status(err)
+	body := response.Error{
+		StatusCode: status,
+		Message:    err.Error(),
+		RequestID:  logger.RequestID(c.UserContext()),
+	}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		body.Errors = appErr.Fields
+	}
+
+	if status >= fiber.StatusInternalServerError {
+		slog.ErrorContext(c.UserContext(), "request failed", "error", err)
+		body.Message = internalMessage
+	}
+
+	return c.Status(status).JSON(body)
+}
+
+// Middleware answers errors right where the handlers return them, so the
+// logging and metrics middlewares around it see the final status code.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err != nil {
+			return Handler(c, err)
+		}
+
+		return nil
+	}
+}
+
+// Status maps an error to its HTTP status code.
+func Status(err error) int {
+	var fiberErr *fiber.Error
+	switch {
+	case errors.Is(err, apperror.ErrValidation):
+		return fiber.StatusBadRequest
+	case errors.Is(err, apperror.ErrUnauthorized):
+		return fiber.StatusUnauthorized
+	case errors.Is(err, apperror.ErrForbidden):
+		return fiber.StatusForbidden
+	case errors.Is(err, apperror.ErrNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, apperror.ErrConflict):
+		return fiber.StatusConflict
+	case errors.As(err, &fiberErr):
+		return fiberErr.Code
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
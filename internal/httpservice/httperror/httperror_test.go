@@ -0,0 +1,111 @@
+package httperror_test
+
+import (
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/httpservice/httperror"
+	"ebookstore/internal/model/response"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantMsg    string
+		wantFields []apperror.FieldError
+	}{
+		{
+			name:       "validation",
+			err:        apperror.Validation("invalid address", apperror.FieldError{Field: "city", Message: "cannot be empty"}),
+			wantStatus: 400,
+			wantMsg:    "invalid address",
+			wantFields: []apperror.FieldError{{Field: "city", Message: "cannot be empty"}},
+		},
+		{
+			name:       "unauthorized",
+			err:        apperror.Unauthorized("invalid password"),
+			wantStatus: 401,
+			wantMsg:    "invalid password",
+		},
+		{
+			name:       "forbidden",
+			err:        apperror.Forbidden("invalid current password"),
+			wantStatus: 403,
+			wantMsg:    "invalid current password",
+		},
+		{
+			name:       "not found",
+			err:        apperror.NotFound("book 1 not found"),
+			wantStatus: 404,
+			wantMsg:    "book 1 not found",
+		},
+		{
+			name:       "wrapped conflict",
+			err:        fmt.Errorf("failed to register: %w", apperror.Conflict("email already exists")),
+			wantStatus: 409,
+			wantMsg:    "failed to register: email already exists",
+		},
+		{
+			name:       "fiber error",
+			err:        fiber.NewError(fiber.StatusUnauthorized, "invalid token"),
+			wantStatus: 401,
+			wantMsg:    "invalid token",
+		},
+		{
+			name:       "internal error is hidden",
+			err:        errors.New("pq: connection refused"),
+			wantStatus: 500,
+			wantMsg:    "internal server error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Get("/", func(c *fiber.Ctx) error {
+				return tt.err
+			})
+
+			resp, _ := srv.Test(httptest.NewRequest("GET", "/", nil), 1000)
+
+			var body response.Error
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			json.Unmarshal(bodyBytes, &body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantStatus, body.StatusCode)
+			assert.Equal(t, tt.wantMsg, body.Message)
+			assert.Equal(t, tt.wantFields, body.Errors)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var seenStatus int
+	var seenErr error
+
+	srv := fiber.New()
+	srv.Use(func(c *fiber.Ctx) error {
+		seenErr = c.Next()
+		seenStatus = c.Response().StatusCode()
+		return seenErr
+	})
+	srv.Use(httperror.Middleware())
+	srv.Get("/", func(c *fiber.Ctx) error {
+		return apperror.NotFound("order not found")
+	})
+
+	resp, _ := srv.Test(httptest.NewRequest("GET", "/", nil), 1000)
+
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, 404, seenStatus)
+	assert.NoError(t, seenErr)
+}
@@ -1,15 +1,19 @@
 package order
 
 import (
+	"ebookstore/internal/apperror"
 	"ebookstore/internal/model/request"
 	"ebookstore/internal/model/response"
 	"ebookstore/internal/service"
-	"errors"
+	"ebookstore/internal/validator"
 	"fmt"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const defaultLimit = 20
+
 type OrderHandler struct {
 	orderService service.IOrderService
 }
@@ -25,27 +29,24 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 
 	err := c.BodyParser(&req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(response.Order{
-			StatusCode: fiber.StatusInternalServerError,
-			Message:    err.Error(),
-		})
+		return apperror.Validation("invalid request body")
 	}
 
-	err = isValidCreateOrderReq(req)
+	err = validator.Struct(req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(response.Order{
-			StatusCode: fiber.StatusBadRequest,
-			Message:    err.Error(),
-		})
+		return err
+	}
+
+	//a client retrying after a timeout sends the same key to not order twice
+	idempotencyKey := c.Get("Idempotency-Key")
+	if len(idempotencyKey) > 255 {
+		return apperror.Validation("invalid idempotency key", apperror.FieldError{Field: "Idempotency-Key", Message: "must be at most 255 characters"})
 	}
 
-	ctx := c.Context()
-	data, err := h.orderService.CreateOrder(ctx, req)
+	ctx := c.UserContext()
+	data, err := h.orderService.CreateOrder(ctx, req, idempotencyKey)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(response.Order{
-			StatusCode: fiber.StatusInternalServerError,
-			Message:    err.Error(),
-		})
+		return err
 	}
 
 	customer := c.Locals("username").(string)
@@ -56,52 +57,106 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 	})
 }
 
+// GetUserOrders lists a page of the customer's orders, newest first. The
+// next_cursor of the response requests the following page.
 func (h *OrderHandler) GetUserOrders(c *fiber.Ctx) error {
-	orders, err := h.orderService.GetUserOrders(c.Context())
+	req := request.GetOrderHistory{}
+	err := c.QueryParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid query")
+	}
+
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	}
+
+	orders, nextCursor, err := h.orderService.GetUserOrders(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(response.GetUserOrders{
-			StatusCode: fiber.StatusInternalServerError,
-			Message:    err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response.GetUserOrders{
 		StatusCode: fiber.StatusOK,
 		Message:    "success",
 		Data:       orders,
+		NextCursor: nextCursor,
+	})
+}
+
+func (h *OrderHandler) GetUserOrder(c *fiber.Ctx) error {
+	reference := c.Params("reference")
+	if len(reference) > 255 {
+		return apperror.Validation("invalid order reference", apperror.FieldError{Field: "reference", Message: "must be at most 255 characters"})
+	}
+
+	order, err := h.orderService.GetUserOrder(c.UserContext(), reference)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.GetUserOrder{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       order,
 	})
 }
 
-func isValidCreateOrderReq(req request.CreateOrder) error {
-	if len(req.Items) == 0 {
-		return errors.New("items cannot be empty")
+// ValidateReference lets support check a customer reference or airwaybill
+// number read out by a customer for typos.
+func (h *OrderHandler) ValidateReference(c *fiber.Ctx) error {
+	value := c.Params("reference")
+	if len(value) > 255 {
+		return apperror.Validation("invalid reference", apperror.FieldError{Field: "reference", Message: "must be at most 255 characters"})
 	}
 
-	for _, item := range req.Items {
-		if item.BookID == 0 {
-			return errors.New("book id cannot be empty")
-		}
+	return c.Status(fiber.StatusOK).JSON(response.ValidateReference{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       h.orderService.ValidateReference(c.UserContext(), value),
+	})
+}
 
-		if item.Quantity == 0 {
-			return errors.New("quantity cannot be empty")
-		}
+// PreviewOrderConfirmation shows the confirmation email of an order as it
+// would be sent.
+func (h *OrderHandler) PreviewOrderConfirmation(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil || id == 0 {
+		return apperror.Validation("invalid order id", apperror.FieldError{Field: "id", Message: "must be a positive number"})
 	}
 
-	if req.Address == "" {
-		return errors.New("receiver address cannot be empty")
+	req := request.PreviewEmail{}
+	err = c.QueryParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid query")
 	}
 
-	if req.City == "" {
-		return errors.New("receiver city cannot be empty")
+	err = validator.Struct(req)
+	if err != nil {
+		return err
 	}
 
-	if req.District == "" {
-		return errors.New("receiver district cannot be empty")
+	data, err := h.orderService.PreviewOrderConfirmation(c.UserContext(), uint(id), req.Locale)
+	if err != nil {
+		return err
 	}
 
-	if req.PostalCode == "" {
-		return errors.New("receiver postal code cannot be empty")
+	switch req.Format {
+	case "html":
+		c.Type("html", "utf-8")
+		return c.Status(fiber.StatusOK).SendString(data.HTML)
+	case "text":
+		c.Type("txt", "utf-8")
+		return c.Status(fiber.StatusOK).SendString(data.Text)
 	}
 
-	return nil
+	return c.Status(fiber.StatusOK).JSON(response.PreviewEmail{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       data,
+	})
 }
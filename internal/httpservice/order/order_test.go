@@ -2,15 +2,19 @@ package order_test
 
 import (
 	"bytes"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/httpservice/httperror"
 	"ebookstore/internal/httpservice/order"
 	"ebookstore/internal/model/request"
 	"ebookstore/internal/model/response"
 	"ebookstore/internal/service"
 	"ebookstore/internal/service/mocks"
+	"ebookstore/internal/validator"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,6 +24,8 @@ import (
 )
 
 func TestOrderHandler_CreateOrder(t *testing.T) {
+	validator.Register("shipper", validator.OneOf("shipper"))
+
 	req := request.CreateOrder{
 		ReceiverName: "hamzah",
 		Address:      "address",
@@ -35,6 +41,12 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 		},
 	}
 
+	reqWithAddress := request.CreateOrder{
+		AddressID: 1,
+		Shipper:   "shipper",
+		Items:     req.Items,
+	}
+
 	data := response.CreateOrderData{
 		OrderID:           1,
 		CustomerReference: "customerReference",
@@ -53,8 +65,9 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 	}
 
 	type args struct {
-		request     request.CreateOrder
-		authHandler fiber.Handler
+		request        request.CreateOrder
+		idempotencyKey string
+		authHandler    fiber.Handler
 	}
 	tests := []struct {
 		name       string
@@ -68,7 +81,7 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			fields: fields{
 				orderService: func() *mocks.IOrderService {
 					m := mocks.IOrderService{}
-					m.On("CreateOrder", mock.Anything, req).Return(data, nil)
+					m.On("CreateOrder", mock.Anything, req, "").Return(data, nil)
 					return &m
 				}(),
 			},
@@ -96,6 +109,43 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			wantStatus: 400,
 			wantMsg:    "items",
 		},
+		{
+			name: "invalid request, negative quantity",
+			args: args{
+				authHandler: func(c *fiber.Ctx) error {
+					c.Locals("username", "hamzah")
+					return c.Next()
+				},
+				request: request.CreateOrder{
+					AddressID: 1,
+					Shipper:   "shipper",
+					Items: []request.Item{
+						{
+							BookID:   1,
+							Quantity: -2,
+						},
+					},
+				},
+			},
+			wantStatus: 400,
+			wantMsg:    "items[0].quantity must be at least 1",
+		},
+		{
+			name: "invalid request, unknown shipper",
+			args: args{
+				authHandler: func(c *fiber.Ctx) error {
+					c.Locals("username", "hamzah")
+					return c.Next()
+				},
+				request: request.CreateOrder{
+					AddressID: 1,
+					Shipper:   "unknown",
+					Items:     req.Items,
+				},
+			},
+			wantStatus: 400,
+			wantMsg:    "shipper must be one of shipper",
+		},
 		{
 			name: "invalid request, receiver address empty",
 			args: args{
@@ -181,12 +231,31 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			wantStatus: 400,
 			wantMsg:    "postal",
 		},
+		{
+			name: "saved address",
+			fields: fields{
+				orderService: func() *mocks.IOrderService {
+					m := mocks.IOrderService{}
+					m.On("CreateOrder", mock.Anything, reqWithAddress, "").Return(data, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				authHandler: func(c *fiber.Ctx) error {
+					c.Locals("username", "hamzah")
+					return c.Next()
+				},
+				request: reqWithAddress,
+			},
+			wantStatus: 200,
+			wantMsg:    "success",
+		},
 		{
 			name: "CreateOrder error",
 			fields: fields{
 				orderService: func() *mocks.IOrderService {
 					m := mocks.IOrderService{}
-					m.On("CreateOrder", mock.Anything, req).Return(data, errors.New("CreateOrder error"))
+					m.On("CreateOrder", mock.Anything, req, "").Return(data, errors.New("CreateOrder error"))
 					return &m
 				}(),
 			},
@@ -200,6 +269,59 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			wantStatus: 500,
 			wantMsg:    "error",
 		},
+		{
+			name: "idempotency key",
+			fields: fields{
+				orderService: func() *mocks.IOrderService {
+					m := mocks.IOrderService{}
+					m.On("CreateOrder", mock.Anything, req, "retry-1").Return(data, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				authHandler: func(c *fiber.Ctx) error {
+					c.Locals("username", "hamzah")
+					return c.Next()
+				},
+				request:        req,
+				idempotencyKey: "retry-1",
+			},
+			wantStatus: 200,
+			wantMsg:    "success",
+		},
+		{
+			name: "idempotency key reused for another order",
+			fields: fields{
+				orderService: func() *mocks.IOrderService {
+					m := mocks.IOrderService{}
+					m.On("CreateOrder", mock.Anything, req, "retry-1").Return(response.CreateOrderData{}, apperror.Conflict("idempotency key was already used for another order"))
+					return &m
+				}(),
+			},
+			args: args{
+				authHandler: func(c *fiber.Ctx) error {
+					c.Locals("username", "hamzah")
+					return c.Next()
+				},
+				request:        req,
+				idempotencyKey: "retry-1",
+			},
+			wantStatus: 409,
+			wantMsg:    "idempotency key",
+		},
+		{
+			name: "idempotency key too long",
+			args: args{
+				authHandler: func(c *fiber.Ctx) error {
+					c.Locals("username", "hamzah")
+					return c.Next()
+				},
+				request:        req,
+				idempotencyKey: strings.Repeat("a", 256),
+			},
+			wantStatus: 400,
+			wantMsg:    "invalid idempotency key",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -209,7 +331,10 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 
 			req := httptest.NewRequest("POST", "/order", bodyIO)
 			req.Header.Add("Content-Type", "application/json")
-			srv := fiber.New()
+			if tt.args.idempotencyKey != "" {
+				req.Header.Add("Idempotency-Key", tt.args.idempotencyKey)
+			}
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
 			srv.Post("/order", tt.args.authHandler, h.CreateOrder)
 
 			resp, _ := srv.Test(req, 1000)
@@ -256,6 +381,7 @@ func TestOrderHandler_GetUserOrders(t *testing.T) {
 	}
 	type args struct {
 		authHandler fiber.Handler
+		query       string
 	}
 	tests := []struct {
 		name       string
@@ -269,7 +395,7 @@ func TestOrderHandler_GetUserOrders(t *testing.T) {
 			fields: fields{
 				orderService: func() *mocks.IOrderService {
 					m := mocks.IOrderService{}
-					m.On("GetUserOrders", mock.Anything, mock.Anything).Return(data, nil)
+					m.On("GetUserOrders", mock.Anything, request.GetOrderHistory{Limit: 20}).Return(data, "", nil)
 					return &m
 				}(),
 			},
@@ -288,7 +414,7 @@ func TestOrderHandler_GetUserOrders(t *testing.T) {
 			fields: fields{
 				orderService: func() *mocks.IOrderService {
 					m := mocks.IOrderService{}
-					m.On("GetUserOrders", mock.Anything, mock.Anything).Return(data, errors.New("GetUserOrders error"))
+					m.On("GetUserOrders", mock.Anything, mock.Anything).Return(nil, "", errors.New("GetUserOrders error"))
 					return &m
 				}(),
 			},
@@ -302,14 +428,55 @@ func TestOrderHandler_GetUserOrders(t *testing.T) {
 			wantStatus: 500,
 			wantMsg:    "error",
 		},
+		{
+			name: "filters and cursor",
+			fields: fields{
+				orderService: func() *mocks.IOrderService {
+					m := mocks.IOrderService{}
+					m.On("GetUserOrders", mock.Anything, request.GetOrderHistory{
+						Cursor: "cursor",
+						From:   "2024-04-01",
+						To:     "2024-04-30",
+						Status: "shipped",
+						Search: "hobbit",
+						Limit:  5,
+					}).Return(data, "next", nil)
+					return &m
+				}(),
+			},
+			args: args{
+				authHandler: func(c *fiber.Ctx) error {
+					c.Locals("id", uint(1))
+					return c.Next()
+				},
+				query: "?cursor=cursor&from=2024-04-01&to=2024-04-30&status=shipped&search=hobbit&limit=5",
+			},
+			wantStatus: 200,
+			wantMsg:    "success",
+		},
+		{
+			name: "invalid query",
+			fields: fields{
+				orderService: &mocks.IOrderService{},
+			},
+			args: args{
+				authHandler: func(c *fiber.Ctx) error {
+					c.Locals("id", uint(1))
+					return c.Next()
+				},
+				query: "?status=lost&limit=500",
+			},
+			wantStatus: 400,
+			wantMsg:    "status",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			h := order.NewOrderHandler(tt.fields.orderService)
 
-			req := httptest.NewRequest("GET", "/order/order-history", nil)
+			req := httptest.NewRequest("GET", "/order/order-history"+tt.args.query, nil)
 			req.Header.Add("Content-Type", "application/json")
-			srv := fiber.New()
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
 			srv.Get("/order/order-history", tt.args.authHandler, h.GetUserOrders)
 
 			resp, _ := srv.Test(req, 1000)
@@ -321,3 +488,214 @@ func TestOrderHandler_GetUserOrders(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderHandler_GetUserOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		orderService *mocks.IOrderService
+		reference    string
+		wantStatus   int
+		wantMsg      string
+	}{
+		{
+			name: "best case",
+			orderService: func() *mocks.IOrderService {
+				m := mocks.IOrderService{}
+				m.On("GetUserOrder", mock.Anything, "Ab12Cd34").Return(response.OrderData{OrderID: 1, CustomerReference: "Ab12Cd34"}, nil)
+				return &m
+			}(),
+			reference:  "Ab12Cd34",
+			wantStatus: 200,
+			wantMsg:    "success",
+		},
+		{
+			name: "not found",
+			orderService: func() *mocks.IOrderService {
+				m := mocks.IOrderService{}
+				m.On("GetUserOrder", mock.Anything, "Ab12Cd34").Return(response.OrderData{}, apperror.NotFound("order not found"))
+				return &m
+			}(),
+			reference:  "Ab12Cd34",
+			wantStatus: 404,
+			wantMsg:    "order not found",
+		},
+		{
+			name:         "reference too long",
+			orderService: &mocks.IOrderService{},
+			reference:    strings.Repeat("a", 256),
+			wantStatus:   400,
+			wantMsg:      "invalid order reference",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := order.NewOrderHandler(tt.orderService)
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			auth := func(c *fiber.Ctx) error {
+				c.Locals("id", uint(1))
+				return c.Next()
+			}
+			h.SetupRoutes(srv, auth, auth)
+
+			resp, _ := srv.Test(httptest.NewRequest("GET", "/api/order/"+tt.reference, nil), 1000)
+			body := response.GetUserOrder{}
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			json.Unmarshal(bodyRespBytes, &body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Contains(t, body.Message, tt.wantMsg)
+			tt.orderService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrderHandler_PreviewOrderConfirmation(t *testing.T) {
+	preview := response.EmailPreview{To: "mail@mail.com", Subject: "Order Confirmation", HTML: "<p>html</p>", Text: "text"}
+
+	type fields struct {
+		orderService service.IOrderService
+	}
+	tests := []struct {
+		name            string
+		fields          fields
+		target          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name: "json",
+			fields: fields{
+				orderService: func() *mocks.IOrderService {
+					m := mocks.IOrderService{}
+					m.On("PreviewOrderConfirmation", mock.Anything, uint(1), "").Return(preview, nil)
+					return &m
+				}(),
+			},
+			target:          "/orders/1/confirmation-email",
+			wantStatus:      200,
+			wantContentType: "application/json",
+			wantBody:        `"subject":"Order Confirmation"`,
+		},
+		{
+			name: "html in another locale",
+			fields: fields{
+				orderService: func() *mocks.IOrderService {
+					m := mocks.IOrderService{}
+					m.On("PreviewOrderConfirmation", mock.Anything, uint(1), "id").Return(preview, nil)
+					return &m
+				}(),
+			},
+			target:          "/orders/1/confirmation-email?locale=id&format=html",
+			wantStatus:      200,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        "<p>html</p>",
+		},
+		{
+			name: "text",
+			fields: fields{
+				orderService: func() *mocks.IOrderService {
+					m := mocks.IOrderService{}
+					m.On("PreviewOrderConfirmation", mock.Anything, uint(1), "").Return(preview, nil)
+					return &m
+				}(),
+			},
+			target:          "/orders/1/confirmation-email?format=text",
+			wantStatus:      200,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "text",
+		},
+		{
+			name:       "invalid format",
+			fields:     fields{orderService: &mocks.IOrderService{}},
+			target:     "/orders/1/confirmation-email?format=pdf",
+			wantStatus: 400,
+			wantBody:   "format has an invalid format",
+		},
+		{
+			name:       "invalid id",
+			fields:     fields{orderService: &mocks.IOrderService{}},
+			target:     "/orders/abc/confirmation-email",
+			wantStatus: 400,
+			wantBody:   "invalid order id",
+		},
+		{
+			name: "order not found",
+			fields: fields{
+				orderService: func() *mocks.IOrderService {
+					m := mocks.IOrderService{}
+					m.On("PreviewOrderConfirmation", mock.Anything, uint(9), "").Return(response.EmailPreview{}, apperror.NotFound("order not found"))
+					return &m
+				}(),
+			},
+			target:     "/orders/9/confirmation-email",
+			wantStatus: 404,
+			wantBody:   "order not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := order.NewOrderHandler(tt.fields.orderService)
+
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Get("/orders/:id/confirmation-email", h.PreviewOrderConfirmation)
+
+			resp, _ := srv.Test(httptest.NewRequest("GET", tt.target, nil), 1000)
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Contains(t, string(bodyBytes), tt.wantBody)
+			if tt.wantContentType != "" {
+				assert.Equal(t, tt.wantContentType, resp.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestOrderHandler_ValidateReference(t *testing.T) {
+	tests := []struct {
+		name         string
+		orderService *mocks.IOrderService
+		reference    string
+		wantStatus   int
+		wantData     response.ReferenceValidation
+	}{
+		{
+			name: "best case",
+			orderService: func() *mocks.IOrderService {
+				m := mocks.IOrderService{}
+				m.On("ValidateReference", mock.Anything, "7k3qw9zd2m4").Return(response.ReferenceValidation{Reference: "7K3QW9ZD2M4", Type: response.ReferenceCustomer, Valid: true})
+				return &m
+			}(),
+			reference:  "7k3qw9zd2m4",
+			wantStatus: 200,
+			wantData:   response.ReferenceValidation{Reference: "7K3QW9ZD2M4", Type: response.ReferenceCustomer, Valid: true},
+		},
+		{
+			name:         "reference too long",
+			orderService: &mocks.IOrderService{},
+			reference:    strings.Repeat("a", 256),
+			wantStatus:   400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := order.NewOrderHandler(tt.orderService)
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			auth := func(c *fiber.Ctx) error {
+				c.Locals("id", uint(1))
+				return c.Next()
+			}
+			h.SetupRoutes(srv, auth, auth)
+
+			resp, _ := srv.Test(httptest.NewRequest("GET", "/api/admin/orders/references/"+tt.reference, nil), 1000)
+			body := response.ValidateReference{}
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			json.Unmarshal(bodyRespBytes, &body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantData, body.Data)
+			tt.orderService.AssertExpectations(t)
+		})
+	}
+}
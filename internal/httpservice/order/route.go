@@ -2,8 +2,13 @@ package order
 
 import "github.com/gofiber/fiber/v2"
 
-func (h *OrderHandler) SetupRoutes(app *fiber.App, auth fiber.Handler) {
+func (h *OrderHandler) SetupRoutes(app *fiber.App, auth, admin fiber.Handler) {
 	orderGroup := app.Group("/api/order")
 	orderGroup.Post("/", auth, h.CreateOrder)
 	orderGroup.Get("/order-history", auth, h.GetUserOrders)
+	//after the fixed paths, so it does not shadow them
+	orderGroup.Get("/:reference", auth, h.GetUserOrder)
+
+	app.Get("/api/admin/orders/:id/confirmation-email", auth, admin, h.PreviewOrderConfirmation)
+	app.Get("/api/admin/orders/references/:reference", auth, admin, h.ValidateReference)
 }
@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+	"ebookstore/internal/validator"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const defaultLimit = 50
+
+type OutboxHandler struct {
+	outboxService service.IOutboxService
+}
+
+func NewOutboxHandler(outboxService service.IOutboxService) *OutboxHandler {
+	return &OutboxHandler{
+		outboxService: outboxService,
+	}
+}
+
+// GetMessages lists the queued notifications with a status, the dead ones by
+// default.
+func (h *OutboxHandler) GetMessages(c *fiber.Ctx) error {
+	req := request.GetOutboxMessages{}
+	err := c.QueryParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid query")
+	}
+
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	if req.Status == "" {
+		req.Status = model.OutboxDead
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	}
+
+	data, err := h.outboxService.GetMessages(c.UserContext(), req.Status, req.Limit)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.GetOutboxMessages{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       data,
+	})
+}
+
+func (h *OutboxHandler) Replay(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil || id == 0 {
+		return apperror.Validation("invalid notification id", apperror.FieldError{Field: "id", Message: "must be a positive number"})
+	}
+
+	err = h.outboxService.Replay(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.OutboxMessage{
+		StatusCode: fiber.StatusOK,
+		Message:    "notification queued again",
+	})
+}
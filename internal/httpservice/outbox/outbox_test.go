@@ -0,0 +1,146 @@
+package outbox_test
+
+import (
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/httpservice/httperror"
+	"ebookstore/internal/httpservice/outbox"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+	"ebookstore/internal/service/mocks"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOutboxHandler_GetMessages(t *testing.T) {
+	data := []response.OutboxMessageData{{ID: 1, To: "mail@mail.com", Status: model.OutboxDead, Attempts: 8}}
+
+	type fields struct {
+		outboxService service.IOutboxService
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		query      string
+		wantStatus int
+		wantLen    int
+	}{
+		{
+			name: "dead messages by default",
+			fields: fields{
+				outboxService: func() *mocks.IOutboxService {
+					m := mocks.IOutboxService{}
+					m.On("GetMessages", mock.Anything, model.OutboxDead, 50).Return(data, nil)
+					return &m
+				}(),
+			},
+			wantStatus: 200,
+			wantLen:    1,
+		},
+		{
+			name: "status and limit",
+			fields: fields{
+				outboxService: func() *mocks.IOutboxService {
+					m := mocks.IOutboxService{}
+					m.On("GetMessages", mock.Anything, model.OutboxPending, 5).Return([]response.OutboxMessageData{}, nil)
+					return &m
+				}(),
+			},
+			query:      "?status=pending&limit=5",
+			wantStatus: 200,
+		},
+		{
+			name:       "invalid status",
+			fields:     fields{outboxService: &mocks.IOutboxService{}},
+			query:      "?status=lost&limit=500",
+			wantStatus: 400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := outbox.NewOutboxHandler(tt.fields.outboxService)
+
+			req := httptest.NewRequest("GET", "/notifications"+tt.query, nil)
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Get("/notifications", h.GetMessages)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			body := response.GetOutboxMessages{}
+			json.Unmarshal(bodyBytes, &body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Len(t, body.Data, tt.wantLen)
+		})
+	}
+}
+
+func TestOutboxHandler_Replay(t *testing.T) {
+	type fields struct {
+		outboxService service.IOutboxService
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		id         string
+		wantStatus int
+		wantMsg    string
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				outboxService: func() *mocks.IOutboxService {
+					m := mocks.IOutboxService{}
+					m.On("Replay", mock.Anything, uint(1)).Return(nil)
+					return &m
+				}(),
+			},
+			id:         "1",
+			wantStatus: 200,
+			wantMsg:    "notification queued again",
+		},
+		{
+			name: "not dead",
+			fields: fields{
+				outboxService: func() *mocks.IOutboxService {
+					m := mocks.IOutboxService{}
+					m.On("Replay", mock.Anything, uint(1)).Return(apperror.Conflict("only dead notifications can be replayed, this one is sent"))
+					return &m
+				}(),
+			},
+			id:         "1",
+			wantStatus: 409,
+			wantMsg:    "only dead notifications can be replayed",
+		},
+		{
+			name:       "invalid id",
+			fields:     fields{outboxService: &mocks.IOutboxService{}},
+			id:         "abc",
+			wantStatus: 400,
+			wantMsg:    "invalid notification id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := outbox.NewOutboxHandler(tt.fields.outboxService)
+
+			req := httptest.NewRequest("POST", "/notifications/"+tt.id+"/replay", nil)
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Post("/notifications/:id/replay", h.Replay)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			body := response.OutboxMessage{}
+			json.Unmarshal(bodyBytes, &body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Contains(t, body.Message, tt.wantMsg)
+		})
+	}
+}
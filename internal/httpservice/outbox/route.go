@@ -0,0 +1,9 @@
+package outbox
+
+import "github.com/gofiber/fiber/v2"
+
+func (h *OutboxHandler) SetupRoutes(app *fiber.App, auth, admin fiber.Handler) {
+	outboxGroup := app.Group("/api/admin/notifications")
+	outboxGroup.Get("/", auth, admin, h.GetMessages)
+	outboxGroup.Post("/:id/replay", auth, admin, h.Replay)
+}
@@ -0,0 +1,106 @@
+package preference
+
+import (
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+	"ebookstore/internal/validator"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// unsubscribePage confirms with a POST, link scanners of mail providers only
+// GET the link and must not unsubscribe anyone.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body style="font-family:Arial,Helvetica,sans-serif;">
+  <form method="post" action="?token={{.}}">
+    <p>Stop receiving these notifications?</p>
+    <button type="submit" name="List-Unsubscribe" value="One-Click">Unsubscribe</button>
+  </form>
+</body>
+</html>
+`))
+
+type PreferenceHandler struct {
+	preferenceService service.IPreferenceService
+}
+
+func NewPreferenceHandler(preferenceService service.IPreferenceService) *PreferenceHandler {
+	return &PreferenceHandler{
+		preferenceService: preferenceService,
+	}
+}
+
+func (h *PreferenceHandler) GetPreferences(c *fiber.Ctx) error {
+	data, err := h.preferenceService.GetPreferences(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.NotificationPreferences{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       data,
+	})
+}
+
+func (h *PreferenceHandler) UpdatePreferences(c *fiber.Ctx) error {
+	req := request.UpdateNotificationPreferences{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return apperror.Validation("invalid request body")
+	}
+
+	err = validator.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	data, err := h.preferenceService.UpdatePreferences(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.NotificationPreferences{
+		StatusCode: fiber.StatusOK,
+		Message:    "success",
+		Data:       data,
+	})
+}
+
+func (h *PreferenceHandler) UnsubscribePage(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return apperror.Validation("invalid unsubscribe link", apperror.FieldError{Field: "token", Message: "is required"})
+	}
+
+	var page strings.Builder
+	err := unsubscribePage.Execute(&page, token)
+	if err != nil {
+		return err
+	}
+
+	c.Type("html", "utf-8")
+	return c.Status(fiber.StatusOK).SendString(page.String())
+}
+
+// Unsubscribe is the one-click unsubscribe of RFC 8058, the body the mail
+// client sends is not needed.
+func (h *PreferenceHandler) Unsubscribe(c *fiber.Ctx) error {
+	data, err := h.preferenceService.Unsubscribe(c.UserContext(), c.Query("token"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Unsubscribe{
+		StatusCode: fiber.StatusOK,
+		Message:    fmt.Sprintf("unsubscribed from %s notifications on %s", data.Category, data.Channel),
+		Data:       data,
+	})
+}
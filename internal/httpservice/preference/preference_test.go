@@ -0,0 +1,137 @@
+package preference_test
+
+import (
+	"bytes"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/httpservice/httperror"
+	"ebookstore/internal/httpservice/preference"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+	"ebookstore/internal/service/mocks"
+	"ebookstore/internal/validator"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPreferenceHandler_UpdatePreferences(t *testing.T) {
+	validator.Register("notification_channel", validator.OneOf("smtp", "webhook"))
+
+	data := []response.NotificationPreference{{Category: "marketing", Channel: "smtp", Enabled: true}}
+
+	tests := []struct {
+		name              string
+		preferenceService service.IPreferenceService
+		body              string
+		wantStatus        int
+		wantBody          string
+	}{
+		{
+			name: "best case",
+			preferenceService: func() *mocks.IPreferenceService {
+				m := mocks.IPreferenceService{}
+				m.On("UpdatePreferences", mock.Anything, mock.Anything).Return(data, nil)
+				return &m
+			}(),
+			body:       `{"preferences":[{"category":"marketing","channel":"smtp","enabled":true}]}`,
+			wantStatus: 200,
+			wantBody:   `"enabled":true`,
+		},
+		{
+			name:              "enabled is required",
+			preferenceService: &mocks.IPreferenceService{},
+			body:              `{"preferences":[{"category":"marketing","channel":"smtp"}]}`,
+			wantStatus:        400,
+			wantBody:          "preferences[0].enabled is required",
+		},
+		{
+			name:              "unknown category and channel",
+			preferenceService: &mocks.IPreferenceService{},
+			body:              `{"preferences":[{"category":"account","channel":"sms","enabled":false}]}`,
+			wantStatus:        400,
+			wantBody:          "preferences[0].category has an invalid format; preferences[0].channel must be one of smtp, webhook",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := preference.NewPreferenceHandler(tt.preferenceService)
+
+			req := httptest.NewRequest("PUT", "/notifications", bytes.NewBufferString(tt.body))
+			req.Header.Add("Content-Type", "application/json")
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Put("/notifications", h.UpdatePreferences)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Contains(t, string(bodyBytes), tt.wantBody)
+		})
+	}
+}
+
+func TestPreferenceHandler_Unsubscribe(t *testing.T) {
+	tests := []struct {
+		name              string
+		preferenceService service.IPreferenceService
+		method            string
+		target            string
+		wantStatus        int
+		wantBody          string
+	}{
+		{
+			name:              "page asks to confirm",
+			preferenceService: &mocks.IPreferenceService{},
+			method:            "GET",
+			target:            "/unsubscribe?token=a.b",
+			wantStatus:        200,
+			wantBody:          `<form method="post" action="?token=a.b">`,
+		},
+		{
+			name: "one-click post",
+			preferenceService: func() *mocks.IPreferenceService {
+				m := mocks.IPreferenceService{}
+				m.On("Unsubscribe", mock.Anything, "a.b").Return(response.UnsubscribeData{Category: "marketing", Channel: "smtp"}, nil)
+				return &m
+			}(),
+			method:     "POST",
+			target:     "/unsubscribe?token=a.b",
+			wantStatus: 200,
+			wantBody:   "unsubscribed from marketing notifications on smtp",
+		},
+		{
+			name: "invalid token",
+			preferenceService: func() *mocks.IPreferenceService {
+				m := mocks.IPreferenceService{}
+				m.On("Unsubscribe", mock.Anything, "forged").Return(response.UnsubscribeData{}, apperror.Validation("invalid unsubscribe link"))
+				return &m
+			}(),
+			method:     "POST",
+			target:     "/unsubscribe?token=forged",
+			wantStatus: 400,
+			wantBody:   "invalid unsubscribe link",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := preference.NewPreferenceHandler(tt.preferenceService)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader("List-Unsubscribe=One-Click"))
+			req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Get("/unsubscribe", h.UnsubscribePage)
+			srv.Post("/unsubscribe", h.Unsubscribe)
+
+			resp, _ := srv.Test(req, 1000)
+			bodyBytes, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Contains(t, string(bodyBytes), tt.wantBody)
+		})
+	}
+}
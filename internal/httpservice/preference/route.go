@@ -0,0 +1,12 @@
+package preference
+
+import "github.com/gofiber/fiber/v2"
+
+func (h *PreferenceHandler) SetupRoutes(app *fiber.App, auth fiber.Handler) {
+	app.Get("/api/customer/me/notifications", auth, h.GetPreferences)
+	app.Put("/api/customer/me/notifications", auth, h.UpdatePreferences)
+
+	//the token in the link logs in, mail clients post here without a session
+	app.Get("/api/customer/notifications/unsubscribe", h.UnsubscribePage)
+	app.Post("/api/customer/notifications/unsubscribe", h.Unsubscribe)
+}
@@ -0,0 +1,66 @@
+package privacy
+
+import (
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PrivacyHandler struct {
+	privacyService service.IPrivacyService
+}
+
+func NewPrivacyHandler(privacyService service.IPrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+func (h *PrivacyHandler) ExportData(c *fiber.Ctx) error {
+	archive, err := h.privacyService.ExportData(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("ebookstore-export-%s.zip", time.Now().UTC().Format("20060102"))
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Attachment(filename)
+	return c.Status(fiber.StatusOK).Send(archive)
+}
+
+func (h *PrivacyHandler) RequestAccountDeletion(c *fiber.Ctx) error {
+	err := h.privacyService.RequestAccountDeletion(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Privacy{
+		StatusCode: fiber.StatusOK,
+		Message:    "confirmation token sent to your email",
+	})
+}
+
+func (h *PrivacyHandler) DeleteAccount(c *fiber.Ctx) error {
+	req := request.DeleteAccount{}
+	if len(c.Body()) > 0 {
+		err := c.BodyParser(&req)
+		if err != nil {
+			return apperror.Validation("invalid request body")
+		}
+	}
+
+	err := h.privacyService.DeleteAccount(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Privacy{
+		StatusCode: fiber.StatusOK,
+		Message:    "account deleted",
+	})
+}
@@ -0,0 +1,190 @@
+package privacy_test
+
+import (
+	"bytes"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/httpservice/httperror"
+	"ebookstore/internal/httpservice/privacy"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+	"ebookstore/internal/service/mocks"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPrivacyHandler_ExportData(t *testing.T) {
+	archive := []byte("PK\x05\x06")
+
+	type fields struct {
+		privacyService service.IPrivacyService
+	}
+	tests := []struct {
+		name            string
+		fields          fields
+		wantStatus      int
+		wantContentType string
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				privacyService: func() *mocks.IPrivacyService {
+					m := mocks.IPrivacyService{}
+					m.On("ExportData", mock.Anything).Return(archive, nil)
+					return &m
+				}(),
+			},
+			wantStatus:      200,
+			wantContentType: "application/zip",
+		},
+		{
+			name: "ExportData error",
+			fields: fields{
+				privacyService: func() *mocks.IPrivacyService {
+					m := mocks.IPrivacyService{}
+					m.On("ExportData", mock.Anything).Return(nil, errors.New("ExportData error"))
+					return &m
+				}(),
+			},
+			wantStatus:      500,
+			wantContentType: "application/json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := privacy.NewPrivacyHandler(tt.fields.privacyService)
+
+			req := httptest.NewRequest("GET", "/export", nil)
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Get("/export", h.ExportData)
+
+			resp, _ := srv.Test(req, 1000)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Contains(t, resp.Header.Get("Content-Type"), tt.wantContentType)
+			if tt.wantStatus == 200 {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, archive, body)
+				assert.Contains(t, resp.Header.Get("Content-Disposition"), "attachment")
+			}
+		})
+	}
+}
+
+func TestPrivacyHandler_RequestAccountDeletion(t *testing.T) {
+	type fields struct {
+		privacyService service.IPrivacyService
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		wantStatus int
+		wantMsg    string
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				privacyService: func() *mocks.IPrivacyService {
+					m := mocks.IPrivacyService{}
+					m.On("RequestAccountDeletion", mock.Anything).Return(nil)
+					return &m
+				}(),
+			},
+			wantStatus: 200,
+			wantMsg:    "confirmation token sent to your email",
+		},
+		{
+			name: "RequestAccountDeletion error",
+			fields: fields{
+				privacyService: func() *mocks.IPrivacyService {
+					m := mocks.IPrivacyService{}
+					m.On("RequestAccountDeletion", mock.Anything).Return(apperror.Conflict("confirm the deletion with your password"))
+					return &m
+				}(),
+			},
+			wantStatus: 409,
+			wantMsg:    "confirm the deletion with your password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := privacy.NewPrivacyHandler(tt.fields.privacyService)
+
+			httpReq := httptest.NewRequest("POST", "/me/deletion-token", nil)
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Post("/me/deletion-token", h.RequestAccountDeletion)
+
+			resp, _ := srv.Test(httpReq, 1000)
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			mockResp := response.Privacy{}
+			json.Unmarshal(bodyRespBytes, &mockResp)
+
+			assert.Contains(t, mockResp.Message, tt.wantMsg)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestPrivacyHandler_DeleteAccount(t *testing.T) {
+	req := request.DeleteAccount{Password: "Passw0rd."}
+
+	type fields struct {
+		privacyService service.IPrivacyService
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		wantStatus int
+		wantMsg    string
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				privacyService: func() *mocks.IPrivacyService {
+					m := mocks.IPrivacyService{}
+					m.On("DeleteAccount", mock.Anything, req).Return(nil)
+					return &m
+				}(),
+			},
+			wantStatus: 200,
+			wantMsg:    "account deleted",
+		},
+		{
+			name: "DeleteAccount error",
+			fields: fields{
+				privacyService: func() *mocks.IPrivacyService {
+					m := mocks.IPrivacyService{}
+					m.On("DeleteAccount", mock.Anything, req).Return(apperror.Forbidden("invalid password"))
+					return &m
+				}(),
+			},
+			wantStatus: 403,
+			wantMsg:    "invalid password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := privacy.NewPrivacyHandler(tt.fields.privacyService)
+			bodyBytes, _ := json.Marshal(req)
+
+			httpReq := httptest.NewRequest("DELETE", "/me", bytes.NewBuffer(bodyBytes))
+			httpReq.Header.Add("Content-Type", "application/json")
+			srv := fiber.New(fiber.Config{ErrorHandler: httperror.Handler})
+			srv.Delete("/me", h.DeleteAccount)
+
+			resp, _ := srv.Test(httpReq, 1000)
+			bodyRespBytes, _ := io.ReadAll(resp.Body)
+			mockResp := response.Privacy{}
+			json.Unmarshal(bodyRespBytes, &mockResp)
+
+			assert.Contains(t, mockResp.Message, tt.wantMsg)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
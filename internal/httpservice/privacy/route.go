@@ -0,0 +1,10 @@
+package privacy
+
+import "github.com/gofiber/fiber/v2"
+
+func (h *PrivacyHandler) SetupRoutes(app *fiber.App, auth fiber.Handler) {
+	privacyGroup := app.Group("/api/customer/me")
+	privacyGroup.Get("/export", auth, h.ExportData)
+	privacyGroup.Post("/deletion-token", auth, h.RequestAccountDeletion)
+	privacyGroup.Delete("/", auth, h.DeleteAccount)
+}
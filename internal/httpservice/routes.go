@@ -1,42 +1,59 @@
 package httpservice
 
 import (
+	"ebookstore/internal/bootstrap"
+	addressHandler "ebookstore/internal/httpservice/address"
 	bookHandler "ebookstore/internal/httpservice/book"
 	customerHandler "ebookstore/internal/httpservice/customer"
+	healthHandler "ebookstore/internal/httpservice/health"
+	"ebookstore/internal/httpservice/httperror"
 	orderHandler "ebookstore/internal/httpservice/order"
-	bookService "ebookstore/internal/service/book"
-	customerService "ebookstore/internal/service/customer"
-	orderService "ebookstore/internal/service/order"
+	outboxHandler "ebookstore/internal/httpservice/outbox"
+	preferenceHandler "ebookstore/internal/httpservice/preference"
+	privacyHandler "ebookstore/internal/httpservice/privacy"
 
-	"ebookstore/internal/repository/postgresql"
-	"ebookstore/utils/config"
+	"ebookstore/internal/model"
+	"ebookstore/utils/logger"
+	"ebookstore/utils/metrics"
 	authentication "ebookstore/utils/middleware"
-	"ebookstore/utils/notification"
-	"ebookstore/utils/transactioner"
+	"ebookstore/utils/tracing"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/jmoiron/sqlx"
-	"gopkg.in/gomail.v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
-func InitRoutes(app *fiber.App, db *sqlx.DB) {
-	auth := authentication.AuthMiddleware()
-	gmailSMTP := gomail.NewDialer(config.CONFIG_SMTP_HOST, config.CONFIG_SMTP_PORT, config.CONFIG_AUTH_EMAIL, config.CONFIG_AUTH_PASSWORD)
-	notificationService := notification.NewGmailNotification(gmailSMTP)
-
-	bookRepository := postgresql.NewBookRepository(db)
-	bookService := bookService.NewBookService(bookRepository)
-	bookHandler := bookHandler.NewBookHandler(bookService)
-	bookHandler.SetupRoutes(app)
-
-	customerRepository := postgresql.NewCustomerRepository(db)
-	customerService := customerService.NewCustomerService(customerRepository, notificationService)
-	customerHandler := customerHandler.NewCustomerHandler(customerService)
-	customerHandler.SetupRoutes(app)
-
-	orderRepository := postgresql.NewOrderRepository(db)
-	orderTxProvider := transactioner.NewTransactionProvider(db)
-	orderService := orderService.NewOrderService(orderRepository, bookRepository, orderTxProvider, notificationService)
-	orderHandler := orderHandler.NewOrderHandler(orderService)
-	orderHandler.SetupRoutes(app, auth)
+func InitRoutes(app *fiber.App, c *bootstrap.Container) {
+	auth := authentication.AuthMiddleware(c.CustomerService.IsActive)
+	admin := authentication.RoleMiddleware(model.RoleAdmin, c.CustomerService.GetRole)
+	app.Use(logger.Middleware())
+	app.Use(tracing.Middleware())
+	app.Use(metrics.Middleware())
+	//errors are answered here, inside the logger and metrics, and panics become errors
+	app.Use(httperror.Middleware())
+	app.Use(recover.New())
+	app.Get("/metrics", metrics.Handler())
+
+	healthHandler := healthHandler.NewHealthHandler(c.HealthService)
+	healthHandler.SetupRoutes(app)
+
+	bookHandler := bookHandler.NewBookHandler(c.BookService)
+	bookHandler.SetupRoutes(app, auth, admin)
+
+	customerHandler := customerHandler.NewCustomerHandler(c.CustomerService)
+	customerHandler.SetupRoutes(app, auth)
+
+	addressHandler := addressHandler.NewAddressHandler(c.AddressService)
+	addressHandler.SetupRoutes(app, auth)
+
+	orderHandler := orderHandler.NewOrderHandler(c.OrderService)
+	orderHandler.SetupRoutes(app, auth, admin)
+
+	privacyHandler := privacyHandler.NewPrivacyHandler(c.PrivacyService)
+	privacyHandler.SetupRoutes(app, auth)
+
+	preferenceHandler := preferenceHandler.NewPreferenceHandler(c.PreferenceService)
+	preferenceHandler.SetupRoutes(app, auth)
+
+	outboxHandler := outboxHandler.NewOutboxHandler(c.OutboxService)
+	outboxHandler.SetupRoutes(app, auth, admin)
 }
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Address struct {
+	ID           uint        `db:"id"`
+	CustomerID   uint        `db:"customer_id"`
+	Label        string      `db:"label"`
+	ReceiverName string      `db:"receiver_name"`
+	Address      string      `db:"address"`
+	City         string      `db:"city"`
+	District     string      `db:"district"`
+	PostalCode   string      `db:"postal_code"`
+	IsDefault    bool        `db:"is_default"`
+	CreatedAt    time.Time   `db:"created_at"`
+	UpdatedAt    pq.NullTime `db:"updated_at"`
+	DeletedAt    pq.NullTime `db:"deleted_at"`
+}
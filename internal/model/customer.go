@@ -1,17 +1,39 @@
 package model
 
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
 type Customer struct {
 	ID       uint   `db:"id"`
 	Email    string `db:"email"`
 	Password string `db:"password"`
 	Username string `db:"username"`
+	Role     string `db:"role"`
+
+	// email change waiting for the customer to confirm the new address
+	PendingEmail               string      `db:"pending_email"`
+	EmailVerificationTokenHash string      `db:"email_verification_token"`
+	EmailVerificationExpiresAt pq.NullTime `db:"email_verification_expires_at"`
+	UpdatedAt                  pq.NullTime `db:"updated_at"`
 }
 
-const CustomerBodyEmailTemplate = `
-<p>Dear %s,</p>
-<p>Welcome to our online bookstore!</p>
-<p>Your account has been successfully created.</p>
-<p>Start exploring our collection of books and enjoy shopping with us!</p>
-<p>Best regards,</p>
-<p>The Bookstore Team</p>
-`
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
+// AnonymizedValue replaces personal data on rows kept after an account is deleted.
+const AnonymizedValue = "[deleted]"
+
+// CustomerIdentity links a customer to an account at an external OpenID Connect provider.
+type CustomerIdentity struct {
+	ID         uint      `db:"id"`
+	CustomerID uint      `db:"customer_id"`
+	Issuer     string    `db:"issuer"`
+	Subject    string    `db:"subject"`
+	Email      string    `db:"email"`
+	CreatedAt  time.Time `db:"created_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// IdempotencyKey is a request a client may retry with the same key. The
+// response of the first request is returned again instead of repeating it.
+type IdempotencyKey struct {
+	CustomerID uint   `db:"customer_id"`
+	Key        string `db:"key"`
+	// RequestHash tells a retry from another request sent with the same key
+	RequestHash string    `db:"request_hash"`
+	Response    []byte    `db:"response"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type CustomerMFA struct {
+	CustomerID   uint   `db:"customer_id"`
+	TOTPSecret   string `db:"totp_secret"`
+	Enabled      bool   `db:"enabled"`
+	LastUsedStep int64  `db:"last_used_step"`
+	// FailedAttempts counts wrong codes since the last success or lockout.
+	FailedAttempts int         `db:"failed_attempts"`
+	LockedUntil    pq.NullTime `db:"locked_until"`
+	CreatedAt      time.Time   `db:"created_at"`
+	EnabledAt      pq.NullTime `db:"enabled_at"`
+}
+
+type RecoveryCode struct {
+	ID         uint        `db:"id"`
+	CustomerID uint        `db:"customer_id"`
+	CodeHash   string      `db:"code_hash"`
+	UsedAt     pq.NullTime `db:"used_at"`
+}
@@ -17,6 +17,7 @@ type Order struct {
 	PostalCode        string      `db:"postal_code"`
 	Shipper           string      `db:"shipper"`
 	AirwaybillNumber  string      `db:"airwaybill_number"`
+	Status            string      `db:"status"`
 	OrderDate         time.Time   `db:"order_date"`
 	TotalItem         int         `db:"total_item"`
 	TotalPrice        float64     `db:"total_price"`
@@ -24,6 +25,29 @@ type Order struct {
 	DeletedAt         pq.NullTime `db:"deleted_at"`
 }
 
+const (
+	OrderPlaced    = "placed"
+	OrderShipped   = "shipped"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+)
+
+// OrderHistoryQuery narrows the order history of a customer, zero fields are
+// not filtered on. Orders are sorted newest first, by order date then ID.
+type OrderHistoryQuery struct {
+	// AfterDate and AfterID are the last order of the previous page, only
+	// older orders are returned when AfterID is set
+	AfterDate time.Time
+	AfterID   uint
+	// From is inclusive and To exclusive
+	From   time.Time
+	To     time.Time
+	Status string
+	// Search matches the customer reference or the title of an ordered book
+	Search string
+	Limit  int
+}
+
 type Item struct {
 	ID        uint        `db:"id"`
 	BookID    uint        `db:"book_id"`
@@ -32,16 +56,3 @@ type Item struct {
 	CreatedAt time.Time   `db:"created_at"`
 	DeletedAt pq.NullTime `db:"deleted_at"`
 }
-
-const OrderBodyEmailTemplate = `<p>Dear %s,</p>
-<p>Your order with ID: %d has been successfully created.</p>
-<p>Order Details:</p>
-<ul>
-  <li>total item: %d</li>
-  <li>total price: %f</li>
-  <li>Customer Reference: %s</li>
-  <li>Order Date: %s</li>
-  <li>Airwaybill Number: %s</li>
-</ul>
-<p>Thank you for shopping with us!</p>
-`
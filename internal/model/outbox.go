@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// OutboxMessage is a notification written in the same transaction as the
+// change it is about, and sent later by the dispatcher. CustomerID is the
+// recipient, 0 for messages not addressed to a customer. DeliveredChannels
+// are the channels that already got it, retries skip them.
+type OutboxMessage struct {
+	ID            uint        `db:"id"`
+	CustomerID    uint        `db:"customer_id"`
+	Payload       []byte      `db:"payload"`
+	Status        string      `db:"status"`
+	Attempts      int         `db:"attempts"`
+	LastError     string      `db:"last_error"`
+	NextAttemptAt time.Time   `db:"next_attempt_at"`
+	CreatedAt     time.Time   `db:"created_at"`
+	UpdatedAt     pq.NullTime `db:"updated_at"`
+	SentAt        pq.NullTime `db:"sent_at"`
+
+	DeliveredChannels pq.StringArray `db:"delivered_channels"`
+}
+
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	// OutboxDead messages ran out of attempts and wait for an admin to replay them.
+	OutboxDead = "dead"
+)
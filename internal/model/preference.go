@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// NotificationPreference turns a category of notifications on or off for one
+// channel of a customer.
+type NotificationPreference struct {
+	CustomerID uint      `db:"customer_id"`
+	Category   string    `db:"category"`
+	Channel    string    `db:"channel"`
+	Enabled    bool      `db:"enabled"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
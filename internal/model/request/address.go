@@ -0,0 +1,11 @@
+package request
+
+type Address struct {
+	Label        string `json:"label" validate:"max=255"`
+	ReceiverName string `json:"receiver_name" validate:"max=255"`
+	Address      string `json:"address" validate:"required,max=255"`
+	City         string `json:"city" validate:"required,max=255"`
+	District     string `json:"district" validate:"required,max=255"`
+	PostalCode   string `json:"postal_code" validate:"required,max=20"`
+	IsDefault    bool   `json:"is_default"`
+}
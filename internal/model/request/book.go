@@ -1,6 +1,8 @@
 package request
 
-type GetBooks struct {
-	Offset int `query:"offset"`
-	Limit  int `query:"limit"`
+type ImportBook struct {
+	Title    string  `validate:"required,max=255"`
+	Author   string  `validate:"required,max=255"`
+	Price    float64 `validate:"min=0.01"`
+	Category string  `validate:"required,max=255"`
 }
@@ -1,12 +1,39 @@
 package request
 
 type Register struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Username string `json:"username"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password"`
+	Username string `json:"username" validate:"required,min=4,max=16,regex=^[a-zA-Z0-9_]+$"`
 }
 
 type Login struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginMFA struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type MFACode struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type OIDCCallback struct {
+	Code       string `query:"code"`
+	State      string `query:"state"`
+	Error      string `query:"error"`
+	StateToken string `query:"-"`
+}
+
+// UpdateProfile only changes the fields that are set.
+type UpdateProfile struct {
+	Username string `json:"username" validate:"required_without=Email,omitempty,min=4,max=16,regex=^[a-zA-Z0-9_]+$"`
+	Email    string `json:"email" validate:"required_without=Username,omitempty,email,max=255"`
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,password"`
 }
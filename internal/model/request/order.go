@@ -1,16 +1,36 @@
 package request
 
+// CreateOrder only needs the receiver fields when no saved address is given.
 type CreateOrder struct {
-	Items        []Item `json:"items"`
-	ReceiverName string `json:"receiver_name"`
-	Address      string `json:"address"`
-	City         string `json:"city"`
-	District     string `json:"district"`
-	PostalCode   string `json:"postal_code"`
-	Shipper      string `json:"shipper"`
+	Items        []Item `json:"items" validate:"required,max=100"`
+	AddressID    uint   `json:"address_id"`
+	ReceiverName string `json:"receiver_name" validate:"max=255"`
+	Address      string `json:"address" validate:"required_without=AddressID,max=255"`
+	City         string `json:"city" validate:"required_without=AddressID,max=255"`
+	District     string `json:"district" validate:"required_without=AddressID,max=255"`
+	PostalCode   string `json:"postal_code" validate:"required_without=AddressID,max=20"`
+	Shipper      string `json:"shipper" validate:"required,shipper"`
 }
 
 type Item struct {
-	BookID   uint `json:"book_id"`
-	Quantity int  `json:"quantity"`
+	BookID   uint `json:"book_id" validate:"required"`
+	Quantity int  `json:"quantity" validate:"min=1,max=100"`
+}
+
+// GetOrderHistory pages through the orders of a customer. Cursor is the
+// next_cursor of the previous page, From and To are inclusive dates.
+type GetOrderHistory struct {
+	Cursor string `query:"cursor" validate:"max=200"`
+	From   string `query:"from" validate:"omitempty,regex=^[0-9]{4}-[0-9]{2}-[0-9]{2}$"`
+	To     string `query:"to" validate:"omitempty,regex=^[0-9]{4}-[0-9]{2}-[0-9]{2}$"`
+	Status string `query:"status" validate:"omitempty,regex=^(placed|shipped|delivered|cancelled)$"`
+	Search string `query:"search" validate:"max=255"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// PreviewEmail renders an email in another locale, and as the bare HTML or text
+// instead of JSON with format.
+type PreviewEmail struct {
+	Locale string `query:"locale" validate:"omitempty,regex=^[a-z]{2}$"`
+	Format string `query:"format" validate:"omitempty,regex=^(json|html|text)$"`
 }
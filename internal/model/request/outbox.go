@@ -0,0 +1,6 @@
+package request
+
+type GetOutboxMessages struct {
+	Status string `query:"status" validate:"omitempty,regex=^(pending|sent|dead)$"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,11 @@
+package request
+
+type UpdateNotificationPreferences struct {
+	Preferences []NotificationPreference `json:"preferences" validate:"required,max=20"`
+}
+
+type NotificationPreference struct {
+	Category string `json:"category" validate:"required,regex=^(transactional|marketing)$"`
+	Channel  string `json:"channel" validate:"required,notification_channel"`
+	Enabled  *bool  `json:"enabled" validate:"required"`
+}
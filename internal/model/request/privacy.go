@@ -0,0 +1,10 @@
+package request
+
+// DeleteAccount asks for the current password again. Accounts created through
+// social login have no password and send the emailed confirmation token
+// instead. Code is the TOTP or recovery code when MFA is enabled.
+type DeleteAccount struct {
+	Password          string `json:"password"`
+	ConfirmationToken string `json:"confirmation_token"`
+	Code              string `json:"code"`
+}
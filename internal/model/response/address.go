@@ -0,0 +1,24 @@
+package response
+
+type Address struct {
+	StatusCode int         `json:"status_code"`
+	Message    string      `json:"message"`
+	Data       AddressData `json:"data,omitempty"`
+}
+
+type GetAddresses struct {
+	StatusCode int           `json:"status_code"`
+	Message    string        `json:"message"`
+	Data       []AddressData `json:"data"`
+}
+
+type AddressData struct {
+	ID           uint   `json:"id"`
+	Label        string `json:"label"`
+	ReceiverName string `json:"receiver_name"`
+	Address      string `json:"address"`
+	City         string `json:"city"`
+	District     string `json:"district"`
+	PostalCode   string `json:"postal_code"`
+	IsDefault    bool   `json:"is_default"`
+}
@@ -13,3 +13,13 @@ type Book struct {
 	Price    float64 `json:"price"`
 	Category string  `json:"category"`
 }
+
+type ImportBooksData struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+type InvalidateCache struct {
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+}
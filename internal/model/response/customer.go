@@ -1,7 +1,52 @@
 package response
 
 type Customer struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"message"`
-	Token      string `json:"token,omitempty"`
+	StatusCode  int    `json:"status_code"`
+	Message     string `json:"message"`
+	Token       string `json:"token,omitempty"`
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+// LoginData carries either the access token or, when the customer has MFA
+// enabled, the challenge token that has to be exchanged for it.
+type LoginData struct {
+	Token    string
+	MFAToken string
+}
+
+type MFAEnrollment struct {
+	StatusCode int               `json:"status_code"`
+	Message    string            `json:"message"`
+	Data       MFAEnrollmentData `json:"data,omitempty"`
+}
+
+type MFAEnrollmentData struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFARecoveryCodes struct {
+	StatusCode    int      `json:"status_code"`
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+type OIDCAuthorization struct {
+	AuthURL    string
+	StateToken string
+}
+
+type Profile struct {
+	StatusCode int         `json:"status_code"`
+	Message    string      `json:"message"`
+	Data       ProfileData `json:"data,omitempty"`
+}
+
+type ProfileData struct {
+	ID           uint   `json:"id"`
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	PendingEmail string `json:"pending_email,omitempty"`
+	MFAEnabled   bool   `json:"mfa_enabled"`
 }
@@ -0,0 +1,11 @@
+package response
+
+import "ebookstore/internal/apperror"
+
+// Error is the body of every failed request.
+type Error struct {
+	StatusCode int                   `json:"status_code"`
+	Message    string                `json:"message"`
+	RequestID  string                `json:"request_id,omitempty"`
+	Errors     []apperror.FieldError `json:"errors,omitempty"`
+}
@@ -19,6 +19,14 @@ type GetUserOrders struct {
 	StatusCode int         `json:"status_code"`
 	Message    string      `json:"message"`
 	Data       []OrderData `json:"data,omitempty"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type GetUserOrder struct {
+	StatusCode int       `json:"status_code"`
+	Message    string    `json:"message"`
+	Data       OrderData `json:"data,omitempty"`
 }
 
 type OrderData struct {
@@ -31,6 +39,7 @@ type OrderData struct {
 	PostalCode        string    `json:"postal_code"`
 	Shipper           string    `json:"shipper"`
 	AirwaybillNumber  string    `json:"airwaybill_number"`
+	Status            string    `json:"status"`
 	OrderDate         time.Time `json:"order_date"`
 	Items             []Item    `json:"items"`
 	TotalItem         int       `json:"total_item"`
@@ -44,3 +53,35 @@ type Item struct {
 	Quantity int     `json:"quantity"`
 	Price    float64 `json:"price"`
 }
+
+type ValidateReference struct {
+	StatusCode int                 `json:"status_code"`
+	Message    string              `json:"message"`
+	Data       ReferenceValidation `json:"data"`
+}
+
+// ReferenceValidation reports whether a reference is well formed. Reference is
+// the normalized form, e.g. in upper case and with 0 instead of O.
+type ReferenceValidation struct {
+	Reference string `json:"reference"`
+	Type      string `json:"type"`
+	Valid     bool   `json:"valid"`
+}
+
+const (
+	ReferenceCustomer         = "customer_reference"
+	ReferenceAirwaybillNumber = "airwaybill_number"
+)
+
+type PreviewEmail struct {
+	StatusCode int          `json:"status_code"`
+	Message    string       `json:"message"`
+	Data       EmailPreview `json:"data,omitempty"`
+}
+
+type EmailPreview struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
@@ -0,0 +1,26 @@
+package response
+
+import "time"
+
+type OutboxMessage struct {
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+}
+
+type GetOutboxMessages struct {
+	StatusCode int                 `json:"status_code"`
+	Message    string              `json:"message"`
+	Data       []OutboxMessageData `json:"data,omitempty"`
+}
+
+type OutboxMessageData struct {
+	ID            uint       `json:"id"`
+	To            string     `json:"to"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,24 @@
+package response
+
+type NotificationPreferences struct {
+	StatusCode int                      `json:"status_code"`
+	Message    string                   `json:"message"`
+	Data       []NotificationPreference `json:"data"`
+}
+
+type NotificationPreference struct {
+	Category string `json:"category"`
+	Channel  string `json:"channel"`
+	Enabled  bool   `json:"enabled"`
+}
+
+type Unsubscribe struct {
+	StatusCode int             `json:"status_code"`
+	Message    string          `json:"message"`
+	Data       UnsubscribeData `json:"data,omitempty"`
+}
+
+type UnsubscribeData struct {
+	Category string `json:"category"`
+	Channel  string `json:"channel"`
+}
@@ -0,0 +1,28 @@
+package response
+
+import "time"
+
+type Privacy struct {
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+}
+
+type ExportMFA struct {
+	Enabled    bool       `json:"enabled"`
+	EnrolledAt *time.Time `json:"enrolled_at,omitempty"`
+	EnabledAt  *time.Time `json:"enabled_at,omitempty"`
+}
+
+type ExportPreference struct {
+	Category  string    `json:"category"`
+	Channel   string    `json:"channel"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ExportIdentity struct {
+	Issuer   string    `json:"issuer"`
+	Subject  string    `json:"subject"`
+	Email    string    `json:"email"`
+	LinkedAt time.Time `json:"linked_at"`
+}
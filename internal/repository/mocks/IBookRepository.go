@@ -7,6 +7,8 @@ import (
 	model "ebookstore/internal/model"
 
 	mock "github.com/stretchr/testify/mock"
+
+	transactioner "ebookstore/utils/transactioner"
 )
 
 // IBookRepository is an autogenerated mock type for the IBookRepository type
@@ -14,6 +16,62 @@ type IBookRepository struct {
 	mock.Mock
 }
 
+// CreateBookIfNotExists provides a mock function with given fields: ctx, tx, book
+func (_m *IBookRepository) CreateBookIfNotExists(ctx context.Context, tx transactioner.TxxProvider, book model.Book) (bool, error) {
+	ret := _m.Called(ctx, tx, book)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBookIfNotExists")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, model.Book) (bool, error)); ok {
+		return rf(ctx, tx, book)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, model.Book) bool); ok {
+		r0 = rf(ctx, tx, book)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, transactioner.TxxProvider, model.Book) error); ok {
+		r1 = rf(ctx, tx, book)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateCategory provides a mock function with given fields: ctx, tx, name
+func (_m *IBookRepository) CreateCategory(ctx context.Context, tx transactioner.TxxProvider, name string) (uint, error) {
+	ret := _m.Called(ctx, tx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCategory")
+	}
+
+	var r0 uint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, string) (uint, error)); ok {
+		return rf(ctx, tx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, string) uint); ok {
+		r0 = rf(ctx, tx, name)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, transactioner.TxxProvider, string) error); ok {
+		r1 = rf(ctx, tx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetBookByID provides a mock function with given fields: ctx, id
 func (_m *IBookRepository) GetBookByID(ctx context.Context, id uint) (model.Book, error) {
 	ret := _m.Called(ctx, id)
@@ -72,6 +130,36 @@ func (_m *IBookRepository) GetBooks(ctx context.Context) ([]model.Book, error) {
 	return r0, r1
 }
 
+// GetBooksByIDs provides a mock function with given fields: ctx, ids
+func (_m *IBookRepository) GetBooksByIDs(ctx context.Context, ids []uint) ([]model.Book, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBooksByIDs")
+	}
+
+	var r0 []model.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) ([]model.Book, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) []model.Book); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Book)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCategoryByID provides a mock function with given fields: ctx, id
 func (_m *IBookRepository) GetCategoryByID(ctx context.Context, id uint) (model.Category, error) {
 	ret := _m.Called(ctx, id)
@@ -100,6 +188,94 @@ func (_m *IBookRepository) GetCategoryByID(ctx context.Context, id uint) (model.
 	return r0, r1
 }
 
+// GetCategoryByName provides a mock function with given fields: ctx, name
+func (_m *IBookRepository) GetCategoryByName(ctx context.Context, name string) (*model.Category, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCategoryByName")
+	}
+
+	var r0 *model.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Category, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Category); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReindexSearch provides a mock function with given fields: ctx
+func (_m *IBookRepository) ReindexSearch(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReindexSearch")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchBooks provides a mock function with given fields: ctx, query
+func (_m *IBookRepository) SearchBooks(ctx context.Context, query string) ([]model.Book, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchBooks")
+	}
+
+	var r0 []model.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.Book, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Book); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Book)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewIBookRepository creates a new instance of IBookRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewIBookRepository(t interface {
@@ -7,6 +7,8 @@ import (
 	model "ebookstore/internal/model"
 
 	mock "github.com/stretchr/testify/mock"
+
+	transactioner "ebookstore/utils/transactioner"
 )
 
 // ICustomerRepository is an autogenerated mock type for the ICustomerRepository type
@@ -14,6 +16,24 @@ type ICustomerRepository struct {
 	mock.Mock
 }
 
+// AnonymizeCustomer provides a mock function with given fields: ctx, tx, id
+func (_m *ICustomerRepository) AnonymizeCustomer(ctx context.Context, tx transactioner.TxxProvider, id uint) error {
+	ret := _m.Called(ctx, tx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AnonymizeCustomer")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, uint) error); ok {
+		r0 = rf(ctx, tx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetCustomerByEmail provides a mock function with given fields: ctx, email
 func (_m *ICustomerRepository) GetCustomerByEmail(ctx context.Context, email string) (*model.Customer, error) {
 	ret := _m.Called(ctx, email)
@@ -44,9 +64,147 @@ func (_m *ICustomerRepository) GetCustomerByEmail(ctx context.Context, email str
 	return r0, r1
 }
 
-// Register provides a mock function with given fields: ctx, customer
-func (_m *ICustomerRepository) Register(ctx context.Context, customer *model.Customer) (uint, error) {
-	ret := _m.Called(ctx, customer)
+// GetCustomerByEmailVerificationToken provides a mock function with given fields: ctx, tokenHash
+func (_m *ICustomerRepository) GetCustomerByEmailVerificationToken(ctx context.Context, tokenHash string) (*model.Customer, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCustomerByEmailVerificationToken")
+	}
+
+	var r0 *model.Customer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Customer, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Customer); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCustomerByID provides a mock function with given fields: ctx, id
+func (_m *ICustomerRepository) GetCustomerByID(ctx context.Context, id uint) (*model.Customer, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCustomerByID")
+	}
+
+	var r0 *model.Customer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*model.Customer, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *model.Customer); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCustomerByIdentity provides a mock function with given fields: ctx, issuer, subject
+func (_m *ICustomerRepository) GetCustomerByIdentity(ctx context.Context, issuer string, subject string) (*model.Customer, error) {
+	ret := _m.Called(ctx, issuer, subject)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCustomerByIdentity")
+	}
+
+	var r0 *model.Customer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.Customer, error)); ok {
+		return rf(ctx, issuer, subject)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.Customer); ok {
+		r0 = rf(ctx, issuer, subject)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, issuer, subject)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetIdentitiesByCustomerID provides a mock function with given fields: ctx, customerID
+func (_m *ICustomerRepository) GetIdentitiesByCustomerID(ctx context.Context, customerID uint) ([]model.CustomerIdentity, error) {
+	ret := _m.Called(ctx, customerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetIdentitiesByCustomerID")
+	}
+
+	var r0 []model.CustomerIdentity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) ([]model.CustomerIdentity, error)); ok {
+		return rf(ctx, customerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []model.CustomerIdentity); ok {
+		r0 = rf(ctx, customerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomerIdentity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, customerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LinkIdentity provides a mock function with given fields: ctx, tx, identity
+func (_m *ICustomerRepository) LinkIdentity(ctx context.Context, tx transactioner.TxxProvider, identity model.CustomerIdentity) error {
+	ret := _m.Called(ctx, tx, identity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkIdentity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, model.CustomerIdentity) error); ok {
+		r0 = rf(ctx, tx, identity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Register provides a mock function with given fields: ctx, tx, customer
+func (_m *ICustomerRepository) Register(ctx context.Context, tx transactioner.TxxProvider, customer *model.Customer) (uint, error) {
+	ret := _m.Called(ctx, tx, customer)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Register")
@@ -54,17 +212,17 @@ func (_m *ICustomerRepository) Register(ctx context.Context, customer *model.Cus
 
 	var r0 uint
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *model.Customer) (uint, error)); ok {
-		return rf(ctx, customer)
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, *model.Customer) (uint, error)); ok {
+		return rf(ctx, tx, customer)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *model.Customer) uint); ok {
-		r0 = rf(ctx, customer)
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, *model.Customer) uint); ok {
+		r0 = rf(ctx, tx, customer)
 	} else {
 		r0 = ret.Get(0).(uint)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *model.Customer) error); ok {
-		r1 = rf(ctx, customer)
+	if rf, ok := ret.Get(1).(func(context.Context, transactioner.TxxProvider, *model.Customer) error); ok {
+		r1 = rf(ctx, tx, customer)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,6 +230,42 @@ func (_m *ICustomerRepository) Register(ctx context.Context, customer *model.Cus
 	return r0, r1
 }
 
+// SetRole provides a mock function with given fields: ctx, id, role
+func (_m *ICustomerRepository) SetRole(ctx context.Context, id uint, role string) error {
+	ret := _m.Called(ctx, id, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(ctx, id, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateCustomer provides a mock function with given fields: ctx, tx, customer
+func (_m *ICustomerRepository) UpdateCustomer(ctx context.Context, tx transactioner.TxxProvider, customer *model.Customer) error {
+	ret := _m.Called(ctx, tx, customer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCustomer")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, *model.Customer) error); ok {
+		r0 = rf(ctx, tx, customer)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewICustomerRepository creates a new instance of ICustomerRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewICustomerRepository(t interface {
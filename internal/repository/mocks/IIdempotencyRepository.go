@@ -0,0 +1,137 @@
+// Code generated by mockery v2.42.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	model "ebookstore/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	transactioner "ebookstore/utils/transactioner"
+)
+
+// IIdempotencyRepository is an autogenerated mock type for the IIdempotencyRepository type
+type IIdempotencyRepository struct {
+	mock.Mock
+}
+
+// CreateIdempotencyKey provides a mock function with given fields: ctx, tx, key
+func (_m *IIdempotencyRepository) CreateIdempotencyKey(ctx context.Context, tx transactioner.TxxProvider, key model.IdempotencyKey) (bool, error) {
+	ret := _m.Called(ctx, tx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateIdempotencyKey")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, model.IdempotencyKey) (bool, error)); ok {
+		return rf(ctx, tx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, model.IdempotencyKey) bool); ok {
+		r0 = rf(ctx, tx, key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, transactioner.TxxProvider, model.IdempotencyKey) error); ok {
+		r1 = rf(ctx, tx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteExpiredIdempotencyKeys provides a mock function with given fields: ctx, now
+func (_m *IIdempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpiredIdempotencyKeys")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteIdempotencyKeysByCustomerID provides a mock function with given fields: ctx, tx, customerID
+func (_m *IIdempotencyRepository) DeleteIdempotencyKeysByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	ret := _m.Called(ctx, tx, customerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteIdempotencyKeysByCustomerID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, uint) error); ok {
+		r0 = rf(ctx, tx, customerID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetIdempotencyKey provides a mock function with given fields: ctx, customerID, key, now
+func (_m *IIdempotencyRepository) GetIdempotencyKey(ctx context.Context, customerID uint, key string, now time.Time) (*model.IdempotencyKey, error) {
+	ret := _m.Called(ctx, customerID, key, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetIdempotencyKey")
+	}
+
+	var r0 *model.IdempotencyKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, time.Time) (*model.IdempotencyKey, error)); ok {
+		return rf(ctx, customerID, key, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, time.Time) *model.IdempotencyKey); ok {
+		r0 = rf(ctx, customerID, key, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.IdempotencyKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string, time.Time) error); ok {
+		r1 = rf(ctx, customerID, key, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewIIdempotencyRepository creates a new instance of IIdempotencyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIIdempotencyRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IIdempotencyRepository {
+	mock := &IIdempotencyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -16,6 +16,24 @@ type IOrderRepository struct {
 	mock.Mock
 }
 
+// AnonymizeOrdersByCustomerID provides a mock function with given fields: ctx, tx, customerID
+func (_m *IOrderRepository) AnonymizeOrdersByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	ret := _m.Called(ctx, tx, customerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AnonymizeOrdersByCustomerID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, uint) error); ok {
+		r0 = rf(ctx, tx, customerID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateItem provides a mock function with given fields: ctx, tx, item
 func (_m *IOrderRepository) CreateItem(ctx context.Context, tx transactioner.TxxProvider, item model.Item) error {
 	ret := _m.Called(ctx, tx, item)
@@ -92,9 +110,99 @@ func (_m *IOrderRepository) GetItemsByOrderID(ctx context.Context, orderID uint)
 	return r0, r1
 }
 
-// GetOrderHistoryByCustomerID provides a mock function with given fields: ctx, customerID
-func (_m *IOrderRepository) GetOrderHistoryByCustomerID(ctx context.Context, customerID uint) ([]model.Order, error) {
-	ret := _m.Called(ctx, customerID)
+// GetItemsByOrderIDs provides a mock function with given fields: ctx, orderIDs
+func (_m *IOrderRepository) GetItemsByOrderIDs(ctx context.Context, orderIDs []uint) ([]model.Item, error) {
+	ret := _m.Called(ctx, orderIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetItemsByOrderIDs")
+	}
+
+	var r0 []model.Item
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) ([]model.Item, error)); ok {
+		return rf(ctx, orderIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) []model.Item); ok {
+		r0 = rf(ctx, orderIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Item)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, orderIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderByID provides a mock function with given fields: ctx, id
+func (_m *IOrderRepository) GetOrderByID(ctx context.Context, id uint) (*model.Order, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderByID")
+	}
+
+	var r0 *model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*model.Order, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *model.Order); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderByReference provides a mock function with given fields: ctx, customerID, reference
+func (_m *IOrderRepository) GetOrderByReference(ctx context.Context, customerID uint, reference string) (*model.Order, error) {
+	ret := _m.Called(ctx, customerID, reference)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderByReference")
+	}
+
+	var r0 *model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) (*model.Order, error)); ok {
+		return rf(ctx, customerID, reference)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) *model.Order); ok {
+		r0 = rf(ctx, customerID, reference)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(ctx, customerID, reference)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderHistoryByCustomerID provides a mock function with given fields: ctx, customerID, q
+func (_m *IOrderRepository) GetOrderHistoryByCustomerID(ctx context.Context, customerID uint, q model.OrderHistoryQuery) ([]model.Order, error) {
+	ret := _m.Called(ctx, customerID, q)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetOrderHistoryByCustomerID")
@@ -102,19 +210,19 @@ func (_m *IOrderRepository) GetOrderHistoryByCustomerID(ctx context.Context, cus
 
 	var r0 []model.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint) ([]model.Order, error)); ok {
-		return rf(ctx, customerID)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, model.OrderHistoryQuery) ([]model.Order, error)); ok {
+		return rf(ctx, customerID, q)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint) []model.Order); ok {
-		r0 = rf(ctx, customerID)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, model.OrderHistoryQuery) []model.Order); ok {
+		r0 = rf(ctx, customerID, q)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]model.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
-		r1 = rf(ctx, customerID)
+	if rf, ok := ret.Get(1).(func(context.Context, uint, model.OrderHistoryQuery) error); ok {
+		r1 = rf(ctx, customerID, q)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,97 @@
+// Code generated by mockery v2.42.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	model "ebookstore/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+
+	transactioner "ebookstore/utils/transactioner"
+)
+
+// IPreferenceRepository is an autogenerated mock type for the IPreferenceRepository type
+type IPreferenceRepository struct {
+	mock.Mock
+}
+
+// DeletePreferences provides a mock function with given fields: ctx, tx, customerID
+func (_m *IPreferenceRepository) DeletePreferences(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	ret := _m.Called(ctx, tx, customerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletePreferences")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, uint) error); ok {
+		r0 = rf(ctx, tx, customerID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetPreferences provides a mock function with given fields: ctx, customerID
+func (_m *IPreferenceRepository) GetPreferences(ctx context.Context, customerID uint) ([]model.NotificationPreference, error) {
+	ret := _m.Called(ctx, customerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPreferences")
+	}
+
+	var r0 []model.NotificationPreference
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) ([]model.NotificationPreference, error)); ok {
+		return rf(ctx, customerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []model.NotificationPreference); ok {
+		r0 = rf(ctx, customerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.NotificationPreference)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, customerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPreference provides a mock function with given fields: ctx, tx, preference
+func (_m *IPreferenceRepository) SetPreference(ctx context.Context, tx transactioner.TxxProvider, preference model.NotificationPreference) error {
+	ret := _m.Called(ctx, tx, preference)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPreference")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, transactioner.TxxProvider, model.NotificationPreference) error); ok {
+		r0 = rf(ctx, tx, preference)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIPreferenceRepository creates a new instance of IPreferenceRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIPreferenceRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IPreferenceRepository {
+	mock := &IPreferenceRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,166 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository"
+	"ebookstore/utils/transactioner"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type addressRepository struct {
+	db *sqlx.DB
+}
+
+func NewAddressRepository(db *sqlx.DB) repository.IAddressRepository {
+	return &addressRepository{db: db}
+}
+
+func (r *addressRepository) CreateAddress(ctx context.Context, tx transactioner.TxxProvider, address model.Address) (uint, error) {
+	var id uint
+	query := `
+	INSERT INTO customer_addresses (
+		customer_id,
+		label,
+		receiver_name,
+		address,
+		city,
+		district,
+		postal_code,
+		is_default
+	) VALUES (
+		$1,
+		$2,
+		$3,
+		$4,
+		$5,
+		$6,
+		$7,
+		$8
+	) RETURNING id;`
+
+	var args = []interface{}{
+		address.CustomerID,
+		address.Label,
+		address.ReceiverName,
+		address.Address,
+		address.City,
+		address.District,
+		address.PostalCode,
+		address.IsDefault,
+	}
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *addressRepository) GetAddressesByCustomerID(ctx context.Context, customerID uint) ([]model.Address, error) {
+	var addresses []model.Address
+	query := `
+		SELECT
+			id,
+			customer_id,
+			COALESCE(label, '') AS label,
+			COALESCE(receiver_name, '') AS receiver_name,
+			address,
+			city,
+			district,
+			postal_code,
+			is_default
+		FROM customer_addresses
+		WHERE customer_id = $1 AND deleted_at IS NULL
+		ORDER BY is_default DESC, id`
+
+	err := r.db.SelectContext(ctx, &addresses, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+// GetAddressByID is scoped by customer so one customer can never read or
+// order to another customer's address.
+func (r *addressRepository) GetAddressByID(ctx context.Context, customerID, id uint) (*model.Address, error) {
+	var address model.Address
+	query := `
+		SELECT
+			id,
+			customer_id,
+			COALESCE(label, '') AS label,
+			COALESCE(receiver_name, '') AS receiver_name,
+			address,
+			city,
+			district,
+			postal_code,
+			is_default
+		FROM customer_addresses
+		WHERE id = $1 AND customer_id = $2 AND deleted_at IS NULL`
+
+	err := r.db.GetContext(ctx, &address, query, id, customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+func (r *addressRepository) UpdateAddress(ctx context.Context, tx transactioner.TxxProvider, address model.Address) error {
+	query := `
+		UPDATE customer_addresses SET
+			label = $1,
+			receiver_name = $2,
+			address = $3,
+			city = $4,
+			district = $5,
+			postal_code = $6,
+			is_default = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8 AND customer_id = $9 AND deleted_at IS NULL`
+
+	var args = []interface{}{
+		address.Label,
+		address.ReceiverName,
+		address.Address,
+		address.City,
+		address.District,
+		address.PostalCode,
+		address.IsDefault,
+		address.ID,
+		address.CustomerID,
+	}
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *addressRepository) DeleteAddress(ctx context.Context, customerID, id uint) error {
+	query := "UPDATE customer_addresses SET deleted_at = CURRENT_TIMESTAMP, is_default = FALSE WHERE id = $1 AND customer_id = $2 AND deleted_at IS NULL"
+
+	_, err := r.db.ExecContext(ctx, query, id, customerID)
+	return err
+}
+
+func (r *addressRepository) ClearDefaultAddress(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	query := "UPDATE customer_addresses SET is_default = FALSE WHERE customer_id = $1 AND is_default"
+
+	_, err := tx.ExecContext(ctx, query, customerID)
+	return err
+}
+
+// DeleteAddressesByCustomerID removes the rows for good instead of soft
+// deleting them, since they only hold personal data.
+func (r *addressRepository) DeleteAddressesByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM customer_addresses WHERE customer_id = $1", customerID)
+	return err
+}
@@ -0,0 +1,139 @@
+package postgresql_test
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository/postgresql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_addressRepository_GetAddressByID(t *testing.T) {
+	address := &model.Address{
+		ID:           2,
+		CustomerID:   1,
+		Label:        "home",
+		ReceiverName: "receiver",
+		Address:      "address",
+		City:         "city",
+		District:     "district",
+		PostalCode:   "12345",
+		IsDefault:    true,
+	}
+
+	type fields struct {
+		noRows bool
+		err    error
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    *model.Address
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			want:    address,
+			wantErr: false,
+		},
+		{
+			name:    "address not found",
+			fields:  fields{noRows: true},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "GetContext Error",
+			fields:  fields{err: errors.New("some error")},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewAddressRepository(sqlxDB)
+
+			query := `
+		SELECT
+			id,
+			customer_id,
+			COALESCE(label, '') AS label,
+			COALESCE(receiver_name, '') AS receiver_name,
+			address,
+			city,
+			district,
+			postal_code,
+			is_default
+		FROM customer_addresses
+		WHERE id = $1 AND customer_id = $2 AND deleted_at IS NULL`
+
+			rows := sqlmock.NewRows([]string{"id", "customer_id", "label", "receiver_name", "address", "city", "district", "postal_code", "is_default"})
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(address.ID, address.CustomerID)
+			switch {
+			case tt.fields.err != nil:
+				mockExpectQuery.WillReturnError(tt.fields.err)
+			case tt.fields.noRows:
+				mockExpectQuery.WillReturnRows(rows)
+			default:
+				mockExpectQuery.WillReturnRows(rows.AddRow(address.ID, address.CustomerID, address.Label, address.ReceiverName, address.Address, address.City, address.District, address.PostalCode, address.IsDefault))
+			}
+
+			got, err := testDB.GetAddressByID(context.Background(), address.CustomerID, address.ID)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_addressRepository_DeleteAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			wantErr: false,
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewAddressRepository(sqlxDB)
+
+			query := "UPDATE customer_addresses SET deleted_at = CURRENT_TIMESTAMP, is_default = FALSE WHERE id = $1 AND customer_id = $2 AND deleted_at IS NULL"
+
+			mockExpectExec := m.ExpectExec(query).WithArgs(uint(2), uint(1))
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err = testDB.DeleteAddress(context.Background(), 1, 2)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -2,10 +2,14 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"ebookstore/internal/model"
 	"ebookstore/internal/repository"
+	"ebookstore/utils/transactioner"
+	"errors"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type BookRepository struct {
@@ -38,6 +42,73 @@ func (r *BookRepository) GetBookByID(ctx context.Context, id uint) (model.Book,
 	return book, nil
 }
 
+// GetBooksByIDs returns the books found among ids, in no particular order.
+func (r *BookRepository) GetBooksByIDs(ctx context.Context, ids []uint) ([]model.Book, error) {
+	var books []model.Book
+	query := "SELECT id, title, author, price, category_id FROM books WHERE id = ANY($1) AND deleted_at IS NULL"
+	err := r.db.SelectContext(ctx, &books, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+func (r *BookRepository) SearchBooks(ctx context.Context, query string) ([]model.Book, error) {
+	var books []model.Book
+	sqlQuery := `
+		SELECT id, title, author, price, category_id
+		FROM books
+		WHERE deleted_at IS NULL AND search_vector @@ plainto_tsquery('simple', $1)
+		ORDER BY ts_rank(search_vector, plainto_tsquery('simple', $1)) DESC, id`
+
+	err := r.db.SelectContext(ctx, &books, sqlQuery, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+// CreateBookIfNotExists inserts the book unless one with the same title and
+// author exists, and reports whether it was inserted. The search vector is
+// filled in right away so imported books are searchable.
+func (r *BookRepository) CreateBookIfNotExists(ctx context.Context, tx transactioner.TxxProvider, book model.Book) (bool, error) {
+	var id uint
+	query := `
+		INSERT INTO books (title, author, price, category_id, search_vector)
+		SELECT $1::VARCHAR, $2::VARCHAR, $3::DECIMAL, $4::INTEGER, to_tsvector('simple', $1::VARCHAR || ' ' || $2::VARCHAR || ' ' || COALESCE((SELECT name FROM categories WHERE id = $4::INTEGER), ''))
+		WHERE NOT EXISTS (
+			SELECT 1 FROM books WHERE title = $1::VARCHAR AND author = $2::VARCHAR AND deleted_at IS NULL
+		)
+		RETURNING id`
+
+	err := tx.QueryRowContext(ctx, query, book.Title, book.Author, book.Price, book.CategoryID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReindexSearch rebuilds the search vector of every book from its title,
+// author and category.
+func (r *BookRepository) ReindexSearch(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE books b SET search_vector = to_tsvector('simple', b.title || ' ' || b.author || ' ' || COALESCE((SELECT c.name FROM categories c WHERE c.id = b.category_id), ''))
+		WHERE b.deleted_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
 func (r *BookRepository) GetCategoryByID(ctx context.Context, id uint) (model.Category, error) {
 	var category model.Category
 	query := "SELECT id, name FROM categories WHERE id = $1"
@@ -48,3 +119,28 @@ func (r *BookRepository) GetCategoryByID(ctx context.Context, id uint) (model.Ca
 
 	return category, nil
 }
+
+func (r *BookRepository) GetCategoryByName(ctx context.Context, name string) (*model.Category, error) {
+	var category model.Category
+	query := "SELECT id, name FROM categories WHERE LOWER(name) = LOWER($1)"
+	err := r.db.GetContext(ctx, &category, query, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *BookRepository) CreateCategory(ctx context.Context, tx transactioner.TxxProvider, name string) (uint, error) {
+	var id uint
+	query := "INSERT INTO categories (name) VALUES ($1) RETURNING id"
+	err := tx.QueryRowContext(ctx, query, name).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
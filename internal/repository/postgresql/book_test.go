@@ -2,6 +2,7 @@ package postgresql_test
 
 import (
 	"context"
+	"database/sql"
 	"ebookstore/internal/model"
 	"ebookstore/internal/repository/postgresql"
 	"errors"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -177,6 +179,62 @@ func TestBookRepository_GetBookByID(t *testing.T) {
 	}
 }
 
+func TestBookRepository_GetBooksByIDs(t *testing.T) {
+	books := []model.Book{
+		{ID: 1, Title: "test", Author: "test", Price: 100, CategoryID: 1},
+		{ID: 3, Title: "test 3", Author: "test", Price: 50, CategoryID: 2},
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		want    []model.Book
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			want:    books,
+			wantErr: false,
+		},
+		{
+			name:    "SelectContext error",
+			err:     errors.New("error"),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewBookRepository(sqlxDB)
+
+			query := "SELECT id, title, author, price, category_id FROM books WHERE id = ANY($1) AND deleted_at IS NULL"
+
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(pq.Array([]uint{1, 3}))
+			if tt.err != nil {
+				mockExpectQuery.WillReturnError(tt.err)
+			} else {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "price", "category_id"})
+				for _, book := range books {
+					rows.AddRow(book.ID, book.Title, book.Author, book.Price, book.CategoryID)
+				}
+				mockExpectQuery.WillReturnRows(rows)
+			}
+
+			got, err := testDB.GetBooksByIDs(context.Background(), []uint{1, 3})
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestBookRepository_GetCategoryByID(t *testing.T) {
 	cat := model.Category{
 		ID:   1,
@@ -253,3 +311,103 @@ func TestBookRepository_GetCategoryByID(t *testing.T) {
 		})
 	}
 }
+
+func TestBookRepository_GetCategoryByName(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    *model.Category
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			want:    &model.Category{ID: 2, Name: "Fantasy"},
+			wantErr: false,
+		},
+		{
+			name:    "not found",
+			err:     sql.ErrNoRows,
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "GetContext error",
+			err:     errors.New("error"),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewBookRepository(sqlxDB)
+
+			query := "SELECT id, name FROM categories WHERE LOWER(name) = LOWER($1)"
+
+			mockExpectQuery := m.ExpectQuery(query).WithArgs("fantasy")
+			if tt.err != nil {
+				mockExpectQuery.WillReturnError(tt.err)
+			} else {
+				mockExpectQuery.WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Fantasy"))
+			}
+
+			got, err := testDB.GetCategoryByName(context.Background(), "fantasy")
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBookRepository_ReindexSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    int64
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("error"),
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewBookRepository(sqlxDB)
+
+			query := `
+		UPDATE books b SET search_vector = to_tsvector('simple', b.title || ' ' || b.author || ' ' || COALESCE((SELECT c.name FROM categories c WHERE c.id = b.category_id), ''))
+		WHERE b.deleted_at IS NULL`
+
+			mockExpectExec := m.ExpectExec(query)
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, 3))
+			}
+
+			got, err := testDB.ReindexSearch(context.Background())
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
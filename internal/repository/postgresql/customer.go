@@ -2,8 +2,11 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"ebookstore/internal/model"
 	"ebookstore/internal/repository"
+	"ebookstore/utils/transactioner"
+	"errors"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -16,10 +19,10 @@ func NewCustomerRepository(db *sqlx.DB) repository.ICustomerRepository {
 	return &customerRepository{db: db}
 }
 
-func (c *customerRepository) Register(ctx context.Context, customer *model.Customer) (uint, error) {
+func (c *customerRepository) Register(ctx context.Context, tx transactioner.TxxProvider, customer *model.Customer) (uint, error) {
 	var id uint
 	query := "INSERT INTO customers (email, password, username) VALUES ($1, $2, $3) RETURNING id;"
-	err := c.db.QueryRowxContext(ctx, query, customer.Email, customer.Password, customer.Username).Scan(&id)
+	err := tx.QueryRowContext(ctx, query, customer.Email, customer.Password, customer.Username).Scan(&id)
 	if err != nil {
 		return 0, err
 
@@ -39,3 +42,140 @@ func (c *customerRepository) GetCustomerByEmail(ctx context.Context, email strin
 
 	return &customer, nil
 }
+
+const customerProfileColumns = `
+		id,
+		email,
+		username,
+		password,
+		role,
+		COALESCE(pending_email, '') AS pending_email,
+		COALESCE(email_verification_token, '') AS email_verification_token,
+		email_verification_expires_at`
+
+func (c *customerRepository) GetCustomerByID(ctx context.Context, id uint) (*model.Customer, error) {
+	var customer model.Customer
+	query := "SELECT" + customerProfileColumns + " FROM customers WHERE id = $1 AND deleted_at IS NULL"
+
+	err := c.db.GetContext(ctx, &customer, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+func (c *customerRepository) GetCustomerByEmailVerificationToken(ctx context.Context, tokenHash string) (*model.Customer, error) {
+	var customer model.Customer
+	query := "SELECT" + customerProfileColumns + " FROM customers WHERE email_verification_token = $1"
+
+	err := c.db.GetContext(ctx, &customer, query, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+func (c *customerRepository) UpdateCustomer(ctx context.Context, tx transactioner.TxxProvider, customer *model.Customer) error {
+	query := `
+		UPDATE customers SET
+			email = $1,
+			username = $2,
+			password = $3,
+			pending_email = NULLIF($4, ''),
+			email_verification_token = NULLIF($5, ''),
+			email_verification_expires_at = $6,
+			updated_at = $7
+		WHERE id = $8`
+
+	var args = []interface{}{
+		customer.Email,
+		customer.Username,
+		customer.Password,
+		customer.PendingEmail,
+		customer.EmailVerificationTokenHash,
+		customer.EmailVerificationExpiresAt,
+		customer.UpdatedAt,
+		customer.ID,
+	}
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (c *customerRepository) GetCustomerByIdentity(ctx context.Context, issuer, subject string) (*model.Customer, error) {
+	var customer model.Customer
+	query := `
+		SELECT c.id, c.email, c.username, c.password
+		FROM customers c
+		JOIN customer_identities ci ON ci.customer_id = c.id
+		WHERE ci.issuer = $1 AND ci.subject = $2`
+
+	err := c.db.GetContext(ctx, &customer, query, issuer, subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+func (c *customerRepository) LinkIdentity(ctx context.Context, tx transactioner.TxxProvider, identity model.CustomerIdentity) error {
+	query := `
+		INSERT INTO customer_identities (customer_id, issuer, subject, email)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (issuer, subject) DO NOTHING`
+
+	_, err := tx.ExecContext(ctx, query, identity.CustomerID, identity.Issuer, identity.Subject, identity.Email)
+	return err
+}
+
+func (c *customerRepository) GetIdentitiesByCustomerID(ctx context.Context, customerID uint) ([]model.CustomerIdentity, error) {
+	var identities []model.CustomerIdentity
+	query := "SELECT id, customer_id, issuer, subject, email, created_at FROM customer_identities WHERE customer_id = $1 ORDER BY id"
+
+	err := c.db.SelectContext(ctx, &identities, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// AnonymizeCustomer removes the personal data of a customer while keeping the
+// row, so orders still reference it. Linked social logins are dropped too.
+func (c *customerRepository) AnonymizeCustomer(ctx context.Context, tx transactioner.TxxProvider, id uint) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM customer_identities WHERE customer_id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE customers SET
+			email = 'deleted-' || id || '@deleted.invalid',
+			username = $1,
+			password = '',
+			pending_email = NULL,
+			email_verification_token = NULL,
+			email_verification_expires_at = NULL,
+			updated_at = CURRENT_TIMESTAMP,
+			deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $2`
+
+	_, err = tx.ExecContext(ctx, query, model.AnonymizedValue, id)
+	return err
+}
+
+func (c *customerRepository) SetRole(ctx context.Context, id uint, role string) error {
+	_, err := c.db.ExecContext(ctx, "UPDATE customers SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", role, id)
+	return err
+}
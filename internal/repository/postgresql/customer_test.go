@@ -69,6 +69,7 @@ func Test_customerRepository_Register(t *testing.T) {
 
 			query := "INSERT INTO customers (email, password, username) VALUES ($1, $2, $3) RETURNING id;"
 
+			m.ExpectBegin()
 			mockExpectQuery := m.ExpectQuery(query).WithArgs(tt.args.customer.Email, tt.args.customer.Password, tt.args.customer.Username)
 			if tt.fields.err != nil {
 				mockExpectQuery.WillReturnError(err)
@@ -77,7 +78,8 @@ func Test_customerRepository_Register(t *testing.T) {
 				mockExpectQuery.WillReturnRows(row)
 			}
 
-			got, err := testDB.Register(tt.args.ctx, tt.args.customer)
+			tx, _ := sqlxDB.Beginx()
+			got, err := testDB.Register(tt.args.ctx, tx, tt.args.customer)
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.want, got)
 		})
@@ -162,3 +164,176 @@ func Test_customerRepository_GetCustomerByEmail(t *testing.T) {
 		})
 	}
 }
+
+func Test_customerRepository_GetCustomerByIdentity(t *testing.T) {
+	customer := &model.Customer{
+		ID:       1,
+		Email:    "username@mail.com",
+		Username: "username",
+	}
+
+	type fields struct {
+		noRows bool
+		err    error
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    *model.Customer
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			want:    customer,
+			wantErr: false,
+		},
+		{
+			name:    "identity not linked",
+			fields:  fields{noRows: true},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "GetContext Error",
+			fields:  fields{err: errors.New("some error")},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewCustomerRepository(sqlxDB)
+
+			query := `
+			SELECT c.id, c.email, c.username, c.password
+			FROM customers c
+			JOIN customer_identities ci ON ci.customer_id = c.id
+			WHERE ci.issuer = $1 AND ci.subject = $2`
+
+			rows := sqlmock.NewRows([]string{"id", "email", "username", "password"})
+			mockExpectQuery := m.ExpectQuery(query).WithArgs("https://idp", "subject")
+			switch {
+			case tt.fields.err != nil:
+				mockExpectQuery.WillReturnError(tt.fields.err)
+			case tt.fields.noRows:
+				mockExpectQuery.WillReturnRows(rows)
+			default:
+				mockExpectQuery.WillReturnRows(rows.AddRow(customer.ID, customer.Email, customer.Username, customer.Password))
+			}
+
+			got, err := testDB.GetCustomerByIdentity(context.Background(), "https://idp", "subject")
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_customerRepository_UpdateCustomer(t *testing.T) {
+	customer := &model.Customer{
+		ID:           1,
+		Email:        "old@mail.com",
+		Username:     "username",
+		Password:     "hashed",
+		PendingEmail: "new@mail.com",
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			wantErr: false,
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewCustomerRepository(sqlxDB)
+
+			query := `
+			UPDATE customers SET
+				email = $1,
+				username = $2,
+				password = $3,
+				pending_email = NULLIF($4, ''),
+				email_verification_token = NULLIF($5, ''),
+				email_verification_expires_at = $6,
+				updated_at = $7
+			WHERE id = $8`
+
+			m.ExpectBegin()
+			mockExpectExec := m.ExpectExec(query).WithArgs(customer.Email, customer.Username, customer.Password, customer.PendingEmail, customer.EmailVerificationTokenHash, customer.EmailVerificationExpiresAt, customer.UpdatedAt, customer.ID)
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			tx, _ := sqlxDB.Beginx()
+			err = testDB.UpdateCustomer(context.Background(), tx, customer)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_customerRepository_SetRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			wantErr: false,
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewCustomerRepository(sqlxDB)
+
+			query := "UPDATE customers SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2"
+
+			mockExpectExec := m.ExpectExec(query).WithArgs(model.RoleAdmin, uint(1))
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err = testDB.SetRole(context.Background(), 1, model.RoleAdmin)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository"
+	"ebookstore/utils/transactioner"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type idempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) repository.IIdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// GetIdempotencyKey returns the key of a customer unless it expired by now.
+func (r *idempotencyRepository) GetIdempotencyKey(ctx context.Context, customerID uint, key string, now time.Time) (*model.IdempotencyKey, error) {
+	var idempotencyKey model.IdempotencyKey
+	query := `
+		SELECT customer_id, key, request_hash, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE customer_id = $1 AND key = $2 AND expires_at > $3`
+
+	err := r.db.GetContext(ctx, &idempotencyKey, query, customerID, key, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &idempotencyKey, nil
+}
+
+// CreateIdempotencyKey stores the key in the caller's transaction, replacing
+// an expired one, and reports whether it was stored. It is not when another
+// transaction holds the key: the insert waits for it to finish, so concurrent
+// requests with one key are not both stored.
+func (r *idempotencyRepository) CreateIdempotencyKey(ctx context.Context, tx transactioner.TxxProvider, key model.IdempotencyKey) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (customer_id, key, request_hash, response, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (customer_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, response = EXCLUDED.response, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`
+
+	result, err := tx.ExecContext(ctx, query, key.CustomerID, key.Key, key.RequestHash, key.Response, key.CreatedAt, key.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *idempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteIdempotencyKeysByCustomerID removes the keys of the customer, their
+// stored responses hold the order references.
+func (r *idempotencyRepository) DeleteIdempotencyKeysByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE customer_id = $1", customerID)
+	return err
+}
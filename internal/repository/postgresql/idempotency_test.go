@@ -0,0 +1,180 @@
+package postgresql_test
+
+import (
+	"context"
+	"database/sql"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository/postgresql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_idempotencyRepository_GetIdempotencyKey(t *testing.T) {
+	now := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+	key := model.IdempotencyKey{
+		CustomerID:  1,
+		Key:         "retry-1",
+		RequestHash: "hash",
+		Response:    []byte(`{"order_id":1}`),
+		CreatedAt:   now.Add(-time.Hour),
+		ExpiresAt:   now.Add(time.Hour),
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		want    *model.IdempotencyKey
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			want: &key,
+		},
+		{
+			name: "not found or expired",
+			err:  sql.ErrNoRows,
+			want: nil,
+		},
+		{
+			name:    "GetContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			testDB := postgresql.NewIdempotencyRepository(sqlx.NewDb(db, "sqlmock"))
+
+			query := `
+				SELECT customer_id, key, request_hash, response, created_at, expires_at
+				FROM idempotency_keys
+				WHERE customer_id = $1 AND key = $2 AND expires_at > $3`
+
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(uint(1), "retry-1", now)
+			if tt.err != nil {
+				mockExpectQuery.WillReturnError(tt.err)
+			} else {
+				mockExpectQuery.WillReturnRows(sqlmock.NewRows([]string{"customer_id", "key", "request_hash", "response", "created_at", "expires_at"}).
+					AddRow(key.CustomerID, key.Key, key.RequestHash, key.Response, key.CreatedAt, key.ExpiresAt))
+			}
+
+			got, err := testDB.GetIdempotencyKey(context.Background(), 1, "retry-1", now)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_idempotencyRepository_CreateIdempotencyKey(t *testing.T) {
+	now := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+	key := model.IdempotencyKey{
+		CustomerID:  1,
+		Key:         "retry-1",
+		RequestHash: "hash",
+		Response:    []byte(`{"order_id":1}`),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(24 * time.Hour),
+	}
+
+	tests := []struct {
+		name     string
+		affected int64
+		err      error
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "best case",
+			affected: 1,
+			want:     true,
+		},
+		{
+			name:     "key in use",
+			affected: 0,
+			want:     false,
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewIdempotencyRepository(sqlxDB)
+
+			query := `
+				INSERT INTO idempotency_keys (customer_id, key, request_hash, response, created_at, expires_at)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (customer_id, key) DO UPDATE
+				SET request_hash = EXCLUDED.request_hash, response = EXCLUDED.response, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+				WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`
+
+			m.ExpectBegin()
+			mockExpectExec := m.ExpectExec(query).WithArgs(key.CustomerID, key.Key, key.RequestHash, key.Response, key.CreatedAt, key.ExpiresAt)
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			}
+
+			tx, _ := sqlxDB.Beginx()
+			got, err := testDB.CreateIdempotencyKey(context.Background(), tx, key)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_idempotencyRepository_DeleteExpiredIdempotencyKeys(t *testing.T) {
+	now := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+
+	db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	testDB := postgresql.NewIdempotencyRepository(sqlx.NewDb(db, "sqlmock"))
+	m.ExpectExec("DELETE FROM idempotency_keys WHERE expires_at <= $1").WithArgs(now).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	got, err := testDB.DeleteExpiredIdempotencyKeys(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), got)
+}
+
+func Test_idempotencyRepository_DeleteIdempotencyKeysByCustomerID(t *testing.T) {
+	db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	testDB := postgresql.NewIdempotencyRepository(sqlxDB)
+	m.ExpectBegin()
+	m.ExpectExec("DELETE FROM idempotency_keys WHERE customer_id = $1").WithArgs(uint(1)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	tx, _ := sqlxDB.Beginx()
+	err = testDB.DeleteIdempotencyKeysByCustomerID(context.Background(), tx, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, m.ExpectationsWereMet())
+}
@@ -0,0 +1,159 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository"
+	"ebookstore/utils/transactioner"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type mfaRepository struct {
+	db *sqlx.DB
+}
+
+func NewMFARepository(db *sqlx.DB) repository.IMFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) GetMFAByCustomerID(ctx context.Context, customerID uint) (*model.CustomerMFA, error) {
+	var mfa model.CustomerMFA
+	query := "SELECT customer_id, totp_secret, enabled, last_used_step, failed_attempts, locked_until FROM customer_mfa WHERE customer_id = $1"
+
+	err := r.db.GetContext(ctx, &mfa, query, customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &mfa, nil
+}
+
+// UpsertMFASecret stores a fresh secret and resets the enrollment, the
+// secret only becomes active once EnableMFA is called.
+func (r *mfaRepository) UpsertMFASecret(ctx context.Context, customerID uint, secret string) error {
+	query := `
+		INSERT INTO customer_mfa (customer_id, totp_secret, enabled, last_used_step)
+		VALUES ($1, $2, FALSE, 0)
+		ON CONFLICT (customer_id) DO UPDATE
+		SET totp_secret = EXCLUDED.totp_secret, enabled = FALSE, last_used_step = 0, enabled_at = NULL`
+
+	_, err := r.db.ExecContext(ctx, query, customerID, secret)
+	return err
+}
+
+func (r *mfaRepository) EnableMFA(ctx context.Context, tx transactioner.TxxProvider, customerID uint, step int64) error {
+	query := "UPDATE customer_mfa SET enabled = TRUE, last_used_step = $1, enabled_at = CURRENT_TIMESTAMP WHERE customer_id = $2"
+
+	_, err := tx.ExecContext(ctx, query, step, customerID)
+	return err
+}
+
+func (r *mfaRepository) DisableMFA(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM customer_recovery_codes WHERE customer_id = $1", customerID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM customer_mfa WHERE customer_id = $1", customerID)
+	return err
+}
+
+// ConsumeTOTPStep records the time step of an accepted code. It only succeeds
+// when the step is newer than the last one used, so a code cannot be replayed.
+func (r *mfaRepository) ConsumeTOTPStep(ctx context.Context, customerID uint, step int64) (bool, error) {
+	query := "UPDATE customer_mfa SET last_used_step = $1 WHERE customer_id = $2 AND last_used_step < $1"
+
+	res, err := r.db.ExecContext(ctx, query, step, customerID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// SetMFAChallenge stores the challenge handed out with a login, replacing
+// any earlier one that was not used yet.
+func (r *mfaRepository) SetMFAChallenge(ctx context.Context, customerID uint, challengeID string) error {
+	query := "UPDATE customer_mfa SET challenge_id = $1 WHERE customer_id = $2"
+
+	_, err := r.db.ExecContext(ctx, query, challengeID, customerID)
+	return err
+}
+
+// ConsumeMFAChallenge clears the challenge and reports whether it was the
+// current one, so every challenge allows a single attempt.
+func (r *mfaRepository) ConsumeMFAChallenge(ctx context.Context, customerID uint, challengeID string) (bool, error) {
+	query := "UPDATE customer_mfa SET challenge_id = NULL WHERE customer_id = $1 AND challenge_id = $2"
+
+	res, err := r.db.ExecContext(ctx, query, customerID, challengeID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// RecordMFAFailure counts a wrong code. The failure that reaches maxAttempts
+// locks the second factor until lockUntil and starts the count over.
+func (r *mfaRepository) RecordMFAFailure(ctx context.Context, customerID uint, maxAttempts int, lockUntil time.Time) error {
+	query := `
+		UPDATE customer_mfa
+		SET failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+			locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE customer_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, customerID, maxAttempts, lockUntil)
+	return err
+}
+
+func (r *mfaRepository) ResetMFAFailures(ctx context.Context, customerID uint) error {
+	query := "UPDATE customer_mfa SET failed_attempts = 0, locked_until = NULL WHERE customer_id = $1"
+
+	_, err := r.db.ExecContext(ctx, query, customerID)
+	return err
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, tx transactioner.TxxProvider, customerID uint, codeHashes []string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM customer_recovery_codes WHERE customer_id = $1", customerID)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO customer_recovery_codes (customer_id, code_hash) SELECT $1, unnest($2::text[])"
+	_, err = tx.ExecContext(ctx, query, customerID, pq.Array(codeHashes))
+	return err
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, customerID uint, codeHash string) (bool, error) {
+	query := "UPDATE customer_recovery_codes SET used_at = CURRENT_TIMESTAMP WHERE customer_id = $1 AND code_hash = $2 AND used_at IS NULL"
+
+	res, err := r.db.ExecContext(ctx, query, customerID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,244 @@
+package postgresql_test
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository/postgresql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_mfaRepository_GetMFAByCustomerID(t *testing.T) {
+	mfa := &model.CustomerMFA{
+		CustomerID:   1,
+		TOTPSecret:   "secret",
+		Enabled:      true,
+		LastUsedStep: 10,
+	}
+
+	type fields struct {
+		noRows bool
+		err    error
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    *model.CustomerMFA
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			want:    mfa,
+			wantErr: false,
+		},
+		{
+			name:    "not enrolled",
+			fields:  fields{noRows: true},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "GetContext error",
+			fields:  fields{err: errors.New("some error")},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewMFARepository(sqlxDB)
+
+			query := "SELECT customer_id, totp_secret, enabled, last_used_step, failed_attempts, locked_until FROM customer_mfa WHERE customer_id = $1"
+
+			rows := sqlmock.NewRows([]string{"customer_id", "totp_secret", "enabled", "last_used_step", "failed_attempts", "locked_until"})
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(uint(1))
+			switch {
+			case tt.fields.err != nil:
+				mockExpectQuery.WillReturnError(tt.fields.err)
+			case tt.fields.noRows:
+				mockExpectQuery.WillReturnRows(rows)
+			default:
+				mockExpectQuery.WillReturnRows(rows.AddRow(mfa.CustomerID, mfa.TOTPSecret, mfa.Enabled, mfa.LastUsedStep, mfa.FailedAttempts, nil))
+			}
+
+			got, err := testDB.GetMFAByCustomerID(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_mfaRepository_ConsumeTOTPStep(t *testing.T) {
+	type fields struct {
+		affected int64
+		err      error
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			fields:  fields{affected: 1},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:    "step already used",
+			fields:  fields{affected: 0},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:    "ExecContext error",
+			fields:  fields{err: errors.New("some error")},
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewMFARepository(sqlxDB)
+
+			query := "UPDATE customer_mfa SET last_used_step = $1 WHERE customer_id = $2 AND last_used_step < $1"
+
+			mockExpectExec := m.ExpectExec(query).WithArgs(int64(42), uint(1))
+			if tt.fields.err != nil {
+				mockExpectExec.WillReturnError(tt.fields.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, tt.fields.affected))
+			}
+
+			got, err := testDB.ConsumeTOTPStep(context.Background(), 1, 42)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_mfaRepository_ConsumeMFAChallenge(t *testing.T) {
+	type fields struct {
+		affected int64
+		err      error
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			fields:  fields{affected: 1},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:    "challenge already used or replaced",
+			fields:  fields{affected: 0},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:    "ExecContext error",
+			fields:  fields{err: errors.New("some error")},
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewMFARepository(sqlxDB)
+
+			query := "UPDATE customer_mfa SET challenge_id = NULL WHERE customer_id = $1 AND challenge_id = $2"
+
+			mockExpectExec := m.ExpectExec(query).WithArgs(uint(1), "challenge")
+			if tt.fields.err != nil {
+				mockExpectExec.WillReturnError(tt.fields.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, tt.fields.affected))
+			}
+
+			got, err := testDB.ConsumeMFAChallenge(context.Background(), 1, "challenge")
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_mfaRepository_RecordMFAFailure(t *testing.T) {
+	lockUntil := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			wantErr: false,
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewMFARepository(sqlxDB)
+
+			query := `
+		UPDATE customer_mfa
+		SET failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+			locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE customer_id = $1`
+
+			mockExpectExec := m.ExpectExec(query).WithArgs(uint(1), 5, lockUntil)
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err = testDB.RecordMFAFailure(context.Background(), 1, 5, lockUntil)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
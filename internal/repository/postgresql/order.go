@@ -2,11 +2,17 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"ebookstore/internal/model"
 	"ebookstore/internal/repository"
 	"ebookstore/utils/transactioner"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type orderRepository struct {
@@ -17,6 +23,22 @@ func NewOrderRepository(db *sqlx.DB) repository.IOrderRepository {
 	return &orderRepository{db: db}
 }
 
+const orderColumns = `
+			id,
+			customer_id,
+			customer_reference,
+			receiver_name,
+			address,
+			city,
+			district,
+			postal_code,
+			shipper,
+			airwaybill_number,
+			status,
+			order_date,
+			total_item,
+			total_price`
+
 func (o *orderRepository) GetItemsByOrderID(ctx context.Context, OrderID uint) ([]model.Item, error) {
 
 	var items []model.Item
@@ -46,6 +68,28 @@ func (o *orderRepository) GetItemsByOrderID(ctx context.Context, OrderID uint) (
 
 }
 
+// GetItemsByOrderIDs returns the items of every order in one query, grouped by
+// order.
+func (o *orderRepository) GetItemsByOrderIDs(ctx context.Context, orderIDs []uint) ([]model.Item, error) {
+	var items []model.Item
+	query := `
+		SELECT
+			id,
+			book_id,
+			order_id,
+			quantity
+		FROM items
+		WHERE order_id = ANY($1)
+		ORDER BY order_id, id`
+
+	err := o.db.SelectContext(ctx, &items, query, pq.Array(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
 func (o *orderRepository) CreateItem(ctx context.Context, tx transactioner.TxxProvider, item model.Item) error {
 	query := "INSERT INTO items (book_id,quantity,order_id,created_at) VALUES ($1,$2,$3,$4)"
 
@@ -82,7 +126,9 @@ func (o *orderRepository) CreateOrder(ctx context.Context, tx transactioner.TxxP
 		$8,
 		$9,
 		$10
-	) RETURNING id;`
+	)
+	ON CONFLICT DO NOTHING
+	RETURNING id;`
 
 	var args = []interface{}{
 		req.CustomerID,
@@ -97,7 +143,11 @@ func (o *orderRepository) CreateOrder(ctx context.Context, tx transactioner.TxxP
 		req.AirwaybillNumber,
 	}
 
+	//a unique violation would abort the transaction, a skipped insert returns no row
 	err := tx.QueryRowContext(ctx, query, args...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repository.ErrDuplicateReference
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -105,28 +155,65 @@ func (o *orderRepository) CreateOrder(ctx context.Context, tx transactioner.TxxP
 	return id, nil
 }
 
-func (o *orderRepository) GetOrderHistoryByCustomerID(ctx context.Context, cusomterID uint) ([]model.Order, error) {
+func (o *orderRepository) GetOrderByID(ctx context.Context, id uint) (*model.Order, error) {
+	var order model.Order
+	query := `
+		SELECT` + orderColumns + `
+		FROM orders
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	err := o.db.GetContext(ctx, &order, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// GetOrderHistoryByCustomerID pages through the orders of a customer, newest
+// first. The filters of q are appended as numbered arguments after the
+// customer ID.
+func (o *orderRepository) GetOrderHistoryByCustomerID(ctx context.Context, customerID uint, q model.OrderHistoryQuery) ([]model.Order, error) {
 	var orders []model.Order
+	conditions := []string{"customer_id = $1", "deleted_at IS NULL"}
+	args := []interface{}{customerID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if q.AfterID != 0 {
+		conditions = append(conditions, fmt.Sprintf("(order_date, id) < (%s, %s)", arg(q.AfterDate), arg(q.AfterID)))
+	}
+	if !q.From.IsZero() {
+		conditions = append(conditions, "order_date >= "+arg(q.From))
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "order_date < "+arg(q.To))
+	}
+	if q.Status != "" {
+		conditions = append(conditions, "status = "+arg(q.Status))
+	}
+	if q.Search != "" {
+		pattern := arg("%" + escapeLike(q.Search) + "%")
+		conditions = append(conditions, fmt.Sprintf(`(customer_reference ILIKE %s OR EXISTS (
+			SELECT 1 FROM items JOIN books ON books.id = items.book_id
+			WHERE items.order_id = orders.id AND books.title ILIKE %s))`, pattern, pattern))
+	}
+
 	query := `
-		SELECT 
-			id,
-			customer_id,
-			customer_reference,
-			receiver_name,
-			address,
-			city,
-			district,
-			postal_code,
-			shipper,
-			airwaybill_number,
-			order_date,
-			total_item,
-			total_price
-		FROM orders 
-		WHERE customer_id = $1 AND deleted_at is NULL
-		ORDER By order_date DESC`
+		SELECT` + orderColumns + `
+		FROM orders
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY order_date DESC, id DESC`
+	if q.Limit > 0 {
+		query += " LIMIT " + arg(q.Limit)
+	}
 
-	err := o.db.SelectContext(ctx, &orders, query, cusomterID)
+	err := o.db.SelectContext(ctx, &orders, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -134,6 +221,31 @@ func (o *orderRepository) GetOrderHistoryByCustomerID(ctx context.Context, cusom
 	return orders, nil
 }
 
+// GetOrderByReference returns the order of a customer with the reference, the
+// orders of other customers are not found.
+func (o *orderRepository) GetOrderByReference(ctx context.Context, customerID uint, reference string) (*model.Order, error) {
+	var order model.Order
+	query := `
+		SELECT` + orderColumns + `
+		FROM orders
+		WHERE customer_id = $1 AND customer_reference = $2 AND deleted_at IS NULL`
+
+	err := o.db.GetContext(ctx, &order, query, customerID, reference)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// escapeLike makes the wildcards of a search match themselves.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func (o *orderRepository) UpdateOrderByOrderID(ctx context.Context, tx transactioner.TxxProvider, order model.Order) error {
 	query := "UPDATE orders SET total_item = $1, total_price = $2 WHERE id = $3"
 	_, err := tx.ExecContext(ctx, query, order.TotalItem, order.TotalPrice, order.ID)
@@ -143,3 +255,20 @@ func (o *orderRepository) UpdateOrderByOrderID(ctx context.Context, tx transacti
 
 	return nil
 }
+
+// AnonymizeOrdersByCustomerID clears the receiver details of every order of a
+// customer. Totals and items are kept for accounting.
+func (o *orderRepository) AnonymizeOrdersByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	query := `
+		UPDATE orders SET
+			receiver_name = $1,
+			address = $1,
+			city = $1,
+			district = $1,
+			postal_code = $1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE customer_id = $2`
+
+	_, err := tx.ExecContext(ctx, query, model.AnonymizedValue, customerID)
+	return err
+}
@@ -3,7 +3,9 @@ package postgresql_test
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"ebookstore/internal/model"
+	"ebookstore/internal/repository"
 	"ebookstore/internal/repository/postgresql"
 	"errors"
 	"testing"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -102,7 +105,112 @@ func Test_orderRepository_GetItemsByOrderID(t *testing.T) {
 	}
 }
 
+func Test_orderRepository_GetItemsByOrderIDs(t *testing.T) {
+	items := []model.Item{
+		{ID: 1, BookID: 1, OrderID: 1, Quantity: 1},
+		{ID: 2, BookID: 2, OrderID: 2, Quantity: 3},
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		want    []model.Item
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			want:    items,
+			wantErr: false,
+		},
+		{
+			name:    "SelectContext error",
+			err:     errors.New("error"),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewOrderRepository(sqlxDB)
+
+			query := `
+			SELECT
+				id,
+				book_id,
+				order_id,
+				quantity
+			FROM items
+			WHERE order_id = ANY($1)
+			ORDER BY order_id, id`
+
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(pq.Array([]uint{1, 2}))
+			if tt.err != nil {
+				mockExpectQuery.WillReturnError(tt.err)
+			} else {
+				rows := sqlmock.NewRows([]string{"id", "book_id", "order_id", "quantity"})
+				for _, item := range items {
+					rows.AddRow(item.ID, item.BookID, item.OrderID, item.Quantity)
+				}
+				mockExpectQuery.WillReturnRows(rows)
+			}
+
+			got, err := testDB.GetItemsByOrderIDs(context.Background(), []uint{1, 2})
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+const orderColumns = `
+	id,
+	customer_id,
+	customer_reference,
+	receiver_name,
+	address,
+	city,
+	district,
+	postal_code,
+	shipper,
+	airwaybill_number,
+	status,
+	order_date,
+	total_item,
+	total_price`
+
+func orderRows(orders []model.Order) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "customer_id", "customer_reference", "receiver_name", "address", "city", "district", "postal_code", "shipper", "airwaybill_number", "status", "order_date", "total_item", "total_price"})
+	for _, order := range orders {
+		rows.AddRow(
+			order.ID,
+			order.CustomerID,
+			order.CustomerReference,
+			order.ReceiverName,
+			order.Address,
+			order.City,
+			order.District,
+			order.PostalCode,
+			order.Shipper,
+			order.AirwaybillNumber,
+			order.Status,
+			order.OrderDate,
+			order.TotalItem,
+			order.TotalPrice,
+		)
+	}
+
+	return rows
+}
+
 func Test_orderRepository_GetOrderHistoryByCustomerID(t *testing.T) {
+	orderDate := time.Now().UTC().Truncate(time.Minute)
 	orders := []model.Order{
 		{
 			ID:                1,
@@ -115,7 +223,8 @@ func Test_orderRepository_GetOrderHistoryByCustomerID(t *testing.T) {
 			PostalCode:        "123",
 			Shipper:           "shipper",
 			AirwaybillNumber:  "123",
-			OrderDate:         time.Now().UTC().Truncate(time.Minute),
+			Status:            model.OrderPlaced,
+			OrderDate:         orderDate,
 			TotalItem:         1,
 			TotalPrice:        1,
 		},
@@ -127,14 +236,17 @@ func Test_orderRepository_GetOrderHistoryByCustomerID(t *testing.T) {
 	}
 	type args struct {
 		ctx        context.Context
-		cusomterID uint
+		customerID uint
+		q          model.OrderHistoryQuery
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    []model.Order
-		wantErr bool
+		name      string
+		fields    fields
+		args      args
+		wantWhere string
+		wantArgs  []driver.Value
+		want      []model.Order
+		wantErr   bool
 	}{
 		{
 			name: "best case",
@@ -144,10 +256,44 @@ func Test_orderRepository_GetOrderHistoryByCustomerID(t *testing.T) {
 			},
 			args: args{
 				ctx:        context.Background(),
-				cusomterID: 1,
+				customerID: 1,
 			},
-			want:    orders,
-			wantErr: false,
+			wantWhere: "WHERE customer_id = $1 AND deleted_at IS NULL ORDER BY order_date DESC, id DESC",
+			wantArgs:  []driver.Value{1},
+			want:      orders,
+			wantErr:   false,
+		},
+		{
+			name: "every filter",
+			fields: fields{
+				data: orders,
+				err:  nil,
+			},
+			args: args{
+				ctx:        context.Background(),
+				customerID: 1,
+				q: model.OrderHistoryQuery{
+					AfterDate: orderDate,
+					AfterID:   9,
+					From:      orderDate.Add(-48 * time.Hour),
+					To:        orderDate.Add(time.Hour),
+					Status:    model.OrderPlaced,
+					Search:    "100%_hobbit",
+					Limit:     21,
+				},
+			},
+			wantWhere: `WHERE customer_id = $1 AND deleted_at IS NULL
+				AND (order_date, id) < ($2, $3)
+				AND order_date >= $4
+				AND order_date < $5
+				AND status = $6
+				AND (customer_reference ILIKE $7 OR EXISTS (
+					SELECT 1 FROM items JOIN books ON books.id = items.book_id
+					WHERE items.order_id = orders.id AND books.title ILIKE $7))
+				ORDER BY order_date DESC, id DESC LIMIT $8`,
+			wantArgs: []driver.Value{1, orderDate, 9, orderDate.Add(-48 * time.Hour), orderDate.Add(time.Hour), model.OrderPlaced, `%100\%\_hobbit%`, 21},
+			want:     orders,
+			wantErr:  false,
 		},
 		{
 			name: "SelectContext error",
@@ -157,10 +303,12 @@ func Test_orderRepository_GetOrderHistoryByCustomerID(t *testing.T) {
 			},
 			args: args{
 				ctx:        context.Background(),
-				cusomterID: 1,
+				customerID: 1,
 			},
-			want:    nil,
-			wantErr: true,
+			wantWhere: "WHERE customer_id = $1 AND deleted_at IS NULL ORDER BY order_date DESC, id DESC",
+			wantArgs:  []driver.Value{1},
+			want:      nil,
+			wantErr:   true,
 		},
 	}
 	for _, tt := range tests {
@@ -174,52 +322,141 @@ func Test_orderRepository_GetOrderHistoryByCustomerID(t *testing.T) {
 			sqlxDB := sqlx.NewDb(db, "sqlmock")
 			testDB := postgresql.NewOrderRepository(sqlxDB)
 
-			query := `
-				SELECT 
-					id,
-					customer_id,
-					customer_reference,
-					receiver_name,
-					address,
-					city,
-					district,
-					postal_code,
-					shipper,
-					airwaybill_number,
-					order_date,
-					total_item,
-					total_price
-				FROM orders 
-				WHERE customer_id = $1 AND deleted_at is NULL
-				ORDER By order_date DESC`
-
-			mockExpectQuery := m.ExpectQuery(query).WithArgs(tt.args.cusomterID)
+			query := "SELECT" + orderColumns + " FROM orders " + tt.wantWhere
+
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(tt.wantArgs...)
 			if tt.fields.err != nil {
-				mockExpectQuery.WillReturnError(err)
+				mockExpectQuery.WillReturnError(tt.fields.err)
 			} else {
-				row := sqlmock.NewRows([]string{"id", "customer_id", "customer_reference", "receiver_name", "address", "city", "district", "postal_code", "shipper", "airwaybill_number", "order_date", "total_item", "total_price"})
-				for _, order := range tt.fields.data {
-					row.AddRow(
-						order.ID,
-						order.CustomerID,
-						order.CustomerReference,
-						order.ReceiverName,
-						order.Address,
-						order.City,
-						order.District,
-						order.PostalCode,
-						order.Shipper,
-						order.AirwaybillNumber,
-						order.OrderDate,
-						order.TotalItem,
-						order.TotalPrice,
-					)
-				}
+				mockExpectQuery.WillReturnRows(orderRows(tt.fields.data))
+			}
 
-				mockExpectQuery.WillReturnRows(row)
+			got, err := testDB.GetOrderHistoryByCustomerID(tt.args.ctx, tt.args.customerID, tt.args.q)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_orderRepository_GetOrderByID(t *testing.T) {
+	order := model.Order{
+		ID:                1,
+		CustomerID:        1,
+		CustomerReference: "Ab12Cd34",
+		Shipper:           "shipper",
+		AirwaybillNumber:  "123",
+		Status:            model.OrderPlaced,
+		OrderDate:         time.Now().UTC().Truncate(time.Minute),
+	}
+
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		err     error
+		want    *model.Order
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			rows:    orderRows([]model.Order{order}),
+			want:    &order,
+			wantErr: false,
+		},
+		{
+			name:    "not found",
+			err:     sql.ErrNoRows,
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "GetContext error",
+			err:     errors.New("error"),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			testDB := postgresql.NewOrderRepository(sqlx.NewDb(db, "sqlmock"))
+
+			query := "SELECT" + orderColumns + " FROM orders WHERE id = $1 AND deleted_at IS NULL"
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(1)
+			if tt.err != nil {
+				mockExpectQuery.WillReturnError(tt.err)
+			} else {
+				mockExpectQuery.WillReturnRows(tt.rows)
+			}
+
+			got, err := testDB.GetOrderByID(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_orderRepository_GetOrderByReference(t *testing.T) {
+	order := model.Order{
+		ID:                1,
+		CustomerID:        1,
+		CustomerReference: "Ab12Cd34",
+		Shipper:           "shipper",
+		AirwaybillNumber:  "123",
+		Status:            model.OrderPlaced,
+		OrderDate:         time.Now().UTC().Truncate(time.Minute),
+	}
+
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		err     error
+		want    *model.Order
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			rows:    orderRows([]model.Order{order}),
+			want:    &order,
+			wantErr: false,
+		},
+		{
+			name:    "not found",
+			err:     sql.ErrNoRows,
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "GetContext error",
+			err:     errors.New("error"),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			testDB := postgresql.NewOrderRepository(sqlx.NewDb(db, "sqlmock"))
+
+			query := "SELECT" + orderColumns + " FROM orders WHERE customer_id = $1 AND customer_reference = $2 AND deleted_at IS NULL"
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(1, "Ab12Cd34")
+			if tt.err != nil {
+				mockExpectQuery.WillReturnError(tt.err)
+			} else {
+				mockExpectQuery.WillReturnRows(tt.rows)
 			}
 
-			got, err := testDB.GetOrderHistoryByCustomerID(tt.args.ctx, tt.args.cusomterID)
+			got, err := testDB.GetOrderByReference(context.Background(), 1, "Ab12Cd34")
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.want, got)
 		})
@@ -256,7 +493,7 @@ func Test_orderRepository_CreateOrder(t *testing.T) {
 		fields  fields
 		args    args
 		want    uint
-		wantErr bool
+		wantErr error
 	}{
 		{
 			name: "best case",
@@ -269,7 +506,20 @@ func Test_orderRepository_CreateOrder(t *testing.T) {
 				req: order,
 			},
 			want:    order.ID,
-			wantErr: false,
+			wantErr: nil,
+		},
+		{
+			name: "duplicate reference",
+			fields: fields{
+				data: 0,
+				err:  nil,
+			},
+			args: args{
+				ctx: context.Background(),
+				req: order,
+			},
+			want:    0,
+			wantErr: repository.ErrDuplicateReference,
 		},
 		{
 			name: "QueryRowxContext error",
@@ -282,7 +532,7 @@ func Test_orderRepository_CreateOrder(t *testing.T) {
 				req: order,
 			},
 			want:    0,
-			wantErr: true,
+			wantErr: errors.New("error"),
 		},
 	}
 	for _, tt := range tests {
@@ -320,19 +570,25 @@ func Test_orderRepository_CreateOrder(t *testing.T) {
 				$8,
 				$9,
 				$10
-			) RETURNING id;`
+			)
+			ON CONFLICT DO NOTHING
+			RETURNING id;`
 
 			tx, _ := sqlxDB.BeginTxx(tt.args.ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
 
 			mockExpectQuery := m.ExpectQuery(query).WithArgs(tt.args.req.CustomerID, tt.args.req.CustomerReference, tt.args.req.ReceiverName, tt.args.req.Address, tt.args.req.City, tt.args.req.District, tt.args.req.PostalCode, tt.args.req.OrderDate, tt.args.req.Shipper, tt.args.req.AirwaybillNumber)
-			if tt.fields.err != nil {
-				mockExpectQuery.WillReturnError(err)
-			} else {
-				mockExpectQuery.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(tt.args.req.ID))
+			switch {
+			case tt.fields.err != nil:
+				mockExpectQuery.WillReturnError(tt.fields.err)
+			case tt.fields.data == 0:
+				//the insert conflicted with an existing reference
+				mockExpectQuery.WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			default:
+				mockExpectQuery.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(tt.fields.data))
 			}
 
 			got, err := testDB.CreateOrder(tt.args.ctx, tx, tt.args.req)
-			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantErr, err)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -473,3 +729,55 @@ func Test_orderRepository_UpdateOrderByOrderID(t *testing.T) {
 		})
 	}
 }
+
+func Test_orderRepository_AnonymizeOrdersByCustomerID(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name:    "best case",
+			wantErr: false,
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewOrderRepository(sqlxDB)
+
+			query := `
+		UPDATE orders SET
+			receiver_name = $1,
+			address = $1,
+			city = $1,
+			district = $1,
+			postal_code = $1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE customer_id = $2`
+
+			m.ExpectBegin()
+			mockExpectExec := m.ExpectExec(query).WithArgs(model.AnonymizedValue, uint(1))
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, 3))
+			}
+
+			tx, _ := sqlxDB.Beginx()
+			err = testDB.AnonymizeOrdersByCustomerID(context.Background(), tx, 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository"
+	"ebookstore/utils/transactioner"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type outboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *sqlx.DB) repository.IOutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+const outboxColumns = `
+		id,
+		COALESCE(customer_id, 0) AS customer_id,
+		payload,
+		status,
+		attempts,
+		COALESCE(last_error, '') AS last_error,
+		next_attempt_at,
+		created_at,
+		updated_at,
+		sent_at,
+		delivered_channels`
+
+// Enqueue writes the message in the caller's transaction, so it only exists
+// once the change it is about is committed.
+func (r *outboxRepository) Enqueue(ctx context.Context, tx transactioner.TxxProvider, msg model.OutboxMessage) (uint, error) {
+	var id uint
+	query := "INSERT INTO notification_outbox (customer_id, payload, status, next_attempt_at) VALUES (NULLIF($1, 0), $2, $3, $4) RETURNING id"
+
+	err := tx.QueryRowContext(ctx, query, msg.CustomerID, msg.Payload, model.OutboxPending, msg.NextAttemptAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ClaimDue leases up to limit pending messages that are due by pushing their
+// next attempt to leaseUntil. Concurrent dispatchers skip the locked rows, and
+// a message whose dispatcher died becomes due again when the lease runs out.
+func (r *outboxRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	query := `
+		UPDATE notification_outbox SET next_attempt_at = $1, updated_at = $2
+		WHERE id IN (
+			SELECT id FROM notification_outbox
+			WHERE status = 'pending' AND next_attempt_at <= $2
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING` + outboxColumns
+
+	err := r.db.SelectContext(ctx, &messages, query, leaseUntil, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id uint, sentAt time.Time) error {
+	query := "UPDATE notification_outbox SET status = 'sent', attempts = attempts + 1, last_error = NULL, sent_at = $1, updated_at = $1 WHERE id = $2"
+
+	_, err := r.db.ExecContext(ctx, query, sentAt, id)
+	return err
+}
+
+// MarkFailed stores the outcome of a failed attempt, either a later retry or
+// the dead state, and the channels that were delivered to so far.
+func (r *outboxRepository) MarkFailed(ctx context.Context, msg model.OutboxMessage) error {
+	query := "UPDATE notification_outbox SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, updated_at = $5, delivered_channels = $6 WHERE id = $7"
+
+	_, err := r.db.ExecContext(ctx, query, msg.Status, msg.Attempts, msg.LastError, msg.NextAttemptAt, msg.UpdatedAt, msg.DeliveredChannels, msg.ID)
+	return err
+}
+
+func (r *outboxRepository) GetMessages(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	query := "SELECT" + outboxColumns + " FROM notification_outbox WHERE status = $1 ORDER BY id DESC LIMIT $2"
+
+	err := r.db.SelectContext(ctx, &messages, query, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *outboxRepository) GetMessageByID(ctx context.Context, id uint) (*model.OutboxMessage, error) {
+	var msg model.OutboxMessage
+	query := "SELECT" + outboxColumns + " FROM notification_outbox WHERE id = $1"
+
+	err := r.db.GetContext(ctx, &msg, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+// Replay puts a dead message back in the queue with a fresh set of attempts.
+// It reports false when there is no dead message with that id.
+func (r *outboxRepository) Replay(ctx context.Context, id uint, now time.Time) (bool, error) {
+	query := "UPDATE notification_outbox SET status = 'pending', attempts = 0, last_error = NULL, next_attempt_at = $1, updated_at = $1 WHERE id = $2 AND status = 'dead'"
+
+	res, err := r.db.ExecContext(ctx, query, now, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteByCustomerID removes every message to the customer, sent or not.
+func (r *outboxRepository) DeleteByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM notification_outbox WHERE customer_id = $1", customerID)
+	return err
+}
+
+// DeleteFinished removes the sent and dead messages last touched before the
+// given time, pending ones are always kept.
+func (r *outboxRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	query := "DELETE FROM notification_outbox WHERE status IN ('sent', 'dead') AND updated_at < $1"
+
+	res, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
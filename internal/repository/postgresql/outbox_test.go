@@ -0,0 +1,246 @@
+package postgresql_test
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository/postgresql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var outboxRows = []string{"id", "customer_id", "payload", "status", "attempts", "last_error", "next_attempt_at", "created_at", "updated_at", "sent_at", "delivered_channels"}
+
+func Test_outboxRepository_Enqueue(t *testing.T) {
+	now := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	msg := model.OutboxMessage{CustomerID: 3, Payload: []byte(`{"to":"email@mail.com"}`), NextAttemptAt: now}
+
+	tests := []struct {
+		name    string
+		err     error
+		want    uint
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			want: 7,
+		},
+		{
+			name:    "QueryRowContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewOutboxRepository(sqlxDB)
+
+			query := "INSERT INTO notification_outbox (customer_id, payload, status, next_attempt_at) VALUES (NULLIF($1, 0), $2, $3, $4) RETURNING id"
+
+			m.ExpectBegin()
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(msg.CustomerID, msg.Payload, model.OutboxPending, now)
+			if tt.err != nil {
+				mockExpectQuery.WillReturnError(tt.err)
+			} else {
+				mockExpectQuery.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			}
+
+			tx, _ := sqlxDB.Beginx()
+			got, err := testDB.Enqueue(context.Background(), tx, msg)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_outboxRepository_ClaimDue(t *testing.T) {
+	now := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	leaseUntil := now.Add(5 * time.Minute)
+	msg := model.OutboxMessage{
+		ID:            1,
+		CustomerID:    3,
+		Payload:       []byte(`{"to":"email@mail.com"}`),
+		Status:        model.OutboxPending,
+		Attempts:      2,
+		LastError:     "smtp down",
+		NextAttemptAt: leaseUntil,
+		CreatedAt:     now.Add(-time.Hour),
+		UpdatedAt:     pq.NullTime{Time: now, Valid: true},
+
+		DeliveredChannels: pq.StringArray{"webhook"},
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		want    []model.OutboxMessage
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			want: []model.OutboxMessage{msg},
+		},
+		{
+			name:    "SelectContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewOutboxRepository(sqlxDB)
+
+			query := `
+		UPDATE notification_outbox SET next_attempt_at = $1, updated_at = $2
+		WHERE id IN (
+			SELECT id FROM notification_outbox
+			WHERE status = 'pending' AND next_attempt_at <= $2
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING
+		id,
+		COALESCE(customer_id, 0) AS customer_id,
+		payload,
+		status,
+		attempts,
+		COALESCE(last_error, '') AS last_error,
+		next_attempt_at,
+		created_at,
+		updated_at,
+		sent_at,
+		delivered_channels`
+
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(leaseUntil, now, 20)
+			if tt.err != nil {
+				mockExpectQuery.WillReturnError(tt.err)
+			} else {
+				mockExpectQuery.WillReturnRows(sqlmock.NewRows(outboxRows).
+					AddRow(msg.ID, msg.CustomerID, msg.Payload, msg.Status, msg.Attempts, msg.LastError, msg.NextAttemptAt, msg.CreatedAt, msg.UpdatedAt.Time, nil, "{webhook}"))
+			}
+
+			got, err := testDB.ClaimDue(context.Background(), now, leaseUntil, 20)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_outboxRepository_Replay(t *testing.T) {
+	now := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		affected int64
+		err      error
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "best case",
+			affected: 1,
+			want:     true,
+		},
+		{
+			name:     "not dead",
+			affected: 0,
+			want:     false,
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewOutboxRepository(sqlxDB)
+
+			query := "UPDATE notification_outbox SET status = 'pending', attempts = 0, last_error = NULL, next_attempt_at = $1, updated_at = $1 WHERE id = $2 AND status = 'dead'"
+
+			mockExpectExec := m.ExpectExec(query).WithArgs(now, uint(1))
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			}
+
+			got, err := testDB.Replay(context.Background(), 1, now)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_outboxRepository_DeleteFinished(t *testing.T) {
+	before := time.Date(2026, 9, 19, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		err     error
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			want: 4,
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewOutboxRepository(sqlxDB)
+
+			query := "DELETE FROM notification_outbox WHERE status IN ('sent', 'dead') AND updated_at < $1"
+
+			mockExpectExec := m.ExpectExec(query).WithArgs(before)
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, tt.want))
+			}
+
+			got, err := testDB.DeleteFinished(context.Background(), before)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package postgresql
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository"
+	"ebookstore/utils/transactioner"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type preferenceRepository struct {
+	db *sqlx.DB
+}
+
+func NewPreferenceRepository(db *sqlx.DB) repository.IPreferenceRepository {
+	return &preferenceRepository{db: db}
+}
+
+// GetPreferences returns the preferences a customer changed, the others keep
+// their default.
+func (r *preferenceRepository) GetPreferences(ctx context.Context, customerID uint) ([]model.NotificationPreference, error) {
+	preferences := []model.NotificationPreference{}
+	query := `
+		SELECT customer_id, category, channel, enabled, updated_at
+		FROM customer_notification_preferences
+		WHERE customer_id = $1
+		ORDER BY category, channel`
+
+	err := r.db.SelectContext(ctx, &preferences, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+func (r *preferenceRepository) SetPreference(ctx context.Context, tx transactioner.TxxProvider, preference model.NotificationPreference) error {
+	query := `
+		INSERT INTO customer_notification_preferences (customer_id, category, channel, enabled, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (customer_id, category, channel) DO UPDATE
+		SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at`
+
+	_, err := tx.ExecContext(ctx, query, preference.CustomerID, preference.Category, preference.Channel, preference.Enabled, preference.UpdatedAt)
+	return err
+}
+
+func (r *preferenceRepository) DeletePreferences(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM customer_notification_preferences WHERE customer_id = $1", customerID)
+	return err
+}
@@ -0,0 +1,170 @@
+package postgresql_test
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository/postgresql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_preferenceRepository_GetPreferences(t *testing.T) {
+	updatedAt := time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC)
+	preference := model.NotificationPreference{
+		CustomerID: 1,
+		Category:   "marketing",
+		Channel:    "smtp",
+		Enabled:    true,
+		UpdatedAt:  updatedAt,
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		want    []model.NotificationPreference
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			want: []model.NotificationPreference{preference},
+		},
+		{
+			name:    "SelectContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewPreferenceRepository(sqlxDB)
+
+			query := `
+				SELECT customer_id, category, channel, enabled, updated_at
+				FROM customer_notification_preferences
+				WHERE customer_id = $1
+				ORDER BY category, channel`
+
+			mockExpectQuery := m.ExpectQuery(query).WithArgs(uint(1))
+			if tt.err != nil {
+				mockExpectQuery.WillReturnError(tt.err)
+			} else {
+				mockExpectQuery.WillReturnRows(sqlmock.NewRows([]string{"customer_id", "category", "channel", "enabled", "updated_at"}).
+					AddRow(preference.CustomerID, preference.Category, preference.Channel, preference.Enabled, preference.UpdatedAt))
+			}
+
+			got, err := testDB.GetPreferences(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_preferenceRepository_SetPreference(t *testing.T) {
+	preference := model.NotificationPreference{
+		CustomerID: 1,
+		Category:   "transactional",
+		Channel:    "webhook",
+		Enabled:    false,
+		UpdatedAt:  time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name: "best case",
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewPreferenceRepository(sqlxDB)
+
+			query := `
+				INSERT INTO customer_notification_preferences (customer_id, category, channel, enabled, updated_at)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (customer_id, category, channel) DO UPDATE
+				SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at`
+
+			m.ExpectBegin()
+			mockExpectExec := m.ExpectExec(query).WithArgs(preference.CustomerID, preference.Category, preference.Channel, preference.Enabled, preference.UpdatedAt)
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			tx, _ := sqlxDB.Beginx()
+			err = testDB.SetPreference(context.Background(), tx, preference)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_preferenceRepository_DeletePreferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name: "best case",
+		},
+		{
+			name:    "ExecContext error",
+			err:     errors.New("some error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			testDB := postgresql.NewPreferenceRepository(sqlxDB)
+
+			query := "DELETE FROM customer_notification_preferences WHERE customer_id = $1"
+
+			m.ExpectBegin()
+			mockExpectExec := m.ExpectExec(query).WithArgs(uint(1))
+			if tt.err != nil {
+				mockExpectExec.WillReturnError(tt.err)
+			} else {
+				mockExpectExec.WillReturnResult(sqlmock.NewResult(0, 4))
+			}
+
+			tx, _ := sqlxDB.Beginx()
+			err = testDB.DeletePreferences(context.Background(), tx, 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
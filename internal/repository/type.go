@@ -2,46 +2,144 @@ package repository
 
 import (
 	"context"
+	"database/sql/driver"
 	"ebookstore/internal/model"
 	"ebookstore/utils/transactioner"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type IBookRepository interface {
 	GetBooks(ctx context.Context) ([]model.Book, error)
 	GetBookByID(ctx context.Context, id uint) (model.Book, error)
+	GetBooksByIDs(ctx context.Context, ids []uint) ([]model.Book, error)
+	SearchBooks(ctx context.Context, query string) ([]model.Book, error)
+	CreateBookIfNotExists(ctx context.Context, tx transactioner.TxxProvider, book model.Book) (bool, error)
+	ReindexSearch(ctx context.Context) (int64, error)
 
 	GetCategoryByID(ctx context.Context, id uint) (model.Category, error)
+	GetCategoryByName(ctx context.Context, name string) (*model.Category, error)
+	CreateCategory(ctx context.Context, tx transactioner.TxxProvider, name string) (uint, error)
 }
 
 type ICustomerRepository interface {
-	Register(ctx context.Context, customer *model.Customer) (uint, error)
+	Register(ctx context.Context, tx transactioner.TxxProvider, customer *model.Customer) (uint, error)
 	GetCustomerByEmail(ctx context.Context, email string) (*model.Customer, error)
+	GetCustomerByID(ctx context.Context, id uint) (*model.Customer, error)
+	GetCustomerByEmailVerificationToken(ctx context.Context, tokenHash string) (*model.Customer, error)
+	UpdateCustomer(ctx context.Context, tx transactioner.TxxProvider, customer *model.Customer) error
+	SetRole(ctx context.Context, id uint, role string) error
+
+	GetCustomerByIdentity(ctx context.Context, issuer, subject string) (*model.Customer, error)
+	LinkIdentity(ctx context.Context, tx transactioner.TxxProvider, identity model.CustomerIdentity) error
+	GetIdentitiesByCustomerID(ctx context.Context, customerID uint) ([]model.CustomerIdentity, error)
+
+	AnonymizeCustomer(ctx context.Context, tx transactioner.TxxProvider, id uint) error
+}
+
+type IMFARepository interface {
+	GetMFAByCustomerID(ctx context.Context, customerID uint) (*model.CustomerMFA, error)
+	UpsertMFASecret(ctx context.Context, customerID uint, secret string) error
+	EnableMFA(ctx context.Context, tx transactioner.TxxProvider, customerID uint, step int64) error
+	DisableMFA(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error
+	ConsumeTOTPStep(ctx context.Context, customerID uint, step int64) (bool, error)
+	SetMFAChallenge(ctx context.Context, customerID uint, challengeID string) error
+	ConsumeMFAChallenge(ctx context.Context, customerID uint, challengeID string) (bool, error)
+	RecordMFAFailure(ctx context.Context, customerID uint, maxAttempts int, lockUntil time.Time) error
+	ResetMFAFailures(ctx context.Context, customerID uint) error
+
+	ReplaceRecoveryCodes(ctx context.Context, tx transactioner.TxxProvider, customerID uint, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, customerID uint, codeHash string) (bool, error)
+}
+
+type IAddressRepository interface {
+	CreateAddress(ctx context.Context, tx transactioner.TxxProvider, address model.Address) (uint, error)
+	GetAddressesByCustomerID(ctx context.Context, customerID uint) ([]model.Address, error)
+	GetAddressByID(ctx context.Context, customerID, id uint) (*model.Address, error)
+	UpdateAddress(ctx context.Context, tx transactioner.TxxProvider, address model.Address) error
+	DeleteAddress(ctx context.Context, customerID, id uint) error
+	ClearDefaultAddress(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error
+	DeleteAddressesByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error
 }
 
+// ErrDuplicateReference is returned by CreateOrder when the customer reference
+// or the airwaybill number is taken. Nothing is inserted and the transaction
+// can go on with other references.
+var ErrDuplicateReference = errors.New("order reference already exists")
+
 type IOrderRepository interface {
 	CreateOrder(ctx context.Context, tx transactioner.TxxProvider, order model.Order) (uint, error)
-	GetOrderHistoryByCustomerID(ctx context.Context, customerID uint) ([]model.Order, error)
+	GetOrderByID(ctx context.Context, id uint) (*model.Order, error)
+	GetOrderHistoryByCustomerID(ctx context.Context, customerID uint, q model.OrderHistoryQuery) ([]model.Order, error)
+	GetOrderByReference(ctx context.Context, customerID uint, reference string) (*model.Order, error)
 	UpdateOrderByOrderID(ctx context.Context, tx transactioner.TxxProvider, order model.Order) error
+	AnonymizeOrdersByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error
 
 	CreateItem(ctx context.Context, tx transactioner.TxxProvider, item model.Item) error
 	GetItemsByOrderID(ctx context.Context, orderID uint) ([]model.Item, error)
+	GetItemsByOrderIDs(ctx context.Context, orderIDs []uint) ([]model.Item, error)
+}
+
+type IIdempotencyRepository interface {
+	GetIdempotencyKey(ctx context.Context, customerID uint, key string, now time.Time) (*model.IdempotencyKey, error)
+	CreateIdempotencyKey(ctx context.Context, tx transactioner.TxxProvider, key model.IdempotencyKey) (bool, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+	DeleteIdempotencyKeysByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error
+}
+
+type IPreferenceRepository interface {
+	GetPreferences(ctx context.Context, customerID uint) ([]model.NotificationPreference, error)
+	SetPreference(ctx context.Context, tx transactioner.TxxProvider, preference model.NotificationPreference) error
+	DeletePreferences(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error
+}
+
+type IOutboxRepository interface {
+	Enqueue(ctx context.Context, tx transactioner.TxxProvider, msg model.OutboxMessage) (uint, error)
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.OutboxMessage, error)
+	MarkSent(ctx context.Context, id uint, sentAt time.Time) error
+	MarkFailed(ctx context.Context, msg model.OutboxMessage) error
+
+	GetMessages(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error)
+	GetMessageByID(ctx context.Context, id uint) (*model.OutboxMessage, error)
+	Replay(ctx context.Context, id uint, now time.Time) (bool, error)
+
+	DeleteByCustomerID(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error
+	DeleteFinished(ctx context.Context, before time.Time) (int64, error)
 }
 
+// ConnectPostgres opens the pool through otelsql, so every query run with a
+// traced context gets its own span.
 func ConnectPostgres(ctx context.Context, dbURL string) (*sqlx.DB, error) {
-	db, err := sqlx.Open("postgres", dbURL)
+	sqlDB, err := otelsql.Open("postgres", dbURL,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			OmitConnectorConnect: true,
+			//probes and background jobs are not worth a trace of their own
+			SpanFilter: func(ctx context.Context, method otelsql.Method, query string, args []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Postgres: %w", err)
 	}
+	db := sqlx.NewDb(sqlDB, "postgres")
 
 	if err = db.PingContext(ctx); err != nil {
 		return nil, fmt.Errorf("failed to ping Postgres: %w", err)
 	}
 
-	log.Println("Connected to Postgres!")
+	slog.InfoContext(ctx, "connected to postgres")
 	return db, nil
 }
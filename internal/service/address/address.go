@@ -0,0 +1,200 @@
+package address
+
+import (
+	"context"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/repository"
+	"ebookstore/internal/service"
+	"ebookstore/utils/transactioner"
+	"fmt"
+)
+
+type addressService struct {
+	addressRepository   repository.IAddressRepository
+	TransactionProvider transactioner.ITransactionProvider
+}
+
+func NewAddressService(addressRepository repository.IAddressRepository, tx transactioner.ITransactionProvider) service.IAddressService {
+	return &addressService{
+		addressRepository:   addressRepository,
+		TransactionProvider: tx,
+	}
+}
+
+func (s *addressService) GetAddresses(ctx context.Context) ([]response.AddressData, error) {
+	customerID := ctx.Value("id").(uint)
+
+	addresses, err := s.addressRepository.GetAddressesByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	resp := []response.AddressData{}
+	for _, address := range addresses {
+		resp = append(resp, addressData(address))
+	}
+
+	return resp, nil
+}
+
+// CreateAddress stores a new address. The first address a customer saves
+// becomes the default one.
+func (s *addressService) CreateAddress(ctx context.Context, req request.Address) (response.AddressData, error) {
+	customerID := ctx.Value("id").(uint)
+
+	addresses, err := s.addressRepository.GetAddressesByCustomerID(ctx, customerID)
+	if err != nil {
+		return response.AddressData{}, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	address := model.Address{
+		CustomerID:   customerID,
+		Label:        req.Label,
+		ReceiverName: req.ReceiverName,
+		Address:      req.Address,
+		City:         req.City,
+		District:     req.District,
+		PostalCode:   req.PostalCode,
+		IsDefault:    req.IsDefault || len(addresses) == 0,
+	}
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return response.AddressData{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if address.IsDefault {
+		err = s.addressRepository.ClearDefaultAddress(ctx, tx, customerID)
+		if err != nil {
+			return response.AddressData{}, fmt.Errorf("failed to clear default address: %w", err)
+		}
+	}
+
+	address.ID, err = s.addressRepository.CreateAddress(ctx, tx, address)
+	if err != nil {
+		return response.AddressData{}, fmt.Errorf("failed to create address: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return response.AddressData{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return addressData(address), nil
+}
+
+func (s *addressService) UpdateAddress(ctx context.Context, id uint, req request.Address) (response.AddressData, error) {
+	customerID := ctx.Value("id").(uint)
+
+	address, err := s.getAddress(ctx, customerID, id)
+	if err != nil {
+		return response.AddressData{}, err
+	}
+
+	address.Label = req.Label
+	address.ReceiverName = req.ReceiverName
+	address.Address = req.Address
+	address.City = req.City
+	address.District = req.District
+	address.PostalCode = req.PostalCode
+	address.IsDefault = address.IsDefault || req.IsDefault
+
+	err = s.saveAddress(ctx, address, req.IsDefault)
+	if err != nil {
+		return response.AddressData{}, err
+	}
+
+	return addressData(*address), nil
+}
+
+func (s *addressService) SetDefaultAddress(ctx context.Context, id uint) (response.AddressData, error) {
+	customerID := ctx.Value("id").(uint)
+
+	address, err := s.getAddress(ctx, customerID, id)
+	if err != nil {
+		return response.AddressData{}, err
+	}
+
+	address.IsDefault = true
+	err = s.saveAddress(ctx, address, true)
+	if err != nil {
+		return response.AddressData{}, err
+	}
+
+	return addressData(*address), nil
+}
+
+func (s *addressService) DeleteAddress(ctx context.Context, id uint) error {
+	customerID := ctx.Value("id").(uint)
+
+	_, err := s.getAddress(ctx, customerID, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.addressRepository.DeleteAddress(ctx, customerID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+
+	return nil
+}
+
+// saveAddress updates the address in a transaction, clearing the previous
+// default first when this address takes over as default.
+func (s *addressService) saveAddress(ctx context.Context, address *model.Address, makeDefault bool) error {
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if makeDefault {
+		err = s.addressRepository.ClearDefaultAddress(ctx, tx, address.CustomerID)
+		if err != nil {
+			return fmt.Errorf("failed to clear default address: %w", err)
+		}
+	}
+
+	err = s.addressRepository.UpdateAddress(ctx, tx, *address)
+	if err != nil {
+		return fmt.Errorf("failed to update address: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *addressService) getAddress(ctx context.Context, customerID, id uint) (*model.Address, error) {
+	address, err := s.addressRepository.GetAddressByID(ctx, customerID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address: %w", err)
+	}
+
+	if address == nil {
+		return nil, apperror.NotFound("address not found")
+	}
+
+	return address, nil
+}
+
+func addressData(address model.Address) response.AddressData {
+	return response.AddressData{
+		ID:           address.ID,
+		Label:        address.Label,
+		ReceiverName: address.ReceiverName,
+		Address:      address.Address,
+		City:         address.City,
+		District:     address.District,
+		PostalCode:   address.PostalCode,
+		IsDefault:    address.IsDefault,
+	}
+}
@@ -0,0 +1,174 @@
+package address_test
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/repository/mocks"
+	"ebookstore/internal/service/address"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_addressService_CreateAddress(t *testing.T) {
+	id := uint(1)
+	ctx := context.WithValue(context.Background(), "id", id)
+
+	req := request.Address{
+		Label:      "home",
+		Address:    "address",
+		City:       "city",
+		District:   "district",
+		PostalCode: "12345",
+	}
+
+	txProvider := func() *mocks.ITransactionProvider {
+		m := mocks.ITransactionProvider{}
+		txProvide := mocks.TxxProvider{}
+		txProvide.On("Commit").Return(nil)
+		txProvide.On("Rollback").Return(nil)
+		m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+		return &m
+	}
+
+	type fields struct {
+		addressRepository   *mocks.IAddressRepository
+		TransactionProvider *mocks.ITransactionProvider
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		wantDefault bool
+		wantErr     bool
+	}{
+		{
+			name: "first address becomes default",
+			fields: fields{
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressesByCustomerID", mock.Anything, id).Return([]model.Address{}, nil)
+					m.On("ClearDefaultAddress", mock.Anything, mock.Anything, id).Return(nil)
+					m.On("CreateAddress", mock.Anything, mock.Anything, mock.MatchedBy(func(a model.Address) bool {
+						return a.IsDefault && a.CustomerID == id
+					})).Return(uint(2), nil)
+					return &m
+				}(),
+				TransactionProvider: txProvider(),
+			},
+			wantDefault: true,
+			wantErr:     false,
+		},
+		{
+			name: "additional address",
+			fields: fields{
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressesByCustomerID", mock.Anything, id).Return([]model.Address{{ID: 1, IsDefault: true}}, nil)
+					m.On("CreateAddress", mock.Anything, mock.Anything, mock.MatchedBy(func(a model.Address) bool {
+						return !a.IsDefault
+					})).Return(uint(2), nil)
+					return &m
+				}(),
+				TransactionProvider: txProvider(),
+			},
+			wantDefault: false,
+			wantErr:     false,
+		},
+		{
+			name: "GetAddressesByCustomerID error",
+			fields: fields{
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressesByCustomerID", mock.Anything, id).Return(nil, errors.New("error"))
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "CreateAddress error",
+			fields: fields{
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressesByCustomerID", mock.Anything, id).Return([]model.Address{{ID: 1, IsDefault: true}}, nil)
+					m.On("CreateAddress", mock.Anything, mock.Anything, mock.Anything).Return(uint(0), errors.New("error"))
+					return &m
+				}(),
+				TransactionProvider: txProvider(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := address.NewAddressService(tt.fields.addressRepository, tt.fields.TransactionProvider)
+			got, err := s.CreateAddress(ctx, req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, uint(2), got.ID)
+				assert.Equal(t, tt.wantDefault, got.IsDefault)
+			}
+		})
+	}
+}
+
+func Test_addressService_SetDefaultAddress(t *testing.T) {
+	id := uint(1)
+	ctx := context.WithValue(context.Background(), "id", id)
+
+	type fields struct {
+		addressRepository   *mocks.IAddressRepository
+		TransactionProvider *mocks.ITransactionProvider
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressByID", mock.Anything, id, uint(2)).Return(&model.Address{ID: 2, CustomerID: id}, nil)
+					m.On("ClearDefaultAddress", mock.Anything, mock.Anything, id).Return(nil)
+					m.On("UpdateAddress", mock.Anything, mock.Anything, mock.MatchedBy(func(a model.Address) bool {
+						return a.ID == 2 && a.IsDefault
+					})).Return(nil)
+					return &m
+				}(),
+				TransactionProvider: func() *mocks.ITransactionProvider {
+					m := mocks.ITransactionProvider{}
+					txProvide := mocks.TxxProvider{}
+					txProvide.On("Commit").Return(nil)
+					txProvide.On("Rollback").Return(nil)
+					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+					return &m
+				}(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "address not found",
+			fields: fields{
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressByID", mock.Anything, id, uint(2)).Return(nil, nil)
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := address.NewAddressService(tt.fields.addressRepository, tt.fields.TransactionProvider)
+			got, err := s.SetDefaultAddress(ctx, 2)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, !tt.wantErr, got.IsDefault)
+		})
+	}
+}
@@ -2,42 +2,170 @@ package book
 
 import (
 	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
 	"ebookstore/internal/model/response"
 	"ebookstore/internal/repository"
 	"ebookstore/internal/service"
+	"ebookstore/internal/validator"
+	"ebookstore/utils/cache"
+	"ebookstore/utils/tracing"
+	"ebookstore/utils/transactioner"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
-var catMap = make(map[uint]string)
-
 type bookService struct {
-	bookRepository repository.IBookRepository
+	bookRepository      repository.IBookRepository
+	TransactionProvider transactioner.ITransactionProvider
+	// bookCache is shared with the order service, which reads the prices from it
+	bookCache     cache.ICache[model.Book]
+	categoryCache cache.ICache[model.Category]
 }
 
-func NewBookService(bookRepository repository.IBookRepository) service.IBookService {
+func NewBookService(bookRepository repository.IBookRepository, tx transactioner.ITransactionProvider, bookCache cache.ICache[model.Book], categoryCache cache.ICache[model.Category]) service.IBookService {
 	return &bookService{
-		bookRepository: bookRepository,
+		bookRepository:      bookRepository,
+		TransactionProvider: tx,
+		bookCache:           bookCache,
+		categoryCache:       categoryCache,
 	}
 }
 
 func (s *bookService) GetBooks(ctx context.Context) ([]response.Book, error) {
+	ctx, span := tracing.Start(ctx, "bookService.GetBooks")
+	defer span.End()
+
 	resp := []response.Book{}
 	books, err := s.bookRepository.GetBooks(ctx)
 	if err != nil {
-		return resp, fmt.Errorf("failed to get books: %s", err.Error())
+		return resp, fmt.Errorf("failed to get books: %w", err)
+	}
+
+	return s.toResponse(ctx, books)
+}
+
+func (s *bookService) SearchBooks(ctx context.Context, query string) ([]response.Book, error) {
+	ctx, span := tracing.Start(ctx, "bookService.SearchBooks")
+	defer span.End()
+
+	resp := []response.Book{}
+	books, err := s.bookRepository.SearchBooks(ctx, query)
+	if err != nil {
+		return resp, fmt.Errorf("failed to search books: %w", err)
+	}
+
+	return s.toResponse(ctx, books)
+}
+
+// ImportBooks adds the books in one transaction. Books that already exist with
+// the same title and author are skipped, and missing categories are created.
+func (s *bookService) ImportBooks(ctx context.Context, books []request.ImportBook) (response.ImportBooksData, error) {
+	ctx, span := tracing.Start(ctx, "bookService.ImportBooks")
+	defer span.End()
+
+	var data response.ImportBooksData
+	for i, book := range books {
+		err := validator.Struct(book)
+		if err != nil {
+			return data, fmt.Errorf("invalid book #%d: %w", i+1, err)
+		}
+	}
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return data, fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer tx.Rollback()
 
+	//categories created in this transaction are not visible outside of it yet
+	categoryIDs := make(map[string]uint)
 	for _, book := range books {
-		var categoryName string
-		//check inMemory caching
-		categoryName, ok := catMap[book.CategoryID]
+		name := strings.TrimSpace(book.Category)
+		key := strings.ToLower(name)
+		categoryID, ok := categoryIDs[key]
 		if !ok {
-			category, err := s.bookRepository.GetCategoryByID(ctx, book.CategoryID)
+			category, err := s.bookRepository.GetCategoryByName(ctx, name)
 			if err != nil {
-				return resp, fmt.Errorf("failed to get category: %s", err.Error())
+				return data, fmt.Errorf("failed to get category: %w", err)
 			}
 
-			categoryName = category.Name
+			if category != nil {
+				categoryID = category.ID
+			} else {
+				categoryID, err = s.bookRepository.CreateCategory(ctx, tx, name)
+				if err != nil {
+					return data, fmt.Errorf("failed to create category: %w", err)
+				}
+			}
+			categoryIDs[key] = categoryID
+		}
+
+		created, err := s.bookRepository.CreateBookIfNotExists(ctx, tx, model.Book{
+			Title:      strings.TrimSpace(book.Title),
+			Author:     strings.TrimSpace(book.Author),
+			Price:      book.Price,
+			CategoryID: categoryID,
+		})
+		if err != nil {
+			return data, fmt.Errorf("failed to create book %q: %w", book.Title, err)
+		}
+
+		if created {
+			data.Imported++
+		} else {
+			data.Skipped++
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return response.ImportBooksData{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return data, nil
+}
+
+func (s *bookService) ReindexSearch(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "bookService.ReindexSearch")
+	defer span.End()
+
+	count, err := s.bookRepository.ReindexSearch(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reindex books: %w", err)
+	}
+
+	return count, nil
+}
+
+// InvalidateCache drops the cached books, so a change made in the database is
+// seen before the cache expires. Without IDs every book and category is dropped.
+func (s *bookService) InvalidateCache(ctx context.Context, bookIDs ...uint) {
+	ctx, span := tracing.Start(ctx, "bookService.InvalidateCache")
+	defer span.End()
+
+	if len(bookIDs) == 0 {
+		s.bookCache.Purge(ctx)
+		s.categoryCache.Purge(ctx)
+		return
+	}
+
+	keys := make([]string, 0, len(bookIDs))
+	for _, id := range bookIDs {
+		keys = append(keys, strconv.FormatUint(uint64(id), 10))
+	}
+	s.bookCache.Delete(ctx, keys...)
+}
+
+func (s *bookService) toResponse(ctx context.Context, books []model.Book) ([]response.Book, error) {
+	resp := []response.Book{}
+	for _, book := range books {
+		category, err := s.categoryCache.GetOrLoad(ctx, strconv.FormatUint(uint64(book.CategoryID), 10), func(ctx context.Context) (model.Category, error) {
+			return s.bookRepository.GetCategoryByID(ctx, book.CategoryID)
+		})
+		if err != nil {
+			return resp, fmt.Errorf("failed to get category: %w", err)
 		}
 
 		resp = append(resp, response.Book{
@@ -45,7 +173,7 @@ func (s *bookService) GetBooks(ctx context.Context) ([]response.Book, error) {
 			Title:    book.Title,
 			Author:   book.Author,
 			Price:    book.Price,
-			Category: categoryName,
+			Category: category.Name,
 		})
 	}
 
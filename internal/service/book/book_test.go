@@ -3,19 +3,25 @@ package book_test
 import (
 	"context"
 	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
 	"ebookstore/internal/model/response"
 	"ebookstore/internal/repository/mocks"
+	"ebookstore/internal/service"
 	"ebookstore/internal/service/book"
+	"ebookstore/utils/cache"
+	"ebookstore/utils/transactioner"
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func Test_bookService_GetBooks(t *testing.T) {
 	type fields struct {
-		bookRepository mocks.IBookRepository
+		bookRepository *mocks.IBookRepository
 	}
 
 	type args struct {
@@ -32,7 +38,7 @@ func Test_bookService_GetBooks(t *testing.T) {
 		{
 			name: "best case",
 			fields: fields{
-				bookRepository: func() mocks.IBookRepository {
+				bookRepository: func() *mocks.IBookRepository {
 					m := mocks.IBookRepository{}
 					m.On("GetBooks", mock.Anything).Return([]model.Book{
 						{
@@ -42,13 +48,21 @@ func Test_bookService_GetBooks(t *testing.T) {
 							Price:      100,
 							CategoryID: uint(1),
 						},
+						{
+							ID:         uint(2),
+							Title:      "title 2",
+							Author:     "author",
+							Price:      50,
+							CategoryID: uint(1),
+						},
 					}, nil)
+					//the second book reads the category from the cache
 					m.On("GetCategoryByID", mock.Anything, uint(1)).Return(model.Category{
 						ID:   1,
 						Name: "category",
-					}, nil)
+					}, nil).Once()
 
-					return m
+					return &m
 				}(),
 			},
 			want: []response.Book{
@@ -59,25 +73,38 @@ func Test_bookService_GetBooks(t *testing.T) {
 					Price:    100,
 					Category: "category",
 				},
+				{
+					ID:       2,
+					Title:    "title 2",
+					Author:   "author",
+					Price:    50,
+					Category: "category",
+				},
+			},
+			args: args{
+				ctx: context.Background(),
 			},
 			wantErr: false,
 		},
 		{
 			name: "failed GetBooks",
 			fields: fields{
-				bookRepository: func() mocks.IBookRepository {
+				bookRepository: func() *mocks.IBookRepository {
 					m := mocks.IBookRepository{}
 					m.On("GetBooks", mock.Anything).Return([]model.Book{}, errors.New("failed"))
-					return m
+					return &m
 				}(),
 			},
+			args: args{
+				ctx: context.Background(),
+			},
 			want:    []response.Book{},
 			wantErr: true,
 		},
 		{
 			name: "failed GetCategoryByID",
 			fields: fields{
-				bookRepository: func() mocks.IBookRepository {
+				bookRepository: func() *mocks.IBookRepository {
 					m := mocks.IBookRepository{}
 					m.On("GetBooks", mock.Anything).Return([]model.Book{
 						{
@@ -89,16 +116,19 @@ func Test_bookService_GetBooks(t *testing.T) {
 						},
 					}, nil)
 					m.On("GetCategoryByID", mock.Anything, uint(1)).Return(model.Category{}, errors.New("failed"))
-					return m
+					return &m
 				}(),
 			},
+			args: args{
+				ctx: context.Background(),
+			},
 			want:    []response.Book{},
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := book.NewBookService(&tt.fields.bookRepository)
+			s := newBookService(tt.fields.bookRepository, &mocks.ITransactionProvider{})
 			got, err := s.GetBooks(tt.args.ctx)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("bookService.GetBooks() error = %v, wantErr %v", err, tt.wantErr)
@@ -110,3 +140,162 @@ func Test_bookService_GetBooks(t *testing.T) {
 		})
 	}
 }
+
+func Test_bookService_SearchBooks(t *testing.T) {
+	tests := []struct {
+		name           string
+		bookRepository *mocks.IBookRepository
+		want           []response.Book
+		wantErr        bool
+	}{
+		{
+			name: "best case",
+			bookRepository: func() *mocks.IBookRepository {
+				m := mocks.IBookRepository{}
+				m.On("SearchBooks", mock.Anything, "tolkien").Return([]model.Book{
+					{ID: 2, Title: "The Hobbit", Author: "J.R.R. Tolkien", Price: 20, CategoryID: 2},
+				}, nil)
+				m.On("GetCategoryByID", mock.Anything, uint(2)).Return(model.Category{ID: 2, Name: "fantasy"}, nil)
+				return &m
+			}(),
+			want: []response.Book{
+				{ID: 2, Title: "The Hobbit", Author: "J.R.R. Tolkien", Price: 20, Category: "fantasy"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failed SearchBooks",
+			bookRepository: func() *mocks.IBookRepository {
+				m := mocks.IBookRepository{}
+				m.On("SearchBooks", mock.Anything, "tolkien").Return(nil, errors.New("failed"))
+				return &m
+			}(),
+			want:    []response.Book{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newBookService(tt.bookRepository, &mocks.ITransactionProvider{})
+			got, err := s.SearchBooks(context.Background(), "tolkien")
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bookService_ImportBooks(t *testing.T) {
+	books := []request.ImportBook{
+		{Title: "Dune", Author: "Frank Herbert", Price: 15, Category: "Science Fiction"},
+		{Title: "Hyperion", Author: "Dan Simmons", Price: 12, Category: "science fiction"},
+		{Title: "Emma", Author: "Jane Austen", Price: 9, Category: "Romance"},
+	}
+
+	txProvider := func(commit bool) *mocks.ITransactionProvider {
+		m := mocks.ITransactionProvider{}
+		tx := mocks.TxxProvider{}
+		if commit {
+			tx.On("Commit").Return(nil)
+		}
+		tx.On("Rollback").Return(nil)
+		m.On("NewTransaction", mock.Anything).Return(&tx, nil)
+		return &m
+	}
+
+	tests := []struct {
+		name                string
+		books               []request.ImportBook
+		bookRepository      *mocks.IBookRepository
+		TransactionProvider *mocks.ITransactionProvider
+		want                response.ImportBooksData
+		wantErr             bool
+	}{
+		{
+			name:  "best case",
+			books: books,
+			bookRepository: func() *mocks.IBookRepository {
+				m := mocks.IBookRepository{}
+				m.On("GetCategoryByName", mock.Anything, "Science Fiction").Return(&model.Category{ID: 3, Name: "Science Fiction"}, nil).Once()
+				m.On("GetCategoryByName", mock.Anything, "Romance").Return(nil, nil).Once()
+				m.On("CreateCategory", mock.Anything, mock.Anything, "Romance").Return(uint(4), nil).Once()
+				m.On("CreateBookIfNotExists", mock.Anything, mock.Anything, model.Book{Title: "Dune", Author: "Frank Herbert", Price: 15, CategoryID: 3}).Return(true, nil)
+				m.On("CreateBookIfNotExists", mock.Anything, mock.Anything, model.Book{Title: "Hyperion", Author: "Dan Simmons", Price: 12, CategoryID: 3}).Return(false, nil)
+				m.On("CreateBookIfNotExists", mock.Anything, mock.Anything, model.Book{Title: "Emma", Author: "Jane Austen", Price: 9, CategoryID: 4}).Return(true, nil)
+				return &m
+			}(),
+			TransactionProvider: txProvider(true),
+			want:                response.ImportBooksData{Imported: 2, Skipped: 1},
+			wantErr:             false,
+		},
+		{
+			name:                "invalid book",
+			books:               []request.ImportBook{{Title: "Dune", Author: "Frank Herbert", Price: 0, Category: "Science Fiction"}},
+			bookRepository:      &mocks.IBookRepository{},
+			TransactionProvider: &mocks.ITransactionProvider{},
+			want:                response.ImportBooksData{},
+			wantErr:             true,
+		},
+		{
+			name:  "failed CreateBookIfNotExists",
+			books: books[:1],
+			bookRepository: func() *mocks.IBookRepository {
+				m := mocks.IBookRepository{}
+				m.On("GetCategoryByName", mock.Anything, "Science Fiction").Return(&model.Category{ID: 3, Name: "Science Fiction"}, nil)
+				m.On("CreateBookIfNotExists", mock.Anything, mock.Anything, mock.Anything).Return(false, errors.New("failed"))
+				return &m
+			}(),
+			TransactionProvider: txProvider(false),
+			want:                response.ImportBooksData{},
+			wantErr:             true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newBookService(tt.bookRepository, tt.TransactionProvider)
+			got, err := s.ImportBooks(context.Background(), tt.books)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+			tt.bookRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_bookService_ReindexSearch(t *testing.T) {
+	m := mocks.IBookRepository{}
+	m.On("ReindexSearch", mock.Anything).Return(int64(12), nil)
+
+	s := newBookService(&m, &mocks.ITransactionProvider{})
+	got, err := s.ReindexSearch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), got)
+}
+
+func Test_bookService_InvalidateCache(t *testing.T) {
+	ctx := context.Background()
+	bookCache := cache.NewMemory[model.Book]("books", cache.Options{Size: 10, Shards: 1, TTL: time.Minute})
+	categoryCache := cache.NewMemory[model.Category]("categories", cache.Options{Size: 10, Shards: 1, TTL: time.Minute})
+	s := book.NewBookService(&mocks.IBookRepository{}, &mocks.ITransactionProvider{}, bookCache, categoryCache)
+
+	bookCache.Set(ctx, "1", model.Book{ID: 1})
+	bookCache.Set(ctx, "2", model.Book{ID: 2})
+	categoryCache.Set(ctx, "1", model.Category{ID: 1})
+
+	s.InvalidateCache(ctx, 1)
+	_, ok := bookCache.Get(ctx, "1")
+	assert.False(t, ok)
+	_, ok = bookCache.Get(ctx, "2")
+	assert.True(t, ok)
+	_, ok = categoryCache.Get(ctx, "1")
+	assert.True(t, ok)
+
+	s.InvalidateCache(ctx)
+	_, ok = bookCache.Get(ctx, "2")
+	assert.False(t, ok)
+	_, ok = categoryCache.Get(ctx, "1")
+	assert.False(t, ok)
+}
+
+func newBookService(bookRepository *mocks.IBookRepository, tx transactioner.ITransactionProvider) service.IBookService {
+	options := cache.Options{Size: 10, Shards: 1, TTL: time.Minute}
+	return book.NewBookService(bookRepository, tx, cache.NewMemory[model.Book]("books", options), cache.NewMemory[model.Category]("categories", options))
+}
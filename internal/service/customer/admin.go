@@ -0,0 +1,93 @@
+package customer
+
+import (
+	"context"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	authentication "ebookstore/utils/middleware"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CreateAdmin gives the admin role to the customer with the email, creating
+// the account first when it does not exist yet.
+func (s *customerService) CreateAdmin(ctx context.Context, req request.Register) (uint, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" {
+		return 0, apperror.Validation("email cannot be empty")
+	}
+
+	customerDB, err := s.customerRepository.GetCustomerByEmail(ctx, email)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get email existing: %w", err)
+	}
+
+	customerID := uint(0)
+	if customerDB != nil && customerDB.Email == email {
+		customerID = customerDB.ID
+	} else {
+		if req.Username == "" || req.Password == "" {
+			return 0, apperror.Validation("username and password are required for a new account")
+		}
+
+		hashedPass, err := authentication.GenerateHashedPassword(req.Password)
+		if err != nil {
+			return 0, errors.New("failed to generate hashed password")
+		}
+
+		tx, err := s.TransactionProvider.NewTransaction(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to start transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		customerID, err = s.customerRepository.Register(ctx, tx, &model.Customer{
+			Email:    email,
+			Password: hashedPass,
+			Username: req.Username,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to register customer: %w", err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+	}
+
+	err = s.customerRepository.SetRole(ctx, customerID, model.RoleAdmin)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set role: %w", err)
+	}
+
+	return customerID, nil
+}
+
+// GetRole returns the current role of the customer, for the routes only an
+// admin can use.
+func (s *customerService) GetRole(ctx context.Context, id uint) (string, error) {
+	customerDB, err := s.customerRepository.GetCustomerByID(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	if customerDB == nil {
+		return "", apperror.Unauthorized("customer not found")
+	}
+
+	return customerDB.Role, nil
+}
+
+// IsActive reports whether the customer still exists, deleted accounts are
+// anonymized and their tokens must stop working right away.
+func (s *customerService) IsActive(ctx context.Context, id uint) (bool, error) {
+	customerDB, err := s.customerRepository.GetCustomerByID(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	return customerDB != nil, nil
+}
@@ -0,0 +1,169 @@
+package customer_test
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/repository/mocks"
+	"ebookstore/internal/service/customer"
+	mocksService "ebookstore/internal/service/mocks"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_customerService_CreateAdmin(t *testing.T) {
+	tests := []struct {
+		name               string
+		customerRepository *mocks.ICustomerRepository
+		req                request.Register
+		want               uint
+		wantErr            bool
+	}{
+		{
+			name: "promote existing customer",
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByEmail", mock.Anything, "admin@mail.com").Return(&model.Customer{ID: 3, Email: "admin@mail.com"}, nil)
+				m.On("SetRole", mock.Anything, uint(3), model.RoleAdmin).Return(nil)
+				return &m
+			}(),
+			req:     request.Register{Email: "Admin@mail.com"},
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "create new customer",
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByEmail", mock.Anything, "admin@mail.com").Return(&model.Customer{}, nil)
+				m.On("Register", mock.Anything, mock.Anything, mock.MatchedBy(func(c *model.Customer) bool {
+					return c.Email == "admin@mail.com" && c.Username == "admin" && c.Password != "Passw0rd."
+				})).Return(uint(4), nil)
+				m.On("SetRole", mock.Anything, uint(4), model.RoleAdmin).Return(nil)
+				return &m
+			}(),
+			req:     request.Register{Email: "admin@mail.com", Username: "admin", Password: "Passw0rd."},
+			want:    4,
+			wantErr: false,
+		},
+		{
+			name: "new customer without password",
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByEmail", mock.Anything, "admin@mail.com").Return(&model.Customer{}, nil)
+				return &m
+			}(),
+			req:     request.Register{Email: "admin@mail.com", Username: "admin"},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "failed SetRole",
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByEmail", mock.Anything, "admin@mail.com").Return(&model.Customer{ID: 3, Email: "admin@mail.com"}, nil)
+				m.On("SetRole", mock.Anything, uint(3), model.RoleAdmin).Return(errors.New("error"))
+				return &m
+			}(),
+			req:     request.Register{Email: "admin@mail.com"},
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(tt.customerRepository, &mocks.IMFARepository{}, txProvider(), &mocksService.IOutboxService{}, nil, testConfig)
+			got, err := s.CreateAdmin(context.Background(), tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_customerService_GetRole(t *testing.T) {
+	tests := []struct {
+		name               string
+		customerRepository *mocks.ICustomerRepository
+		want               string
+		wantErr            bool
+	}{
+		{
+			name: "best case",
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByID", mock.Anything, uint(3)).Return(&model.Customer{ID: 3, Role: model.RoleAdmin}, nil)
+				return &m
+			}(),
+			want:    model.RoleAdmin,
+			wantErr: false,
+		},
+		{
+			name: "deleted customer",
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByID", mock.Anything, uint(3)).Return(nil, nil)
+				return &m
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(tt.customerRepository, &mocks.IMFARepository{}, &mocks.ITransactionProvider{}, &mocksService.IOutboxService{}, nil, testConfig)
+			got, err := s.GetRole(context.Background(), 3)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_customerService_IsActive(t *testing.T) {
+	tests := []struct {
+		name               string
+		customerRepository *mocks.ICustomerRepository
+		want               bool
+		wantErr            bool
+	}{
+		{
+			name: "best case",
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByID", mock.Anything, uint(3)).Return(&model.Customer{ID: 3}, nil)
+				return &m
+			}(),
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "deleted customer",
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByID", mock.Anything, uint(3)).Return(nil, nil)
+				return &m
+			}(),
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "GetCustomerByID error",
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByID", mock.Anything, uint(3)).Return(nil, errors.New("error"))
+				return &m
+			}(),
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(tt.customerRepository, &mocks.IMFARepository{}, &mocks.ITransactionProvider{}, &mocksService.IOutboxService{}, nil, testConfig)
+			got, err := s.IsActive(context.Background(), 3)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,13 +2,19 @@ package customer
 
 import (
 	"context"
+	"ebookstore/internal/apperror"
 	"ebookstore/internal/model"
 	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
 	"ebookstore/internal/repository"
 	"ebookstore/internal/service"
 	"ebookstore/utils/config"
+	"ebookstore/utils/emailtemplate"
+	"ebookstore/utils/metrics"
 	authentication "ebookstore/utils/middleware"
 	"ebookstore/utils/notification"
+	"ebookstore/utils/oidc"
+	"ebookstore/utils/transactioner"
 	"errors"
 	"fmt"
 	"strings"
@@ -16,13 +22,22 @@ import (
 
 type customerService struct {
 	customerRepository  repository.ICustomerRepository
-	notificationService notification.INotificationService
+	mfaRepository       repository.IMFARepository
+	TransactionProvider transactioner.ITransactionProvider
+	outboxService       service.IOutboxService
+	oidcProvider        oidc.IProvider
+	cfg                 *config.Config
 }
 
-func NewCustomerService(customerRepository repository.ICustomerRepository, notificationService notification.INotificationService) service.ICustomerService {
+// NewCustomerService accepts a nil oidcProvider when social login is disabled.
+func NewCustomerService(customerRepository repository.ICustomerRepository, mfaRepository repository.IMFARepository, tx transactioner.ITransactionProvider, outboxService service.IOutboxService, oidcProvider oidc.IProvider, cfg *config.Config) service.ICustomerService {
 	return &customerService{
 		customerRepository:  customerRepository,
-		notificationService: notificationService,
+		mfaRepository:       mfaRepository,
+		TransactionProvider: tx,
+		outboxService:       outboxService,
+		oidcProvider:        oidcProvider,
+		cfg:                 cfg,
 	}
 }
 
@@ -30,11 +45,11 @@ func (s *customerService) Register(ctx context.Context, customer request.Registe
 	email := strings.ToLower(customer.Email)
 	customerDB, err := s.customerRepository.GetCustomerByEmail(ctx, email)
 	if err != nil {
-		return "", fmt.Errorf("failed to get email existing: %s", err.Error())
+		return "", fmt.Errorf("failed to get email existing: %w", err)
 	}
 
 	if customerDB != nil && customerDB.Email == email {
-		return "", errors.New("email already exists")
+		return "", apperror.Conflict("email already exists")
 	}
 
 	hashedPass, err := authentication.GenerateHashedPassword(customer.Password)
@@ -42,56 +57,111 @@ func (s *customerService) Register(ctx context.Context, customer request.Registe
 		return "", errors.New("failed to generate hashed password")
 	}
 
-	customerID, err := s.customerRepository.Register(ctx, &model.Customer{
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	customerID, err := s.customerRepository.Register(ctx, tx, &model.Customer{
 		Email:    email,
 		Password: hashedPass,
 		Username: customer.Username,
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("failed to register customer: %s", err.Error())
-	}
-
-	token, err := authentication.GenerateToken(customer.Username, email, customerID)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %s", err.Error())
+		return "", fmt.Errorf("failed to register customer: %w", err)
 	}
 
-	//send notification
-	if config.CONFIG_EMAIL_SERVICE {
-		body := fmt.Sprintf(model.CustomerBodyEmailTemplate, customer.Email)
+	//queue the notification with the account, it is sent once committed
+	if s.cfg.Email.Enabled {
+		content, err := emailtemplate.Render(emailtemplate.CustomerRegistered, s.cfg.Notification.Locale, emailtemplate.Account{Name: customer.Username})
+		if err != nil {
+			return "", err
+		}
 
-		emailPayload := notification.EmailPayload{
+		msg := notification.Message{
+			Event:   "customer.registered",
 			To:      customer.Email,
-			Subject: "Account Registration Notification",
-			Body:    body,
+			Subject: content.Subject,
+			Body:    content.HTML,
+			Text:    content.Text,
+			Data:    map[string]any{"customer_id": customerID, "username": customer.Username},
+
+			CustomerID: customerID,
+			Category:   notification.CategoryTransactional,
+		}
+
+		err = s.outboxService.Enqueue(ctx, tx, msg)
+		if err != nil {
+			return "", err
 		}
+	}
 
-		go s.notificationService.SendNotification(emailPayload)
+	err = tx.Commit()
+	if err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	metrics.Registrations.WithLabelValues("password").Inc()
+
+	token, err := authentication.GenerateToken(customer.Username, email, customerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	return token, nil
 }
 
-func (s *customerService) Login(ctx context.Context, customer request.Login) (string, error) {
-	customerDB, err := s.customerRepository.GetCustomerByEmail(ctx, customer.Email)
+func (s *customerService) Login(ctx context.Context, customer request.Login) (response.LoginData, error) {
+	//emails are stored lowercase at registration
+	customerDB, err := s.customerRepository.GetCustomerByEmail(ctx, strings.ToLower(customer.Email))
 	if err != nil {
-		return "", fmt.Errorf("failed to login: %s", err.Error())
+		return response.LoginData{}, fmt.Errorf("failed to login: %w", err)
 	}
 
 	if customerDB.Email == "" {
-		return "", errors.New("invalid email")
+		return response.LoginData{}, apperror.Unauthorized("invalid email")
 	}
 
 	ok := authentication.CompareHashedPassword(customerDB.Password, customer.Password)
 	if !ok {
-		return "", errors.New("invalid password")
+		return response.LoginData{}, apperror.Unauthorized("invalid password")
 	}
 
-	token, err := authentication.GenerateToken(customerDB.Username, customerDB.Email, customerDB.ID)
+	return s.issueLoginToken(ctx, customerDB)
+}
+
+// issueLoginToken returns the access token, or an MFA challenge token when the
+// customer has a verified second factor.
+func (s *customerService) issueLoginToken(ctx context.Context, customer *model.Customer) (response.LoginData, error) {
+	mfa, err := s.mfaRepository.GetMFAByCustomerID(ctx, customer.ID)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %s", err.Error())
+		return response.LoginData{}, fmt.Errorf("failed to get mfa status: %w", err)
 	}
 
-	return token, nil
+	if mfa != nil && mfa.Enabled {
+		challenge, err := randomToken()
+		if err != nil {
+			return response.LoginData{}, fmt.Errorf("failed to generate mfa challenge: %w", err)
+		}
+
+		err = s.mfaRepository.SetMFAChallenge(ctx, customer.ID, challenge)
+		if err != nil {
+			return response.LoginData{}, fmt.Errorf("failed to save mfa challenge: %w", err)
+		}
+
+		mfaToken, err := authentication.GenerateMFAChallengeToken(customer.Username, customer.Email, customer.ID, challenge)
+		if err != nil {
+			return response.LoginData{}, fmt.Errorf("failed to generate mfa token: %w", err)
+		}
+
+		return response.LoginData{MFAToken: mfaToken}, nil
+	}
+
+	token, err := authentication.GenerateToken(customer.Username, customer.Email, customer.ID)
+	if err != nil {
+		return response.LoginData{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return response.LoginData{Token: token}, nil
 }
@@ -2,18 +2,39 @@ package customer_test
 
 import (
 	"context"
+	"ebookstore/internal/apperror"
 	"ebookstore/internal/model"
 	"ebookstore/internal/model/request"
 	"ebookstore/internal/repository/mocks"
 	"ebookstore/internal/service/customer"
 	mocksService "ebookstore/internal/service/mocks"
+	"ebookstore/utils/config"
+	"ebookstore/utils/notification"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+// testConfig keeps email enabled so the notification paths are exercised.
+var testConfig = func() *config.Config {
+	cfg := config.Default()
+	cfg.Email.Enabled = true
+	return &cfg
+}()
+
+// txProvider hands out a transaction that can be committed.
+func txProvider() *mocks.ITransactionProvider {
+	m := mocks.ITransactionProvider{}
+	txProvide := mocks.TxxProvider{}
+	txProvide.On("Commit").Return(nil)
+	txProvide.On("Rollback").Return(nil)
+	m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+	return &m
+}
+
 func Test_customerService_Register(t *testing.T) {
 	customerReq := request.Register{
 		Email:    "email",
@@ -21,33 +42,39 @@ func Test_customerService_Register(t *testing.T) {
 		Username: "username",
 	}
 	type fields struct {
-		customerRepository  mocks.ICustomerRepository
-		notificationService mocksService.INotificationService
+		customerRepository  *mocks.ICustomerRepository
+		mfaRepository       *mocks.IMFARepository
+		TransactionProvider *mocks.ITransactionProvider
+		outboxService       *mocksService.IOutboxService
 	}
 	type args struct {
 		ctx      context.Context
 		customer request.Register
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
+		name     string
+		fields   fields
+		args     args
+		wantErr  bool
+		wantKind error
 	}{
 		{
 			name: "best case",
 			fields: fields{
-				customerRepository: func() mocks.ICustomerRepository {
+				customerRepository: func() *mocks.ICustomerRepository {
 					m := mocks.ICustomerRepository{}
 					m.On("GetCustomerByEmail", mock.Anything, customerReq.Email).Return(nil, nil)
 					customerReq.Password = mock.Anything
-					m.On("Register", mock.Anything, mock.Anything).Return(uint(1), nil)
-					return m
+					m.On("Register", mock.Anything, mock.Anything, mock.Anything).Return(uint(1), nil)
+					return &m
 				}(),
-				notificationService: func() mocksService.INotificationService {
-					m := mocksService.INotificationService{}
-					m.On("SendEmail", mock.Anything, mock.Anything).Return(nil)
-					return m
+				TransactionProvider: txProvider(),
+				outboxService: func() *mocksService.IOutboxService {
+					m := mocksService.IOutboxService{}
+					m.On("Enqueue", mock.Anything, mock.Anything, mock.MatchedBy(func(p notification.Message) bool {
+						return p.To == customerReq.Email && p.Subject == "Account Registration Notification" && strings.HasPrefix(p.Text, "Dear "+customerReq.Username+",")
+					})).Return(nil)
+					return &m
 				}(),
 			},
 			args: args{
@@ -59,10 +86,10 @@ func Test_customerService_Register(t *testing.T) {
 		{
 			name: "GetCustomerByEmail error",
 			fields: fields{
-				customerRepository: func() mocks.ICustomerRepository {
+				customerRepository: func() *mocks.ICustomerRepository {
 					m := mocks.ICustomerRepository{}
 					m.On("GetCustomerByEmail", mock.Anything, customerReq.Email).Return(nil, errors.New("error"))
-					return m
+					return &m
 				}(),
 			},
 			args: args{
@@ -74,28 +101,58 @@ func Test_customerService_Register(t *testing.T) {
 		{
 			name: "email already exist",
 			fields: fields{
-				customerRepository: func() mocks.ICustomerRepository {
+				customerRepository: func() *mocks.ICustomerRepository {
 					m := mocks.ICustomerRepository{}
 					m.On("GetCustomerByEmail", mock.Anything, customerReq.Email).Return(&model.Customer{
 						Email: customerReq.Email,
 					}, nil)
-					return m
+					return &m
 				}(),
 			},
 			args: args{
 				ctx:      context.Background(),
 				customer: customerReq,
 			},
-			wantErr: true,
+			wantErr:  true,
+			wantKind: apperror.ErrConflict,
 		},
 		{
 			name: "Register error",
 			fields: fields{
-				customerRepository: func() mocks.ICustomerRepository {
+				customerRepository: func() *mocks.ICustomerRepository {
 					m := mocks.ICustomerRepository{}
 					m.On("GetCustomerByEmail", mock.Anything, customerReq.Email).Return(nil, nil)
-					m.On("Register", mock.Anything, mock.Anything).Return(uint(0), errors.New("error"))
-					return m
+					m.On("Register", mock.Anything, mock.Anything, mock.Anything).Return(uint(0), errors.New("error"))
+					return &m
+				}(),
+				TransactionProvider: txProvider(),
+			},
+			args: args{
+				ctx:      context.Background(),
+				customer: customerReq,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Enqueue error rolls the account back",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByEmail", mock.Anything, customerReq.Email).Return(nil, nil)
+					m.On("Register", mock.Anything, mock.Anything, mock.Anything).Return(uint(1), nil)
+					return &m
+				}(),
+				TransactionProvider: func() *mocks.ITransactionProvider {
+					m := mocks.ITransactionProvider{}
+					txProvide := mocks.TxxProvider{}
+					txProvide.On("Rollback").Return(nil)
+					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+					return &m
+				}(),
+				outboxService: func() *mocksService.IOutboxService {
+					m := mocksService.IOutboxService{}
+					m.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("error"))
+					return &m
 				}(),
 			},
 			args: args{
@@ -107,17 +164,22 @@ func Test_customerService_Register(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := customer.NewCustomerService(&tt.fields.customerRepository, &tt.fields.notificationService)
+			s := customer.NewCustomerService(tt.fields.customerRepository, tt.fields.mfaRepository, tt.fields.TransactionProvider, tt.fields.outboxService, nil, testConfig)
 			_, err := s.Register(tt.args.ctx, tt.args.customer)
 			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantKind != nil {
+				assert.ErrorIs(t, err, tt.wantKind)
+			}
 		})
 	}
 }
 
 func Test_customerService_Login(t *testing.T) {
 	type fields struct {
-		customerRepository  mocks.ICustomerRepository
-		notificationService mocksService.INotificationService
+		customerRepository  *mocks.ICustomerRepository
+		mfaRepository       *mocks.IMFARepository
+		TransactionProvider *mocks.ITransactionProvider
+		outboxService       *mocksService.IOutboxService
 	}
 	type args struct {
 		ctx      context.Context
@@ -127,23 +189,24 @@ func Test_customerService_Login(t *testing.T) {
 		name    string
 		fields  fields
 		args    args
+		wantMFA bool
 		wantErr bool
 	}{
 		{
 			name: "best case",
 			fields: fields{
-				customerRepository: func() mocks.ICustomerRepository {
+				customerRepository: func() *mocks.ICustomerRepository {
 					m := mocks.ICustomerRepository{}
 					m.On("GetCustomerByEmail", mock.Anything, mock.Anything).Return(&model.Customer{
 						Email:    "email",
 						Password: "$2a$12$KptVrUIFh4qX5.b8fHNjK.n1U749q8q86DtGxUFbEwbSUymQ./zty",
 					}, nil)
-					return m
+					return &m
 				}(),
-				notificationService: func() mocksService.INotificationService {
-					m := mocksService.INotificationService{}
-					m.On("SendEmail", mock.Anything, mock.Anything).Return(nil)
-					return m
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, mock.Anything).Return(nil, nil)
+					return &m
 				}(),
 			},
 			args: args{
@@ -155,13 +218,95 @@ func Test_customerService_Login(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "mixed case email",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByEmail", mock.Anything, "mail@mail.com").Return(&model.Customer{
+						Email:    "mail@mail.com",
+						Password: "$2a$12$KptVrUIFh4qX5.b8fHNjK.n1U749q8q86DtGxUFbEwbSUymQ./zty",
+					}, nil)
+					return &m
+				}(),
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, mock.Anything).Return(nil, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				customer: request.Login{
+					Email:    "Mail@Mail.com",
+					Password: "password",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "mfa enabled",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByEmail", mock.Anything, mock.Anything).Return(&model.Customer{
+						ID:       1,
+						Email:    "email",
+						Password: "$2a$12$KptVrUIFh4qX5.b8fHNjK.n1U749q8q86DtGxUFbEwbSUymQ./zty",
+					}, nil)
+					return &m
+				}(),
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(&model.CustomerMFA{CustomerID: 1, Enabled: true}, nil)
+					m.On("SetMFAChallenge", mock.Anything, uint(1), mock.Anything).Return(nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				customer: request.Login{
+					Email:    "email",
+					Password: "password",
+				},
+			},
+			wantMFA: true,
+			wantErr: false,
+		},
+		{
+			name: "GetMFAByCustomerID error",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByEmail", mock.Anything, mock.Anything).Return(&model.Customer{
+						ID:       1,
+						Email:    "email",
+						Password: "$2a$12$KptVrUIFh4qX5.b8fHNjK.n1U749q8q86DtGxUFbEwbSUymQ./zty",
+					}, nil)
+					return &m
+				}(),
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(nil, errors.New("error"))
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				customer: request.Login{
+					Email:    "email",
+					Password: "password",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "GetCustomerByEmail error",
 			fields: fields{
-				customerRepository: func() mocks.ICustomerRepository {
+				customerRepository: func() *mocks.ICustomerRepository {
 					m := mocks.ICustomerRepository{}
 					m.On("GetCustomerByEmail", mock.Anything, mock.Anything).Return(nil, errors.New("error"))
-					return m
+					return &m
 				}(),
 			},
 			args: args{
@@ -176,13 +321,13 @@ func Test_customerService_Login(t *testing.T) {
 		{
 			name: "invalid password",
 			fields: fields{
-				customerRepository: func() mocks.ICustomerRepository {
+				customerRepository: func() *mocks.ICustomerRepository {
 					m := mocks.ICustomerRepository{}
 					m.On("GetCustomerByEmail", mock.Anything, mock.Anything).Return(&model.Customer{
 						Email:    "email",
 						Password: "password",
 					}, nil)
-					return m
+					return &m
 				}(),
 			},
 			args: args{
@@ -197,10 +342,10 @@ func Test_customerService_Login(t *testing.T) {
 		{
 			name: "invalid email",
 			fields: fields{
-				customerRepository: func() mocks.ICustomerRepository {
+				customerRepository: func() *mocks.ICustomerRepository {
 					m := mocks.ICustomerRepository{}
 					m.On("GetCustomerByEmail", mock.Anything, mock.Anything).Return(&model.Customer{}, nil)
-					return m
+					return &m
 				}(),
 			},
 			args: args{
@@ -215,12 +360,13 @@ func Test_customerService_Login(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := customer.NewCustomerService(&tt.fields.customerRepository, &tt.fields.notificationService)
-			_, err := s.Login(tt.args.ctx, tt.args.customer)
+			s := customer.NewCustomerService(tt.fields.customerRepository, tt.fields.mfaRepository, tt.fields.TransactionProvider, tt.fields.outboxService, nil, testConfig)
+			got, err := s.Login(tt.args.ctx, tt.args.customer)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("customerService.Login() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			assert.Equal(t, tt.wantMFA, got.MFAToken != "")
 		})
 	}
 }
@@ -0,0 +1,278 @@
+package customer
+
+import (
+	"context"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/utils/mfa"
+	authentication "ebookstore/utils/middleware"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (s *customerService) VerifyMFALogin(ctx context.Context, req request.LoginMFA) (string, error) {
+	username, email, customerID, challenge, err := authentication.ParseMFAChallengeToken(req.MFAToken)
+	if err != nil {
+		return "", apperror.Unauthorized(err.Error())
+	}
+
+	customerMFA, err := s.mfaRepository.GetMFAByCustomerID(ctx, customerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get mfa status: %w", err)
+	}
+
+	if customerMFA == nil || !customerMFA.Enabled {
+		return "", apperror.Unauthorized("mfa is not enabled")
+	}
+
+	//the challenge is spent by the first attempt, right or wrong
+	consumed, err := s.mfaRepository.ConsumeMFAChallenge(ctx, customerID, challenge)
+	if err != nil {
+		return "", fmt.Errorf("failed to consume mfa challenge: %w", err)
+	}
+
+	if !consumed {
+		return "", apperror.Unauthorized("mfa token already used")
+	}
+
+	err = s.verifySecondFactor(ctx, customerMFA, req.Code)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := authentication.GenerateToken(username, email, customerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *customerService) EnrollMFA(ctx context.Context) (response.MFAEnrollmentData, error) {
+	customerID := ctx.Value("id").(uint)
+
+	//the account name in the authenticator app is the current email, not the one in the token
+	customerDB, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return response.MFAEnrollmentData{}, err
+	}
+
+	customerMFA, err := s.mfaRepository.GetMFAByCustomerID(ctx, customerID)
+	if err != nil {
+		return response.MFAEnrollmentData{}, fmt.Errorf("failed to get mfa status: %w", err)
+	}
+
+	if customerMFA != nil && customerMFA.Enabled {
+		return response.MFAEnrollmentData{}, apperror.Conflict("mfa already enabled")
+	}
+
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return response.MFAEnrollmentData{}, fmt.Errorf("failed to generate mfa secret: %w", err)
+	}
+
+	err = s.mfaRepository.UpsertMFASecret(ctx, customerID, secret)
+	if err != nil {
+		return response.MFAEnrollmentData{}, fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+
+	return response.MFAEnrollmentData{
+		Secret:          secret,
+		ProvisioningURI: mfa.ProvisioningURI(s.cfg.Auth.MFAIssuer, customerDB.Email, secret),
+	}, nil
+}
+
+// ActivateMFA confirms the enrollment with a code from the authenticator app
+// and returns the recovery codes, they are only ever shown once.
+func (s *customerService) ActivateMFA(ctx context.Context, req request.MFACode) ([]string, error) {
+	customerID := ctx.Value("id").(uint)
+
+	customerMFA, err := s.mfaRepository.GetMFAByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa status: %w", err)
+	}
+
+	if customerMFA == nil {
+		return nil, apperror.Conflict("mfa enrollment not started")
+	}
+
+	if customerMFA.Enabled {
+		return nil, apperror.Conflict("mfa already enabled")
+	}
+
+	step, ok := mfa.Validate(customerMFA.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return nil, apperror.Validation("invalid mfa code", apperror.FieldError{Field: "code", Message: "is invalid"})
+	}
+
+	codes, err := mfa.GenerateRecoveryCodes(mfa.RecoveryCodeCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, mfa.HashRecoveryCode(code))
+	}
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = s.mfaRepository.EnableMFA(ctx, tx, customerID, step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	err = s.mfaRepository.ReplaceRecoveryCodes(ctx, tx, customerID, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (s *customerService) DisableMFA(ctx context.Context, req request.MFACode) error {
+	customerID := ctx.Value("id").(uint)
+
+	customerMFA, err := s.mfaRepository.GetMFAByCustomerID(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to get mfa status: %w", err)
+	}
+
+	if customerMFA == nil || !customerMFA.Enabled {
+		return apperror.Conflict("mfa is not enabled")
+	}
+
+	err = s.verifySecondFactor(ctx, customerMFA, req.Code)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = s.mfaRepository.DisableMFA(ctx, tx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// VerifySecondFactor asks customers with MFA enabled for a code again before a
+// sensitive change. Customers without MFA pass.
+func (s *customerService) VerifySecondFactor(ctx context.Context, customerID uint, code string) error {
+	customerMFA, err := s.mfaRepository.GetMFAByCustomerID(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to get mfa status: %w", err)
+	}
+
+	if customerMFA == nil || !customerMFA.Enabled {
+		return nil
+	}
+
+	return s.verifySecondFactor(ctx, customerMFA, code)
+}
+
+// verifySecondFactor accepts either a TOTP code or one of the unused recovery
+// codes. Wrong codes are counted and too many in a row lock the second factor
+// for a while, so neither kind of code can be guessed.
+func (s *customerService) verifySecondFactor(ctx context.Context, customerMFA *model.CustomerMFA, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return apperror.Validation("mfa code cannot be empty", apperror.FieldError{Field: "code", Message: "cannot be empty"})
+	}
+
+	now := time.Now()
+	if customerMFA.LockedUntil.Valid && customerMFA.LockedUntil.Time.After(now) {
+		return apperror.Unauthorized("too many failed mfa attempts, try again later")
+	}
+
+	err := s.matchSecondFactor(ctx, customerMFA, code, now)
+	if errors.Is(err, apperror.ErrUnauthorized) {
+		recordErr := s.mfaRepository.RecordMFAFailure(ctx, customerMFA.CustomerID, s.cfg.Auth.MFAMaxAttempts, now.Add(s.cfg.Auth.MFALockout))
+		if recordErr != nil {
+			return fmt.Errorf("failed to record mfa failure: %w", recordErr)
+		}
+
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if customerMFA.FailedAttempts > 0 {
+		err = s.mfaRepository.ResetMFAFailures(ctx, customerMFA.CustomerID)
+		if err != nil {
+			return fmt.Errorf("failed to reset mfa failures: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *customerService) matchSecondFactor(ctx context.Context, customerMFA *model.CustomerMFA, code string, now time.Time) error {
+
+	if isTOTPCode(code) {
+		step, ok := mfa.Validate(customerMFA.TOTPSecret, code, now)
+		if !ok {
+			return apperror.Unauthorized("invalid mfa code")
+		}
+
+		consumed, err := s.mfaRepository.ConsumeTOTPStep(ctx, customerMFA.CustomerID, step)
+		if err != nil {
+			return fmt.Errorf("failed to verify mfa code: %w", err)
+		}
+
+		if !consumed {
+			return apperror.Unauthorized("mfa code already used")
+		}
+
+		return nil
+	}
+
+	used, err := s.mfaRepository.UseRecoveryCode(ctx, customerMFA.CustomerID, mfa.HashRecoveryCode(code))
+	if err != nil {
+		return fmt.Errorf("failed to verify recovery code: %w", err)
+	}
+
+	if !used {
+		return apperror.Unauthorized("invalid recovery code")
+	}
+
+	return nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != mfa.Digits {
+		return false
+	}
+
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,525 @@
+package customer_test
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/repository/mocks"
+	"ebookstore/internal/service/customer"
+	mocksService "ebookstore/internal/service/mocks"
+	"ebookstore/utils/mfa"
+	authentication "ebookstore/utils/middleware"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_customerService_VerifyMFALogin(t *testing.T) {
+	secret, _ := mfa.GenerateSecret()
+	code, _ := mfa.GenerateCode(secret, time.Now())
+	mfaToken, _ := authentication.GenerateMFAChallengeToken("username", "email", 1, "challenge")
+	accessToken, _ := authentication.GenerateToken("username", "email", 1)
+	customerMFA := &model.CustomerMFA{CustomerID: 1, TOTPSecret: secret, Enabled: true}
+
+	type fields struct {
+		mfaRepository *mocks.IMFARepository
+	}
+	type args struct {
+		ctx context.Context
+		req request.LoginMFA
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "best case totp",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					m.On("ConsumeMFAChallenge", mock.Anything, uint(1), "challenge").Return(true, nil)
+					m.On("ConsumeTOTPStep", mock.Anything, uint(1), mock.Anything).Return(true, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: request.LoginMFA{MFAToken: mfaToken, Code: code},
+			},
+			wantErr: false,
+		},
+		{
+			name: "best case recovery code",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					m.On("ConsumeMFAChallenge", mock.Anything, uint(1), "challenge").Return(true, nil)
+					m.On("UseRecoveryCode", mock.Anything, uint(1), mfa.HashRecoveryCode("abcde-fghjk")).Return(true, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: request.LoginMFA{MFAToken: mfaToken, Code: "abcde-fghjk"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "success resets failed attempts",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(&model.CustomerMFA{CustomerID: 1, TOTPSecret: secret, Enabled: true, FailedAttempts: 2}, nil)
+					m.On("ConsumeMFAChallenge", mock.Anything, uint(1), "challenge").Return(true, nil)
+					m.On("ConsumeTOTPStep", mock.Anything, uint(1), mock.Anything).Return(true, nil)
+					m.On("ResetMFAFailures", mock.Anything, uint(1)).Return(nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: request.LoginMFA{MFAToken: mfaToken, Code: code},
+			},
+			wantErr: false,
+		},
+		{
+			name: "challenge already used",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					m.On("ConsumeMFAChallenge", mock.Anything, uint(1), "challenge").Return(false, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: request.LoginMFA{MFAToken: mfaToken, Code: code},
+			},
+			wantErr: true,
+		},
+		{
+			name: "locked out",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(&model.CustomerMFA{
+						CustomerID:  1,
+						TOTPSecret:  secret,
+						Enabled:     true,
+						LockedUntil: pq.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+					}, nil)
+					m.On("ConsumeMFAChallenge", mock.Anything, uint(1), "challenge").Return(true, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: request.LoginMFA{MFAToken: mfaToken, Code: code},
+			},
+			wantErr: true,
+		},
+		{
+			name: "access token is not a challenge token",
+			args: args{
+				ctx: context.Background(),
+				req: request.LoginMFA{MFAToken: accessToken, Code: code},
+			},
+			wantErr: true,
+		},
+		{
+			name: "replayed totp code",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					m.On("ConsumeMFAChallenge", mock.Anything, uint(1), "challenge").Return(true, nil)
+					m.On("ConsumeTOTPStep", mock.Anything, uint(1), mock.Anything).Return(false, nil)
+					m.On("RecordMFAFailure", mock.Anything, uint(1), 5, mock.Anything).Return(nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: request.LoginMFA{MFAToken: mfaToken, Code: code},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown recovery code",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					m.On("ConsumeMFAChallenge", mock.Anything, uint(1), "challenge").Return(true, nil)
+					m.On("UseRecoveryCode", mock.Anything, uint(1), mock.Anything).Return(false, nil)
+					m.On("RecordMFAFailure", mock.Anything, uint(1), 5, mock.Anything).Return(nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: request.LoginMFA{MFAToken: mfaToken, Code: "abcde-fghjk"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "GetMFAByCustomerID error",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(nil, errors.New("error"))
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: request.LoginMFA{MFAToken: mfaToken, Code: code},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(&mocks.ICustomerRepository{}, tt.fields.mfaRepository, &mocks.ITransactionProvider{}, &mocksService.IOutboxService{}, nil, testConfig)
+			got, err := s.VerifyMFALogin(tt.args.ctx, tt.args.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantErr, got == "")
+		})
+	}
+}
+
+func Test_customerService_EnrollMFA(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	ctx = context.WithValue(ctx, "email", "mail@mail.com")
+	customerRepository := func() *mocks.ICustomerRepository {
+		m := mocks.ICustomerRepository{}
+		m.On("GetCustomerByID", mock.Anything, uint(1)).Return(&model.Customer{ID: 1, Email: "current@mail.com"}, nil)
+		return &m
+	}
+
+	type fields struct {
+		mfaRepository *mocks.IMFARepository
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(nil, nil)
+					m.On("UpsertMFASecret", mock.Anything, uint(1), mock.Anything).Return(nil)
+					return &m
+				}(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "already enabled",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(&model.CustomerMFA{Enabled: true}, nil)
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "UpsertMFASecret error",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(nil, nil)
+					m.On("UpsertMFASecret", mock.Anything, uint(1), mock.Anything).Return(errors.New("error"))
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(customerRepository(), tt.fields.mfaRepository, &mocks.ITransactionProvider{}, &mocksService.IOutboxService{}, nil, testConfig)
+			got, err := s.EnrollMFA(ctx)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Contains(t, got.ProvisioningURI, "secret="+got.Secret)
+				assert.Contains(t, got.ProvisioningURI, "Ebookstore:current@mail.com?")
+			}
+		})
+	}
+}
+
+func Test_customerService_ActivateMFA(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	secret, _ := mfa.GenerateSecret()
+	code, _ := mfa.GenerateCode(secret, time.Now())
+
+	type fields struct {
+		mfaRepository       *mocks.IMFARepository
+		TransactionProvider *mocks.ITransactionProvider
+	}
+	type args struct {
+		req request.MFACode
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(&model.CustomerMFA{CustomerID: 1, TOTPSecret: secret}, nil)
+					m.On("EnableMFA", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(nil)
+					m.On("ReplaceRecoveryCodes", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(nil)
+					return &m
+				}(),
+				TransactionProvider: func() *mocks.ITransactionProvider {
+					m := mocks.ITransactionProvider{}
+					txProvide := mocks.TxxProvider{}
+					txProvide.On("Commit").Return(nil)
+					txProvide.On("Rollback").Return(nil)
+					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+					return &m
+				}(),
+			},
+			args:    args{req: request.MFACode{Code: code}},
+			wantErr: false,
+		},
+		{
+			name: "enrollment not started",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(nil, nil)
+					return &m
+				}(),
+			},
+			args:    args{req: request.MFACode{Code: code}},
+			wantErr: true,
+		},
+		{
+			name: "invalid code",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(&model.CustomerMFA{CustomerID: 1, TOTPSecret: secret}, nil)
+					return &m
+				}(),
+			},
+			args:    args{req: request.MFACode{Code: "000000x"}},
+			wantErr: true,
+		},
+		{
+			name: "ReplaceRecoveryCodes error",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(&model.CustomerMFA{CustomerID: 1, TOTPSecret: secret}, nil)
+					m.On("EnableMFA", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(nil)
+					m.On("ReplaceRecoveryCodes", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(errors.New("error"))
+					return &m
+				}(),
+				TransactionProvider: func() *mocks.ITransactionProvider {
+					m := mocks.ITransactionProvider{}
+					txProvide := mocks.TxxProvider{}
+					txProvide.On("Rollback").Return(nil)
+					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+					return &m
+				}(),
+			},
+			args:    args{req: request.MFACode{Code: code}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(&mocks.ICustomerRepository{}, tt.fields.mfaRepository, tt.fields.TransactionProvider, &mocksService.IOutboxService{}, nil, testConfig)
+			got, err := s.ActivateMFA(ctx, tt.args.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Len(t, got, mfa.RecoveryCodeCount)
+			}
+		})
+	}
+}
+
+func Test_customerService_DisableMFA(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	secret, _ := mfa.GenerateSecret()
+	code, _ := mfa.GenerateCode(secret, time.Now())
+	customerMFA := &model.CustomerMFA{CustomerID: 1, TOTPSecret: secret, Enabled: true}
+
+	type fields struct {
+		mfaRepository       *mocks.IMFARepository
+		TransactionProvider *mocks.ITransactionProvider
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					m.On("ConsumeTOTPStep", mock.Anything, uint(1), mock.Anything).Return(true, nil)
+					m.On("DisableMFA", mock.Anything, mock.Anything, uint(1)).Return(nil)
+					return &m
+				}(),
+				TransactionProvider: func() *mocks.ITransactionProvider {
+					m := mocks.ITransactionProvider{}
+					txProvide := mocks.TxxProvider{}
+					txProvide.On("Commit").Return(nil)
+					txProvide.On("Rollback").Return(nil)
+					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+					return &m
+				}(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "mfa not enabled",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(nil, nil)
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "DisableMFA error",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					m.On("ConsumeTOTPStep", mock.Anything, uint(1), mock.Anything).Return(true, nil)
+					m.On("DisableMFA", mock.Anything, mock.Anything, uint(1)).Return(errors.New("error"))
+					return &m
+				}(),
+				TransactionProvider: func() *mocks.ITransactionProvider {
+					m := mocks.ITransactionProvider{}
+					txProvide := mocks.TxxProvider{}
+					txProvide.On("Rollback").Return(nil)
+					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(&mocks.ICustomerRepository{}, tt.fields.mfaRepository, tt.fields.TransactionProvider, &mocksService.IOutboxService{}, nil, testConfig)
+			err := s.DisableMFA(ctx, request.MFACode{Code: code})
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_customerService_VerifySecondFactor(t *testing.T) {
+	secret, _ := mfa.GenerateSecret()
+	code, _ := mfa.GenerateCode(secret, time.Now())
+	customerMFA := &model.CustomerMFA{CustomerID: 1, TOTPSecret: secret, Enabled: true}
+
+	type fields struct {
+		mfaRepository *mocks.IMFARepository
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		code    string
+		wantErr bool
+	}{
+		{
+			name: "valid code",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					m.On("ConsumeTOTPStep", mock.Anything, uint(1), mock.Anything).Return(true, nil)
+					return &m
+				}(),
+			},
+			code:    code,
+			wantErr: false,
+		},
+		{
+			name: "mfa not enabled",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(nil, nil)
+					return &m
+				}(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing code",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong recovery code",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(customerMFA, nil)
+					m.On("UseRecoveryCode", mock.Anything, uint(1), mock.Anything).Return(false, nil)
+					m.On("RecordMFAFailure", mock.Anything, uint(1), testConfig.Auth.MFAMaxAttempts, mock.Anything).Return(nil)
+					return &m
+				}(),
+			},
+			code:    "WRONG-CODE",
+			wantErr: true,
+		},
+		{
+			name: "GetMFAByCustomerID error",
+			fields: fields{
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(nil, errors.New("error"))
+					return &m
+				}(),
+			},
+			code:    code,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(&mocks.ICustomerRepository{}, tt.fields.mfaRepository, txProvider(), &mocksService.IOutboxService{}, nil, testConfig)
+			err := s.VerifySecondFactor(context.Background(), 1, tt.code)
+			assert.Equal(t, tt.wantErr, err != nil)
+			tt.fields.mfaRepository.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package customer
+
+import (
+	"context"
+	"crypto/rand"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/utils/metrics"
+	authentication "ebookstore/utils/middleware"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	minUsernameLength = 4
+	maxUsernameLength = 16
+)
+
+func (s *customerService) OIDCLogin(ctx context.Context) (response.OIDCAuthorization, error) {
+	if s.oidcProvider == nil {
+		return response.OIDCAuthorization{}, apperror.NotFound("oidc login is disabled")
+	}
+
+	stateValue, err := randomToken()
+	if err != nil {
+		return response.OIDCAuthorization{}, fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return response.OIDCAuthorization{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	state := authentication.OIDCState{
+		State:        stateValue,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+	}
+
+	authURL, err := s.oidcProvider.AuthCodeURL(ctx, state.State, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		return response.OIDCAuthorization{}, fmt.Errorf("failed to build authorization url: %w", err)
+	}
+
+	stateToken, err := authentication.GenerateOIDCStateToken(state)
+	if err != nil {
+		return response.OIDCAuthorization{}, fmt.Errorf("failed to generate state token: %w", err)
+	}
+
+	return response.OIDCAuthorization{
+		AuthURL:    authURL,
+		StateToken: stateToken,
+	}, nil
+}
+
+// OIDCCallback finishes the authorization code flow. The customer is found by
+// the provider identity first, then linked by verified email, and created on
+// first login otherwise.
+func (s *customerService) OIDCCallback(ctx context.Context, req request.OIDCCallback) (response.LoginData, error) {
+	if s.oidcProvider == nil {
+		return response.LoginData{}, apperror.NotFound("oidc login is disabled")
+	}
+
+	if req.Error != "" {
+		return response.LoginData{}, apperror.Unauthorized("oidc provider returned error: " + req.Error)
+	}
+
+	state, err := authentication.ParseOIDCStateToken(req.StateToken)
+	if err != nil {
+		return response.LoginData{}, apperror.Unauthorized(err.Error())
+	}
+
+	if req.State == "" || req.State != state.State {
+		return response.LoginData{}, apperror.Unauthorized("invalid login state")
+	}
+
+	claims, err := s.oidcProvider.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to exchange oidc code", "error", err)
+		return response.LoginData{}, apperror.Unauthorized("failed to login with oidc")
+	}
+
+	customerDB, err := s.customerRepository.GetCustomerByIdentity(ctx, s.oidcProvider.Issuer(), claims.Subject)
+	if err != nil {
+		return response.LoginData{}, fmt.Errorf("failed to get customer identity: %w", err)
+	}
+
+	if customerDB == nil {
+		customerDB, err = s.linkOIDCCustomer(ctx, claims.Subject, claims.Email, claims.EmailVerified, claims.PreferredUsername)
+		if err != nil {
+			return response.LoginData{}, err
+		}
+	}
+
+	return s.issueLoginToken(ctx, customerDB)
+}
+
+func (s *customerService) linkOIDCCustomer(ctx context.Context, subject, email string, emailVerified bool, preferredUsername string) (*model.Customer, error) {
+	if email == "" || !emailVerified {
+		return nil, apperror.Unauthorized("email is not verified by the oidc provider")
+	}
+
+	email = strings.ToLower(email)
+	customerDB, err := s.customerRepository.GetCustomerByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email existing: %w", err)
+	}
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	registered := false
+	if customerDB == nil || customerDB.Email == "" {
+		username := oidcUsername(preferredUsername, email)
+
+		// customers created from a social login have no password and can
+		// only sign in through the provider
+		customerID, err := s.customerRepository.Register(ctx, tx, &model.Customer{
+			Email:    email,
+			Username: username,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to register customer: %w", err)
+		}
+
+		customerDB = &model.Customer{ID: customerID, Email: email, Username: username}
+		registered = true
+	}
+
+	//link in the same transaction, a customer without the identity could never log in
+	err = s.customerRepository.LinkIdentity(ctx, tx, model.CustomerIdentity{
+		CustomerID: customerDB.ID,
+		Issuer:     s.oidcProvider.Issuer(),
+		Subject:    subject,
+		Email:      email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to link customer identity: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if registered {
+		metrics.Registrations.WithLabelValues("oidc").Inc()
+	}
+
+	return customerDB, nil
+}
+
+// oidcUsername derives a username that satisfies the registration rules from
+// the provider's preferred username or the email local part.
+func oidcUsername(preferred, email string) string {
+	source := preferred
+	if source == "" {
+		source = strings.SplitN(email, "@", 2)[0]
+	}
+
+	var b strings.Builder
+	for _, c := range source {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			b.WriteRune(c)
+		}
+	}
+
+	username := b.String()
+	if len(username) > maxUsernameLength {
+		username = username[:maxUsernameLength]
+	}
+
+	for len(username) < minUsernameLength {
+		username += "_"
+	}
+
+	return username
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
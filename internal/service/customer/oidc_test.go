@@ -0,0 +1,168 @@
+package customer_test
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/repository/mocks"
+	"ebookstore/internal/service/customer"
+	mocksService "ebookstore/internal/service/mocks"
+	authentication "ebookstore/utils/middleware"
+	"ebookstore/utils/oidc"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_customerService_OIDCLogin(t *testing.T) {
+	provider := &mocksService.IOIDCProvider{}
+	provider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("https://idp/authorize", nil)
+
+	s := customer.NewCustomerService(&mocks.ICustomerRepository{}, &mocks.IMFARepository{}, &mocks.ITransactionProvider{}, &mocksService.IOutboxService{}, provider, testConfig)
+	got, err := s.OIDCLogin(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://idp/authorize", got.AuthURL)
+
+	state, err := authentication.ParseOIDCStateToken(got.StateToken)
+	assert.NoError(t, err)
+	provider.AssertCalled(t, "AuthCodeURL", mock.Anything, state.State, state.Nonce, state.CodeVerifier)
+
+	disabled := customer.NewCustomerService(&mocks.ICustomerRepository{}, &mocks.IMFARepository{}, &mocks.ITransactionProvider{}, &mocksService.IOutboxService{}, nil, testConfig)
+	_, err = disabled.OIDCLogin(context.Background())
+	assert.Error(t, err)
+}
+
+func Test_customerService_OIDCCallback(t *testing.T) {
+	const issuer = "https://idp"
+	state := authentication.OIDCState{State: "state", Nonce: "nonce", CodeVerifier: "verifier"}
+	stateToken, _ := authentication.GenerateOIDCStateToken(state)
+	req := request.OIDCCallback{Code: "code", State: "state", StateToken: stateToken}
+	claims := oidc.Claims{Subject: "subject", Email: "Mail@mail.com", EmailVerified: true, PreferredUsername: "jo"}
+
+	newProvider := func(claims oidc.Claims, err error) *mocksService.IOIDCProvider {
+		m := mocksService.IOIDCProvider{}
+		m.On("Issuer").Return(issuer)
+		m.On("Exchange", mock.Anything, "code", "verifier", "nonce").Return(claims, err)
+		return &m
+	}
+	noMFA := func() *mocks.IMFARepository {
+		m := mocks.IMFARepository{}
+		m.On("GetMFAByCustomerID", mock.Anything, mock.Anything).Return(nil, nil)
+		return &m
+	}
+
+	type fields struct {
+		customerRepository *mocks.ICustomerRepository
+		mfaRepository      *mocks.IMFARepository
+		oidcProvider       *mocksService.IOIDCProvider
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		req     request.OIDCCallback
+		wantErr bool
+	}{
+		{
+			name: "known identity",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByIdentity", mock.Anything, issuer, "subject").Return(&model.Customer{ID: 1, Email: "mail@mail.com"}, nil)
+					return &m
+				}(),
+				mfaRepository: noMFA(),
+				oidcProvider:  newProvider(claims, nil),
+			},
+			req:     req,
+			wantErr: false,
+		},
+		{
+			name: "link existing customer by email",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByIdentity", mock.Anything, issuer, "subject").Return(nil, nil)
+					m.On("GetCustomerByEmail", mock.Anything, "mail@mail.com").Return(&model.Customer{ID: 2, Email: "mail@mail.com"}, nil)
+					m.On("LinkIdentity", mock.Anything, mock.Anything, model.CustomerIdentity{CustomerID: 2, Issuer: issuer, Subject: "subject", Email: "mail@mail.com"}).Return(nil)
+					return &m
+				}(),
+				mfaRepository: noMFA(),
+				oidcProvider:  newProvider(claims, nil),
+			},
+			req:     req,
+			wantErr: false,
+		},
+		{
+			name: "create customer on first login",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByIdentity", mock.Anything, issuer, "subject").Return(nil, nil)
+					m.On("GetCustomerByEmail", mock.Anything, "mail@mail.com").Return(&model.Customer{}, nil)
+					m.On("Register", mock.Anything, mock.Anything, &model.Customer{Email: "mail@mail.com", Username: "jo__"}).Return(uint(3), nil)
+					m.On("LinkIdentity", mock.Anything, mock.Anything, model.CustomerIdentity{CustomerID: 3, Issuer: issuer, Subject: "subject", Email: "mail@mail.com"}).Return(nil)
+					return &m
+				}(),
+				mfaRepository: noMFA(),
+				oidcProvider:  newProvider(claims, nil),
+			},
+			req:     req,
+			wantErr: false,
+		},
+		{
+			name: "LinkIdentity error",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByIdentity", mock.Anything, issuer, "subject").Return(nil, nil)
+					m.On("GetCustomerByEmail", mock.Anything, "mail@mail.com").Return(&model.Customer{}, nil)
+					m.On("Register", mock.Anything, mock.Anything, mock.Anything).Return(uint(3), nil)
+					m.On("LinkIdentity", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("error"))
+					return &m
+				}(),
+				oidcProvider: newProvider(claims, nil),
+			},
+			req:     req,
+			wantErr: true,
+		},
+		{
+			name: "unverified email",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByIdentity", mock.Anything, issuer, "subject").Return(nil, nil)
+					return &m
+				}(),
+				oidcProvider: newProvider(oidc.Claims{Subject: "subject", Email: "mail@mail.com"}, nil),
+			},
+			req:     req,
+			wantErr: true,
+		},
+		{
+			name: "state mismatch",
+			fields: fields{
+				oidcProvider: newProvider(claims, nil),
+			},
+			req:     request.OIDCCallback{Code: "code", State: "forged", StateToken: stateToken},
+			wantErr: true,
+		},
+		{
+			name: "Exchange error",
+			fields: fields{
+				oidcProvider: newProvider(oidc.Claims{}, errors.New("error")),
+			},
+			req:     req,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(tt.fields.customerRepository, tt.fields.mfaRepository, txProvider(), &mocksService.IOutboxService{}, tt.fields.oidcProvider, testConfig)
+			got, err := s.OIDCCallback(context.Background(), tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantErr, got.Token == "")
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package customer
+
+import (
+	"context"
+	"crypto/sha256"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/utils/emailtemplate"
+	authentication "ebookstore/utils/middleware"
+	"ebookstore/utils/notification"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+func (s *customerService) GetProfile(ctx context.Context) (response.ProfileData, error) {
+	customerID := ctx.Value("id").(uint)
+
+	customerDB, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return response.ProfileData{}, err
+	}
+
+	return s.profileData(ctx, customerDB)
+}
+
+// UpdateProfile changes the username right away. A new email is only stored as
+// pending until the customer opens the verification link sent to it.
+func (s *customerService) UpdateProfile(ctx context.Context, req request.UpdateProfile) (response.ProfileData, error) {
+	customerID := ctx.Value("id").(uint)
+
+	customerDB, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return response.ProfileData{}, err
+	}
+
+	if req.Username != "" {
+		customerDB.Username = req.Username
+	}
+
+	var verificationToken string
+	email := strings.ToLower(req.Email)
+	if email != "" && email != customerDB.Email {
+		err = s.ensureEmailAvailable(ctx, email, customerID)
+		if err != nil {
+			return response.ProfileData{}, err
+		}
+
+		verificationToken, err = randomToken()
+		if err != nil {
+			return response.ProfileData{}, fmt.Errorf("failed to generate verification token: %w", err)
+		}
+
+		customerDB.PendingEmail = email
+		customerDB.EmailVerificationTokenHash = hashToken(verificationToken)
+		customerDB.EmailVerificationExpiresAt = pq.NullTime{Time: time.Now().UTC().Add(emailVerificationTTL), Valid: true}
+	}
+
+	customerDB.UpdatedAt = pq.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return response.ProfileData{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = s.customerRepository.UpdateCustomer(ctx, tx, customerDB)
+	if err != nil {
+		return response.ProfileData{}, fmt.Errorf("failed to update customer: %w", err)
+	}
+
+	//send verification to the new address
+	if verificationToken != "" && s.cfg.Email.Enabled {
+		link := s.cfg.Server.BaseURL + "/api/customer/email/verify?token=" + verificationToken
+		content, err := emailtemplate.Render(emailtemplate.EmailVerification, s.cfg.Notification.Locale, emailtemplate.Verification{Name: customerDB.Username, Link: link})
+		if err != nil {
+			return response.ProfileData{}, err
+		}
+
+		msg := notification.Message{
+			Event:   "customer.email_change_requested",
+			To:      customerDB.PendingEmail,
+			Subject: content.Subject,
+			Body:    content.HTML,
+			Text:    content.Text,
+
+			CustomerID: customerDB.ID,
+			Category:   notification.CategoryAccount,
+		}
+
+		err = s.outboxService.Enqueue(ctx, tx, msg)
+		if err != nil {
+			return response.ProfileData{}, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return response.ProfileData{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.profileData(ctx, customerDB)
+}
+
+func (s *customerService) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return apperror.Validation("verification token cannot be empty")
+	}
+
+	customerDB, err := s.customerRepository.GetCustomerByEmailVerificationToken(ctx, hashToken(token))
+	if err != nil {
+		return fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	if customerDB == nil || customerDB.PendingEmail == "" {
+		return apperror.Validation("invalid verification token")
+	}
+
+	if !customerDB.EmailVerificationExpiresAt.Valid || time.Now().UTC().After(customerDB.EmailVerificationExpiresAt.Time) {
+		return apperror.Validation("verification token expired")
+	}
+
+	// the address may have been taken since the change was requested
+	err = s.ensureEmailAvailable(ctx, customerDB.PendingEmail, customerDB.ID)
+	if err != nil {
+		return err
+	}
+
+	customerDB.Email = customerDB.PendingEmail
+	customerDB.PendingEmail = ""
+	customerDB.EmailVerificationTokenHash = ""
+	customerDB.EmailVerificationExpiresAt = pq.NullTime{}
+	customerDB.UpdatedAt = pq.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	return s.updateCustomer(ctx, customerDB)
+}
+
+func (s *customerService) ChangePassword(ctx context.Context, req request.ChangePassword) error {
+	customerID := ctx.Value("id").(uint)
+
+	customerDB, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	if !authentication.CompareHashedPassword(customerDB.Password, req.CurrentPassword) {
+		return apperror.Forbidden("invalid current password")
+	}
+
+	hashedPass, err := authentication.GenerateHashedPassword(req.NewPassword)
+	if err != nil {
+		return errors.New("failed to generate hashed password")
+	}
+
+	customerDB.Password = hashedPass
+	customerDB.UpdatedAt = pq.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	return s.updateCustomer(ctx, customerDB)
+}
+
+func (s *customerService) updateCustomer(ctx context.Context, customer *model.Customer) error {
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = s.customerRepository.UpdateCustomer(ctx, tx, customer)
+	if err != nil {
+		return fmt.Errorf("failed to update customer: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *customerService) getCustomer(ctx context.Context, customerID uint) (*model.Customer, error) {
+	customerDB, err := s.customerRepository.GetCustomerByID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	if customerDB == nil {
+		return nil, apperror.NotFound("customer not found")
+	}
+
+	return customerDB, nil
+}
+
+func (s *customerService) ensureEmailAvailable(ctx context.Context, email string, customerID uint) error {
+	existing, err := s.customerRepository.GetCustomerByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to get email existing: %w", err)
+	}
+
+	if existing != nil && existing.Email == email && existing.ID != customerID {
+		return apperror.Conflict("email already exists")
+	}
+
+	return nil
+}
+
+func (s *customerService) profileData(ctx context.Context, customer *model.Customer) (response.ProfileData, error) {
+	customerMFA, err := s.mfaRepository.GetMFAByCustomerID(ctx, customer.ID)
+	if err != nil {
+		return response.ProfileData{}, fmt.Errorf("failed to get mfa status: %w", err)
+	}
+
+	return response.ProfileData{
+		ID:           customer.ID,
+		Username:     customer.Username,
+		Email:        customer.Email,
+		PendingEmail: customer.PendingEmail,
+		MFAEnabled:   customerMFA != nil && customerMFA.Enabled,
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
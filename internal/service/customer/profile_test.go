@@ -0,0 +1,269 @@
+package customer_test
+
+import (
+	"context"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/repository/mocks"
+	"ebookstore/internal/service/customer"
+	mocksService "ebookstore/internal/service/mocks"
+	"ebookstore/utils/notification"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_customerService_UpdateProfile(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	noMFA := func() *mocks.IMFARepository {
+		m := mocks.IMFARepository{}
+		m.On("GetMFAByCustomerID", mock.Anything, uint(1)).Return(nil, nil)
+		return &m
+	}
+
+	type fields struct {
+		customerRepository *mocks.ICustomerRepository
+		mfaRepository      *mocks.IMFARepository
+		outboxService      *mocksService.IOutboxService
+	}
+	tests := []struct {
+		name             string
+		fields           fields
+		req              request.UpdateProfile
+		wantUsername     string
+		wantPendingEmail string
+		wantErr          bool
+	}{
+		{
+			name: "change username",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(1)).Return(&model.Customer{ID: 1, Email: "old@mail.com", Username: "old_name"}, nil)
+					m.On("UpdateCustomer", mock.Anything, mock.Anything, mock.MatchedBy(func(c *model.Customer) bool {
+						return c.Username == "new_name" && c.PendingEmail == ""
+					})).Return(nil)
+					return &m
+				}(),
+				mfaRepository: noMFA(),
+			},
+			req:          request.UpdateProfile{Username: "new_name"},
+			wantUsername: "new_name",
+			wantErr:      false,
+		},
+		{
+			name: "change email needs verification",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(1)).Return(&model.Customer{ID: 1, Email: "old@mail.com", Username: "old_name"}, nil)
+					m.On("GetCustomerByEmail", mock.Anything, "new@mail.com").Return(&model.Customer{}, nil)
+					m.On("UpdateCustomer", mock.Anything, mock.Anything, mock.MatchedBy(func(c *model.Customer) bool {
+						return c.Email == "old@mail.com" && c.PendingEmail == "new@mail.com" && c.EmailVerificationTokenHash != "" && c.EmailVerificationExpiresAt.Valid
+					})).Return(nil)
+					return &m
+				}(),
+				mfaRepository: noMFA(),
+				outboxService: func() *mocksService.IOutboxService {
+					m := mocksService.IOutboxService{}
+					m.On("Enqueue", mock.Anything, mock.Anything, mock.MatchedBy(func(p notification.Message) bool {
+						return p.To == "new@mail.com"
+					})).Return(nil)
+					return &m
+				}(),
+			},
+			req:              request.UpdateProfile{Email: "New@mail.com"},
+			wantUsername:     "old_name",
+			wantPendingEmail: "new@mail.com",
+			wantErr:          false,
+		},
+		{
+			name: "email already exists",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(1)).Return(&model.Customer{ID: 1, Email: "old@mail.com"}, nil)
+					m.On("GetCustomerByEmail", mock.Anything, "new@mail.com").Return(&model.Customer{ID: 2, Email: "new@mail.com"}, nil)
+					return &m
+				}(),
+			},
+			req:     request.UpdateProfile{Email: "new@mail.com"},
+			wantErr: true,
+		},
+		{
+			name: "customer not found",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(1)).Return(nil, nil)
+					return &m
+				}(),
+			},
+			req:     request.UpdateProfile{Username: "new_name"},
+			wantErr: true,
+		},
+		{
+			name: "UpdateCustomer error",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(1)).Return(&model.Customer{ID: 1, Email: "old@mail.com"}, nil)
+					m.On("UpdateCustomer", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("error"))
+					return &m
+				}(),
+			},
+			req:     request.UpdateProfile{Username: "new_name"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(tt.fields.customerRepository, tt.fields.mfaRepository, txProvider(), tt.fields.outboxService, nil, testConfig)
+			got, err := s.UpdateProfile(ctx, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantUsername, got.Username)
+			assert.Equal(t, tt.wantPendingEmail, got.PendingEmail)
+		})
+	}
+}
+
+func Test_customerService_VerifyEmail(t *testing.T) {
+	pending := func(expiresAt time.Time) *model.Customer {
+		return &model.Customer{
+			ID:                         1,
+			Email:                      "old@mail.com",
+			PendingEmail:               "new@mail.com",
+			EmailVerificationTokenHash: "hash",
+			EmailVerificationExpiresAt: pq.NullTime{Time: expiresAt, Valid: true},
+		}
+	}
+
+	type fields struct {
+		customerRepository *mocks.ICustomerRepository
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		token   string
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByEmailVerificationToken", mock.Anything, mock.Anything).Return(pending(time.Now().Add(time.Hour)), nil)
+					m.On("GetCustomerByEmail", mock.Anything, "new@mail.com").Return(&model.Customer{}, nil)
+					m.On("UpdateCustomer", mock.Anything, mock.Anything, mock.MatchedBy(func(c *model.Customer) bool {
+						return c.Email == "new@mail.com" && c.PendingEmail == "" && c.EmailVerificationTokenHash == ""
+					})).Return(nil)
+					return &m
+				}(),
+			},
+			token:   "token",
+			wantErr: false,
+		},
+		{
+			name: "expired token",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByEmailVerificationToken", mock.Anything, mock.Anything).Return(pending(time.Now().Add(-time.Hour)), nil)
+					return &m
+				}(),
+			},
+			token:   "token",
+			wantErr: true,
+		},
+		{
+			name: "unknown token",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByEmailVerificationToken", mock.Anything, mock.Anything).Return(nil, nil)
+					return &m
+				}(),
+			},
+			token:   "token",
+			wantErr: true,
+		},
+		{
+			name:    "empty token",
+			token:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(tt.fields.customerRepository, &mocks.IMFARepository{}, txProvider(), &mocksService.IOutboxService{}, nil, testConfig)
+			err := s.VerifyEmail(context.Background(), tt.token)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_customerService_ChangePassword(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	hashed := "$2a$12$KptVrUIFh4qX5.b8fHNjK.n1U749q8q86DtGxUFbEwbSUymQ./zty"
+
+	type fields struct {
+		customerRepository *mocks.ICustomerRepository
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		req     request.ChangePassword
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(1)).Return(&model.Customer{ID: 1, Password: hashed}, nil)
+					m.On("UpdateCustomer", mock.Anything, mock.Anything, mock.MatchedBy(func(c *model.Customer) bool {
+						return c.Password != hashed
+					})).Return(nil)
+					return &m
+				}(),
+			},
+			req:     request.ChangePassword{CurrentPassword: "password", NewPassword: "Passw0rd."},
+			wantErr: false,
+		},
+		{
+			name: "invalid current password",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(1)).Return(&model.Customer{ID: 1, Password: hashed}, nil)
+					return &m
+				}(),
+			},
+			req:     request.ChangePassword{CurrentPassword: "wrong", NewPassword: "Passw0rd."},
+			wantErr: true,
+		},
+		{
+			name: "GetCustomerByID error",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(1)).Return(nil, errors.New("error"))
+					return &m
+				}(),
+			},
+			req:     request.ChangePassword{CurrentPassword: "password", NewPassword: "Passw0rd."},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := customer.NewCustomerService(tt.fields.customerRepository, &mocks.IMFARepository{}, txProvider(), &mocksService.IOutboxService{}, nil, testConfig)
+			err := s.ChangePassword(ctx, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
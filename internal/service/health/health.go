@@ -0,0 +1,168 @@
+package health
+
+import (
+	"context"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/service"
+	"ebookstore/utils/config"
+	"ebookstore/utils/migrator"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusSkipped     = "skipped"
+)
+
+// checkTimeout keeps a hanging dependency from hanging the probe.
+const checkTimeout = 2 * time.Second
+
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+type migrationStatus interface {
+	Status(ctx context.Context) ([]migrator.Status, error)
+}
+
+type healthService struct {
+	db           pinger
+	migrations   migrationStatus
+	cfg          *config.Config
+	shuttingDown atomic.Bool
+}
+
+func NewHealthService(db pinger, migrations migrationStatus, cfg *config.Config) service.IHealthService {
+	return &healthService{
+		db:         db,
+		migrations: migrations,
+		cfg:        cfg,
+	}
+}
+
+// SetShuttingDown makes readiness fail from now on, so the load balancer
+// stops sending traffic before the server stops.
+func (s *healthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+// Ready runs every dependency check concurrently and reports whether the
+// service can take traffic.
+func (s *healthService) Ready(ctx context.Context) (response.Readiness, bool) {
+	checks := map[string]func(ctx context.Context) (string, error){
+		"postgres":   s.checkPostgres,
+		"migrations": s.checkMigrations,
+		"smtp":       s.checkSMTP,
+	}
+
+	resp := response.Readiness{
+		Status: StatusOK,
+		Checks: make(map[string]response.HealthCheck, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) (string, error)) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			result := response.HealthCheck{Status: StatusOK}
+			detail, err := check(checkCtx)
+			if errors.Is(err, errSkipped) {
+				result.Status = StatusSkipped
+			} else if err != nil {
+				result.Status = StatusUnavailable
+				result.Error = err.Error()
+			}
+			result.Detail = detail
+
+			mu.Lock()
+			resp.Checks[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	ready := true
+	for _, check := range resp.Checks {
+		if check.Status == StatusUnavailable {
+			ready = false
+		}
+	}
+
+	if s.shuttingDown.Load() {
+		resp.Checks["shutdown"] = response.HealthCheck{Status: StatusUnavailable, Error: "server is shutting down"}
+		ready = false
+	}
+
+	if !ready {
+		resp.Status = StatusUnavailable
+	}
+
+	return resp, ready
+}
+
+var errSkipped = errors.New("skipped")
+
+func (s *healthService) checkPostgres(ctx context.Context) (string, error) {
+	return "", s.db.PingContext(ctx)
+}
+
+// checkMigrations fails when a migration is pending, or when the database was
+// migrated by a newer or different build.
+func (s *healthService) checkMigrations(ctx context.Context) (string, error) {
+	statuses, err := s.migrations.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var current, expected int64
+	for _, status := range statuses {
+		switch status.State {
+		case migrator.StateApplied:
+			current = status.Version
+			expected = status.Version
+		case migrator.StatePending:
+			expected = status.Version
+		default:
+			return "", fmt.Errorf("migration %d_%s is %s", status.Version, status.Name, status.State)
+		}
+	}
+
+	detail := fmt.Sprintf("version %d", current)
+	if current != expected {
+		return detail, fmt.Errorf("database is at version %d, expected %d", current, expected)
+	}
+
+	return detail, nil
+}
+
+// checkSMTP only opens a TCP connection, logging in on every probe would get
+// the account rate limited.
+func (s *healthService) checkSMTP(ctx context.Context) (string, error) {
+	if !s.cfg.Email.Enabled || !s.cfg.Email.HealthCheck || !slices.Contains(s.cfg.Notification.Channels, "smtp") {
+		return "", errSkipped
+	}
+
+	address := net.JoinHostPort(s.cfg.Email.SMTPHost, strconv.Itoa(s.cfg.Email.SMTPPort))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return address, err
+	}
+	conn.Close()
+
+	return address, nil
+}
@@ -0,0 +1,142 @@
+package health_test
+
+import (
+	"context"
+	"ebookstore/internal/service/health"
+	"ebookstore/utils/config"
+	"ebookstore/utils/migrator"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (f fakePinger) PingContext(ctx context.Context) error {
+	return f.err
+}
+
+type fakeMigrations struct {
+	statuses []migrator.Status
+	err      error
+}
+
+func (f fakeMigrations) Status(ctx context.Context) ([]migrator.Status, error) {
+	return f.statuses, f.err
+}
+
+func Test_healthService_Ready(t *testing.T) {
+	upToDate := fakeMigrations{statuses: []migrator.Status{
+		{Version: 1, Name: "create_books", State: migrator.StateApplied},
+		{Version: 2, Name: "create_orders", State: migrator.StateApplied},
+	}}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	smtpPort := listener.Addr().(*net.TCPAddr).Port
+
+	smtpConfig := func(port int) *config.Config {
+		cfg := config.Default()
+		cfg.Email.Enabled = true
+		cfg.Email.HealthCheck = true
+		cfg.Email.SMTPHost = "127.0.0.1"
+		cfg.Email.SMTPPort = port
+		return &cfg
+	}
+	defaultConfig := func() *config.Config {
+		cfg := config.Default()
+		return &cfg
+	}()
+
+	tests := []struct {
+		name         string
+		db           fakePinger
+		migrations   fakeMigrations
+		cfg          *config.Config
+		shuttingDown bool
+		wantReady    bool
+		wantChecks   map[string]string
+	}{
+		{
+			name:       "best case",
+			migrations: upToDate,
+			cfg:        defaultConfig,
+			wantReady:  true,
+			wantChecks: map[string]string{"postgres": "ok", "migrations": "ok", "smtp": "skipped"},
+		},
+		{
+			name:       "postgres down",
+			db:         fakePinger{err: errors.New("connection refused")},
+			migrations: upToDate,
+			cfg:        defaultConfig,
+			wantReady:  false,
+			wantChecks: map[string]string{"postgres": "unavailable", "migrations": "ok", "smtp": "skipped"},
+		},
+		{
+			name: "pending migration",
+			migrations: fakeMigrations{statuses: []migrator.Status{
+				{Version: 1, Name: "create_books", State: migrator.StateApplied},
+				{Version: 2, Name: "create_orders", State: migrator.StatePending},
+			}},
+			cfg:        defaultConfig,
+			wantReady:  false,
+			wantChecks: map[string]string{"postgres": "ok", "migrations": "unavailable", "smtp": "skipped"},
+		},
+		{
+			name: "unknown migration applied",
+			migrations: fakeMigrations{statuses: []migrator.Status{
+				{Version: 1, Name: "create_books", State: migrator.StateApplied},
+				{Version: 3, Name: "newer", State: migrator.StateMissing},
+			}},
+			cfg:        defaultConfig,
+			wantReady:  false,
+			wantChecks: map[string]string{"postgres": "ok", "migrations": "unavailable", "smtp": "skipped"},
+		},
+		{
+			name:       "smtp reachable",
+			migrations: upToDate,
+			cfg:        smtpConfig(smtpPort),
+			wantReady:  true,
+			wantChecks: map[string]string{"postgres": "ok", "migrations": "ok", "smtp": "ok"},
+		},
+		{
+			name:       "smtp unreachable",
+			migrations: upToDate,
+			cfg:        smtpConfig(1),
+			wantReady:  false,
+			wantChecks: map[string]string{"postgres": "ok", "migrations": "ok", "smtp": "unavailable"},
+		},
+		{
+			name:         "shutting down",
+			migrations:   upToDate,
+			cfg:          defaultConfig,
+			shuttingDown: true,
+			wantReady:    false,
+			wantChecks:   map[string]string{"postgres": "ok", "migrations": "ok", "smtp": "skipped", "shutdown": "unavailable"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := health.NewHealthService(tt.db, tt.migrations, tt.cfg)
+			if tt.shuttingDown {
+				s.SetShuttingDown()
+			}
+
+			got, ready := s.Ready(context.Background())
+			assert.Equal(t, tt.wantReady, ready)
+
+			checks := make(map[string]string)
+			for name, check := range got.Checks {
+				checks[name] = check.Status
+			}
+			assert.Equal(t, tt.wantChecks, checks)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Code generated by mockery v2.42.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	request "ebookstore/internal/model/request"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "ebookstore/internal/model/response"
+)
+
+// IAddressService is an autogenerated mock type for the IAddressService type
+type IAddressService struct {
+	mock.Mock
+}
+
+// CreateAddress provides a mock function with given fields: ctx, req
+func (_m *IAddressService) CreateAddress(ctx context.Context, req request.Address) (response.AddressData, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAddress")
+	}
+
+	var r0 response.AddressData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.Address) (response.AddressData, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.Address) response.AddressData); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(response.AddressData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.Address) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAddress provides a mock function with given fields: ctx, id
+func (_m *IAddressService) DeleteAddress(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAddress")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAddresses provides a mock function with given fields: ctx
+func (_m *IAddressService) GetAddresses(ctx context.Context) ([]response.AddressData, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAddresses")
+	}
+
+	var r0 []response.AddressData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]response.AddressData, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []response.AddressData); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]response.AddressData)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetDefaultAddress provides a mock function with given fields: ctx, id
+func (_m *IAddressService) SetDefaultAddress(ctx context.Context, id uint) (response.AddressData, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetDefaultAddress")
+	}
+
+	var r0 response.AddressData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (response.AddressData, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) response.AddressData); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(response.AddressData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAddress provides a mock function with given fields: ctx, id, req
+func (_m *IAddressService) UpdateAddress(ctx context.Context, id uint, req request.Address) (response.AddressData, error) {
+	ret := _m.Called(ctx, id, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAddress")
+	}
+
+	var r0 response.AddressData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, request.Address) (response.AddressData, error)); ok {
+		return rf(ctx, id, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, request.Address) response.AddressData); ok {
+		r0 = rf(ctx, id, req)
+	} else {
+		r0 = ret.Get(0).(response.AddressData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, request.Address) error); ok {
+		r1 = rf(ctx, id, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewIAddressService creates a new instance of IAddressService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIAddressService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IAddressService {
+	mock := &IAddressService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -4,9 +4,11 @@ package mocks
 
 import (
 	context "context"
-	response "ebookstore/internal/model/response"
+	request "ebookstore/internal/model/request"
 
 	mock "github.com/stretchr/testify/mock"
+
+	response "ebookstore/internal/model/response"
 )
 
 // IBookService is an autogenerated mock type for the IBookService type
@@ -44,6 +46,104 @@ func (_m *IBookService) GetBooks(ctx context.Context) ([]response.Book, error) {
 	return r0, r1
 }
 
+// ImportBooks provides a mock function with given fields: ctx, books
+func (_m *IBookService) ImportBooks(ctx context.Context, books []request.ImportBook) (response.ImportBooksData, error) {
+	ret := _m.Called(ctx, books)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportBooks")
+	}
+
+	var r0 response.ImportBooksData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []request.ImportBook) (response.ImportBooksData, error)); ok {
+		return rf(ctx, books)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []request.ImportBook) response.ImportBooksData); ok {
+		r0 = rf(ctx, books)
+	} else {
+		r0 = ret.Get(0).(response.ImportBooksData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []request.ImportBook) error); ok {
+		r1 = rf(ctx, books)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvalidateCache provides a mock function with given fields: ctx, bookIDs
+func (_m *IBookService) InvalidateCache(ctx context.Context, bookIDs ...uint) {
+	_va := make([]interface{}, len(bookIDs))
+	for _i := range bookIDs {
+		_va[_i] = bookIDs[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
+// ReindexSearch provides a mock function with given fields: ctx
+func (_m *IBookService) ReindexSearch(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReindexSearch")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchBooks provides a mock function with given fields: ctx, query
+func (_m *IBookService) SearchBooks(ctx context.Context, query string) ([]response.Book, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchBooks")
+	}
+
+	var r0 []response.Book
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]response.Book, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []response.Book); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]response.Book)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewIBookService creates a new instance of IBookService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewIBookService(t interface {
@@ -7,6 +7,8 @@ import (
 	request "ebookstore/internal/model/request"
 
 	mock "github.com/stretchr/testify/mock"
+
+	response "ebookstore/internal/model/response"
 )
 
 // ICustomerService is an autogenerated mock type for the ICustomerService type
@@ -14,23 +16,229 @@ type ICustomerService struct {
 	mock.Mock
 }
 
+// ActivateMFA provides a mock function with given fields: ctx, req
+func (_m *ICustomerService) ActivateMFA(ctx context.Context, req request.MFACode) ([]string, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ActivateMFA")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.MFACode) ([]string, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.MFACode) []string); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.MFACode) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePassword provides a mock function with given fields: ctx, req
+func (_m *ICustomerService) ChangePassword(ctx context.Context, req request.ChangePassword) error {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.ChangePassword) error); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateAdmin provides a mock function with given fields: ctx, req
+func (_m *ICustomerService) CreateAdmin(ctx context.Context, req request.Register) (uint, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAdmin")
+	}
+
+	var r0 uint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.Register) (uint, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.Register) uint); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.Register) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DisableMFA provides a mock function with given fields: ctx, req
+func (_m *ICustomerService) DisableMFA(ctx context.Context, req request.MFACode) error {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DisableMFA")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.MFACode) error); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnrollMFA provides a mock function with given fields: ctx
+func (_m *ICustomerService) EnrollMFA(ctx context.Context) (response.MFAEnrollmentData, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnrollMFA")
+	}
+
+	var r0 response.MFAEnrollmentData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (response.MFAEnrollmentData, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) response.MFAEnrollmentData); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(response.MFAEnrollmentData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProfile provides a mock function with given fields: ctx
+func (_m *ICustomerService) GetProfile(ctx context.Context) (response.ProfileData, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetProfile")
+	}
+
+	var r0 response.ProfileData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (response.ProfileData, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) response.ProfileData); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(response.ProfileData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRole provides a mock function with given fields: ctx, id
+func (_m *ICustomerService) GetRole(ctx context.Context, id uint) (string, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRole")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (string, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) string); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsActive provides a mock function with given fields: ctx, id
+func (_m *ICustomerService) IsActive(ctx context.Context, id uint) (bool, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsActive")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (bool, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) bool); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Login provides a mock function with given fields: ctx, customer
-func (_m *ICustomerService) Login(ctx context.Context, customer request.Login) (string, error) {
+func (_m *ICustomerService) Login(ctx context.Context, customer request.Login) (response.LoginData, error) {
 	ret := _m.Called(ctx, customer)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Login")
 	}
 
-	var r0 string
+	var r0 response.LoginData
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, request.Login) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, request.Login) (response.LoginData, error)); ok {
 		return rf(ctx, customer)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, request.Login) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, request.Login) response.LoginData); ok {
 		r0 = rf(ctx, customer)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(response.LoginData)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, request.Login) error); ok {
@@ -42,6 +250,62 @@ func (_m *ICustomerService) Login(ctx context.Context, customer request.Login) (
 	return r0, r1
 }
 
+// OIDCCallback provides a mock function with given fields: ctx, req
+func (_m *ICustomerService) OIDCCallback(ctx context.Context, req request.OIDCCallback) (response.LoginData, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OIDCCallback")
+	}
+
+	var r0 response.LoginData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.OIDCCallback) (response.LoginData, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.OIDCCallback) response.LoginData); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(response.LoginData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.OIDCCallback) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OIDCLogin provides a mock function with given fields: ctx
+func (_m *ICustomerService) OIDCLogin(ctx context.Context) (response.OIDCAuthorization, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OIDCLogin")
+	}
+
+	var r0 response.OIDCAuthorization
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (response.OIDCAuthorization, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) response.OIDCAuthorization); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(response.OIDCAuthorization)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Register provides a mock function with given fields: ctx, customer
 func (_m *ICustomerService) Register(ctx context.Context, customer request.Register) (string, error) {
 	ret := _m.Called(ctx, customer)
@@ -70,6 +334,98 @@ func (_m *ICustomerService) Register(ctx context.Context, customer request.Regis
 	return r0, r1
 }
 
+// UpdateProfile provides a mock function with given fields: ctx, req
+func (_m *ICustomerService) UpdateProfile(ctx context.Context, req request.UpdateProfile) (response.ProfileData, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateProfile")
+	}
+
+	var r0 response.ProfileData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.UpdateProfile) (response.ProfileData, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.UpdateProfile) response.ProfileData); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(response.ProfileData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.UpdateProfile) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyEmail provides a mock function with given fields: ctx, token
+func (_m *ICustomerService) VerifyEmail(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyMFALogin provides a mock function with given fields: ctx, req
+func (_m *ICustomerService) VerifyMFALogin(ctx context.Context, req request.LoginMFA) (string, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyMFALogin")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.LoginMFA) (string, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.LoginMFA) string); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.LoginMFA) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifySecondFactor provides a mock function with given fields: ctx, customerID, code
+func (_m *ICustomerService) VerifySecondFactor(ctx context.Context, customerID uint, code string) error {
+	ret := _m.Called(ctx, customerID, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifySecondFactor")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(ctx, customerID, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewICustomerService creates a new instance of ICustomerService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewICustomerService(t interface {
@@ -0,0 +1,62 @@
+// Code generated by mockery v2.42.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	response "ebookstore/internal/model/response"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IHealthService is an autogenerated mock type for the IHealthService type
+type IHealthService struct {
+	mock.Mock
+}
+
+// Ready provides a mock function with given fields: ctx
+func (_m *IHealthService) Ready(ctx context.Context) (response.Readiness, bool) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ready")
+	}
+
+	var r0 response.Readiness
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context) (response.Readiness, bool)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) response.Readiness); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(response.Readiness)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) bool); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// SetShuttingDown provides a mock function with given fields:
+func (_m *IHealthService) SetShuttingDown() {
+	_m.Called()
+}
+
+// NewIHealthService creates a new instance of IHealthService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIHealthService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IHealthService {
+	mock := &IHealthService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
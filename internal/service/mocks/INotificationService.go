@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	notification "ebookstore/utils/notification"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,17 +14,17 @@ type INotificationService struct {
 	mock.Mock
 }
 
-// SendNotification provides a mock function with given fields: payload
-func (_m *INotificationService) SendNotification(payload notification.EmailPayload) error {
-	ret := _m.Called(payload)
+// SendNotification provides a mock function with given fields: ctx, msg
+func (_m *INotificationService) SendNotification(ctx context.Context, msg notification.Message) error {
+	ret := _m.Called(ctx, msg)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SendNotification")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(notification.EmailPayload) error); ok {
-		r0 = rf(payload)
+	if rf, ok := ret.Get(0).(func(context.Context, notification.Message) error); ok {
+		r0 = rf(ctx, msg)
 	} else {
 		r0 = ret.Error(0)
 	}
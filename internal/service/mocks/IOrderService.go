@@ -16,9 +16,9 @@ type IOrderService struct {
 	mock.Mock
 }
 
-// CreateOrder provides a mock function with given fields: ctx, req
-func (_m *IOrderService) CreateOrder(ctx context.Context, req request.CreateOrder) (response.CreateOrderData, error) {
-	ret := _m.Called(ctx, req)
+// CreateOrder provides a mock function with given fields: ctx, req, idempotencyKey
+func (_m *IOrderService) CreateOrder(ctx context.Context, req request.CreateOrder, idempotencyKey string) (response.CreateOrderData, error) {
+	ret := _m.Called(ctx, req, idempotencyKey)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateOrder")
@@ -26,17 +26,140 @@ func (_m *IOrderService) CreateOrder(ctx context.Context, req request.CreateOrde
 
 	var r0 response.CreateOrderData
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, request.CreateOrder) (response.CreateOrderData, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, request.CreateOrder, string) (response.CreateOrderData, error)); ok {
+		return rf(ctx, req, idempotencyKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.CreateOrder, string) response.CreateOrderData); ok {
+		r0 = rf(ctx, req, idempotencyKey)
+	} else {
+		r0 = ret.Get(0).(response.CreateOrderData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.CreateOrder, string) error); ok {
+		r1 = rf(ctx, req, idempotencyKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportOrders provides a mock function with given fields: ctx, customerID
+func (_m *IOrderService) ExportOrders(ctx context.Context, customerID uint) ([]response.OrderData, error) {
+	ret := _m.Called(ctx, customerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportOrders")
+	}
+
+	var r0 []response.OrderData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) ([]response.OrderData, error)); ok {
+		return rf(ctx, customerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []response.OrderData); ok {
+		r0 = rf(ctx, customerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]response.OrderData)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, customerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserOrder provides a mock function with given fields: ctx, reference
+func (_m *IOrderService) GetUserOrder(ctx context.Context, reference string) (response.OrderData, error) {
+	ret := _m.Called(ctx, reference)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserOrder")
+	}
+
+	var r0 response.OrderData
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (response.OrderData, error)); ok {
+		return rf(ctx, reference)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) response.OrderData); ok {
+		r0 = rf(ctx, reference)
+	} else {
+		r0 = ret.Get(0).(response.OrderData)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, reference)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserOrders provides a mock function with given fields: ctx, req
+func (_m *IOrderService) GetUserOrders(ctx context.Context, req request.GetOrderHistory) ([]response.OrderData, string, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserOrders")
+	}
+
+	var r0 []response.OrderData
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.GetOrderHistory) ([]response.OrderData, string, error)); ok {
 		return rf(ctx, req)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, request.CreateOrder) response.CreateOrderData); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, request.GetOrderHistory) []response.OrderData); ok {
 		r0 = rf(ctx, req)
 	} else {
-		r0 = ret.Get(0).(response.CreateOrderData)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]response.OrderData)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, request.CreateOrder) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, request.GetOrderHistory) string); ok {
 		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, request.GetOrderHistory) error); ok {
+		r2 = rf(ctx, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// PreviewOrderConfirmation provides a mock function with given fields: ctx, orderID, locale
+func (_m *IOrderService) PreviewOrderConfirmation(ctx context.Context, orderID uint, locale string) (response.EmailPreview, error) {
+	ret := _m.Called(ctx, orderID, locale)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PreviewOrderConfirmation")
+	}
+
+	var r0 response.EmailPreview
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) (response.EmailPreview, error)); ok {
+		return rf(ctx, orderID, locale)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) response.EmailPreview); ok {
+		r0 = rf(ctx, orderID, locale)
+	} else {
+		r0 = ret.Get(0).(response.EmailPreview)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(ctx, orderID, locale)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,25 +167,23 @@ func (_m *IOrderService) CreateOrder(ctx context.Context, req request.CreateOrde
 	return r0, r1
 }
 
-// GetUserOrders provides a mock function with given fields: ctx
-func (_m *IOrderService) GetUserOrders(ctx context.Context) ([]response.OrderData, error) {
+// PurgeIdempotencyKeys provides a mock function with given fields: ctx
+func (_m *IOrderService) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserOrders")
+		panic("no return value specified for PurgeIdempotencyKeys")
 	}
 
-	var r0 []response.OrderData
+	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]response.OrderData, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
 		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []response.OrderData); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
 		r0 = rf(ctx)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]response.OrderData)
-		}
+		r0 = ret.Get(0).(int64)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
@@ -74,6 +195,42 @@ func (_m *IOrderService) GetUserOrders(ctx context.Context) ([]response.OrderDat
 	return r0, r1
 }
 
+// ResendOrderConfirmation provides a mock function with given fields: ctx, orderID
+func (_m *IOrderService) ResendOrderConfirmation(ctx context.Context, orderID uint) error {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResendOrderConfirmation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateReference provides a mock function with given fields: ctx, value
+func (_m *IOrderService) ValidateReference(ctx context.Context, value string) response.ReferenceValidation {
+	ret := _m.Called(ctx, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateReference")
+	}
+
+	var r0 response.ReferenceValidation
+	if rf, ok := ret.Get(0).(func(context.Context, string) response.ReferenceValidation); ok {
+		r0 = rf(ctx, value)
+	} else {
+		r0 = ret.Get(0).(response.ReferenceValidation)
+	}
+
+	return r0
+}
+
 // NewIOrderService creates a new instance of IOrderService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewIOrderService(t interface {
@@ -2,53 +2,186 @@ package order
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"ebookstore/internal/apperror"
 	"ebookstore/internal/model"
 	"ebookstore/internal/model/request"
 	"ebookstore/internal/model/response"
 	"ebookstore/internal/repository"
 	"ebookstore/internal/service"
+	"ebookstore/utils/cache"
 	"ebookstore/utils/config"
+	"ebookstore/utils/emailtemplate"
+	"ebookstore/utils/metrics"
 	"ebookstore/utils/notification"
+	"ebookstore/utils/reference"
+	"ebookstore/utils/tracing"
 	"ebookstore/utils/transactioner"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
 )
 
-var mapBook = make(map[uint]float64)
+const (
+	// customerReferenceLength and airwaybillNumberLength are the random symbols
+	// of the references, a check symbol follows them
+	customerReferenceLength = 10
+	airwaybillNumberLength  = 12
+	// maxReferenceAttempts bounds the orders tried when the references collide
+	maxReferenceAttempts = 5
+)
 
 type orderService struct {
-	orderRepository     repository.IOrderRepository
-	TransactionProvider transactioner.ITransactionProvider
-	bookRepository      repository.IBookRepository
-	notificationService notification.INotificationService
+	orderRepository       repository.IOrderRepository
+	addressRepository     repository.IAddressRepository
+	customerRepository    repository.ICustomerRepository
+	TransactionProvider   transactioner.ITransactionProvider
+	bookRepository        repository.IBookRepository
+	idempotencyRepository repository.IIdempotencyRepository
+	bookCache             cache.ICache[model.Book]
+	notificationService   notification.INotificationService
+	outboxService         service.IOutboxService
+	cfg                   *config.Config
 }
 
-func NewOrderService(orderRepository repository.IOrderRepository, bookRepository repository.IBookRepository, tx transactioner.ITransactionProvider, notificationService notification.INotificationService) service.IOrderService {
+func NewOrderService(orderRepository repository.IOrderRepository, addressRepository repository.IAddressRepository, customerRepository repository.ICustomerRepository, bookRepository repository.IBookRepository, idempotencyRepository repository.IIdempotencyRepository, bookCache cache.ICache[model.Book], tx transactioner.ITransactionProvider, notificationService notification.INotificationService, outboxService service.IOutboxService, cfg *config.Config) service.IOrderService {
 	return &orderService{
-		orderRepository:     orderRepository,
-		bookRepository:      bookRepository,
-		TransactionProvider: tx,
-		notificationService: notificationService,
+		orderRepository:       orderRepository,
+		addressRepository:     addressRepository,
+		customerRepository:    customerRepository,
+		bookRepository:        bookRepository,
+		idempotencyRepository: idempotencyRepository,
+		bookCache:             bookCache,
+		TransactionProvider:   tx,
+		notificationService:   notificationService,
+		outboxService:         outboxService,
+		cfg:                   cfg,
 	}
 }
 
-func (o *orderService) GetUserOrders(ctx context.Context) ([]response.OrderData, error) {
+// GetUserOrders returns a page of the customer's orders, newest first, and the
+// cursor of the next page, empty on the last one.
+func (o *orderService) GetUserOrders(ctx context.Context, req request.GetOrderHistory) ([]response.OrderData, string, error) {
+	ctx, span := tracing.Start(ctx, "orderService.GetUserOrders")
+	defer span.End()
+
 	customerID := ctx.Value("id").(uint)
-	mapOrderIDToOrderData := make(map[uint]response.OrderData)
+	q, err := orderHistoryQuery(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	//one order more than the page tells whether there is a next one
+	q.Limit = req.Limit + 1
+	orders, err := o.orderRepository.GetOrderHistoryByCustomerID(ctx, customerID, q)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get order history: %w", err)
+	}
+
+	var nextCursor string
+	if len(orders) > req.Limit {
+		orders = orders[:req.Limit]
+		nextCursor = encodeCursor(orders[len(orders)-1])
+	}
+
+	resp, err := o.toOrderData(ctx, orders)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resp, nextCursor, nil
+}
+
+// GetUserOrder returns the order of the customer with the reference. References
+// with a check symbol are normalized first, older ones are case sensitive and
+// looked up as given.
+func (o *orderService) GetUserOrder(ctx context.Context, ref string) (response.OrderData, error) {
+	ctx, span := tracing.Start(ctx, "orderService.GetUserOrder")
+	defer span.End()
+
+	if normalized := reference.Normalize(ref); reference.Valid(normalized, customerReferenceLength) {
+		ref = normalized
+	}
+
+	customerID := ctx.Value("id").(uint)
+	order, err := o.orderRepository.GetOrderByReference(ctx, customerID, ref)
+	if err != nil {
+		return response.OrderData{}, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order == nil {
+		return response.OrderData{}, apperror.NotFound("order not found")
+	}
+
+	resp, err := o.toOrderData(ctx, []model.Order{*order})
+	if err != nil {
+		return response.OrderData{}, err
+	}
+
+	return resp[0], nil
+}
+
+// ExportOrders returns every order of the customer with its items, without
+// filters or pages, for the data export.
+func (o *orderService) ExportOrders(ctx context.Context, customerID uint) ([]response.OrderData, error) {
+	ctx, span := tracing.Start(ctx, "orderService.ExportOrders")
+	defer span.End()
+
+	orders, err := o.orderRepository.GetOrderHistoryByCustomerID(ctx, customerID, model.OrderHistoryQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order history: %w", err)
+	}
+
+	return o.toOrderData(ctx, orders)
+}
+
+// toOrderData adds the items and their books to orders, in three queries
+// whatever the number of orders.
+func (o *orderService) toOrderData(ctx context.Context, orders []model.Order) ([]response.OrderData, error) {
 	resp := []response.OrderData{}
+	if len(orders) == 0 {
+		return resp, nil
+	}
 
-	//get all order from customer
-	orders, err := o.orderRepository.GetOrderHistoryByCustomerID(ctx, customerID)
+	orderIDs := make([]uint, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	items, err := o.orderRepository.GetItemsByOrderIDs(ctx, orderIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get order history: %s", err.Error())
+		return nil, fmt.Errorf("failed to get order items: %w", err)
 	}
 
-	//assign order data into response
+	books, err := o.getBooks(ctx, items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get books: %w", err)
+	}
+
+	itemsByOrderID := make(map[uint][]response.Item)
+	for _, item := range items {
+		book := books[item.BookID]
+		itemsByOrderID[item.OrderID] = append(itemsByOrderID[item.OrderID], response.Item{
+			BookID:   item.BookID,
+			Title:    book.Title,
+			Author:   book.Author,
+			Quantity: item.Quantity,
+			Price:    book.Price,
+		})
+	}
+
+	//orders keep the newest first order of the query
 	for _, order := range orders {
-		mapOrderIDToOrderData[order.ID] = response.OrderData{
+		resp = append(resp, response.OrderData{
 			OrderID:           order.ID,
 			CustomerReference: order.CustomerReference,
 			ReceiverName:      order.ReceiverName,
@@ -58,50 +191,62 @@ func (o *orderService) GetUserOrders(ctx context.Context) ([]response.OrderData,
 			PostalCode:        order.PostalCode,
 			Shipper:           order.Shipper,
 			AirwaybillNumber:  order.AirwaybillNumber,
+			Status:            order.Status,
 			OrderDate:         order.OrderDate,
 			TotalPrice:        order.TotalPrice,
 			TotalItem:         order.TotalItem,
-		}
-	}
-
-	//assign order items into response
-	for orderID, order := range mapOrderIDToOrderData {
-		items, err := o.orderRepository.GetItemsByOrderID(ctx, orderID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get order items: %s", err.Error())
-		}
-
-		for _, item := range items {
-			book, err := o.bookRepository.GetBookByID(ctx, item.BookID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get book: %s", err.Error())
-			}
-
-			order.Items = append(order.Items, response.Item{
-				BookID:   item.BookID,
-				Title:    book.Title,
-				Author:   book.Author,
-				Quantity: item.Quantity,
-				Price:    book.Price,
-			})
-		}
-
-		resp = append(resp, order)
+			Items:             itemsByOrderID[order.ID],
+		})
 	}
 
 	return resp, nil
 }
 
-func (o *orderService) CreateOrder(ctx context.Context, req request.CreateOrder) (response.CreateOrderData, error) {
+// CreateOrder places the order of the customer. With an idempotency key, a
+// retry of the same request returns the response of the first one instead of
+// placing another order, and another request with the key is a conflict.
+func (o *orderService) CreateOrder(ctx context.Context, req request.CreateOrder, idempotencyKey string) (response.CreateOrderData, error) {
+	ctx, span := tracing.Start(ctx, "orderService.CreateOrder")
+	defer span.End()
+
 	var totalPrice float64
 	var totalQuantity int
 	customerID := ctx.Value("id").(uint)
-	customerEmail := ctx.Value("email").(string)
 	var order model.Order
 
+	//hashed before the saved address is copied in, a retry sends the same request
+	var requestHash string
+	if idempotencyKey != "" {
+		requestHash = hashRequest(req)
+		data, replayed, err := o.replayOrder(ctx, customerID, idempotencyKey, requestHash)
+		if err != nil || replayed {
+			return data, err
+		}
+	}
+
+	//copy the saved address so the order keeps it even if the address book changes later
+	if req.AddressID != 0 {
+		address, err := o.addressRepository.GetAddressByID(ctx, customerID, req.AddressID)
+		if err != nil {
+			return response.CreateOrderData{}, fmt.Errorf("failed to get address: %w", err)
+		}
+
+		if address == nil {
+			return response.CreateOrderData{}, apperror.NotFound("address not found")
+		}
+
+		if req.ReceiverName == "" {
+			req.ReceiverName = address.ReceiverName
+		}
+		req.Address = address.Address
+		req.City = address.City
+		req.District = address.District
+		req.PostalCode = address.PostalCode
+	}
+
 	tx, err := o.TransactionProvider.NewTransaction(ctx)
 	if err != nil {
-		return response.CreateOrderData{}, fmt.Errorf("failed to start transaction: %s", err.Error())
+		return response.CreateOrderData{}, fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer tx.Rollback()
 
@@ -114,36 +259,55 @@ func (o *orderService) CreateOrder(ctx context.Context, req request.CreateOrder)
 	order.ReceiverName = req.ReceiverName
 	order.CustomerID = customerID
 	order.OrderDate = time.Now().UTC()
-	order.CustomerReference = generateCustomerReference(order.OrderDate)
 	order.Address = req.Address
 	order.City = req.City
 	order.District = req.District
 	order.PostalCode = req.PostalCode
 	order.Shipper = req.Shipper
-	order.AirwaybillNumber = generateAirwaybillNumber(req.Shipper)
 
-	//create order
-	orderID, err := o.orderRepository.CreateOrder(ctx, tx, order)
-	if err != nil {
-		return response.CreateOrderData{}, fmt.Errorf("failed to create order: %s", err.Error())
+	//create order, with other references while they collide with another order
+	var orderID uint
+	for attempt := 1; ; attempt++ {
+		order.CustomerReference, err = reference.New(customerReferenceLength)
+		if err != nil {
+			return response.CreateOrderData{}, fmt.Errorf("failed to generate customer reference: %w", err)
+		}
+
+		order.AirwaybillNumber, err = generateAirwaybillNumber(req.Shipper)
+		if err != nil {
+			return response.CreateOrderData{}, fmt.Errorf("failed to generate airwaybill number: %w", err)
+		}
+
+		orderID, err = o.orderRepository.CreateOrder(ctx, tx, order)
+		if errors.Is(err, repository.ErrDuplicateReference) && attempt < maxReferenceAttempts {
+			continue
+		}
+		if err != nil {
+			return response.CreateOrderData{}, fmt.Errorf("failed to create order: %w", err)
+		}
+
+		break
 	}
 
+	var lines []emailtemplate.OrderItem
 	for _, item := range req.Items {
-		//get book price from inMemory Cache first
-		bookPrice, ok := mapBook[item.BookID]
-		if !ok {
-			book, err := o.bookRepository.GetBookByID(ctx, item.BookID)
-			if err != nil {
-				return response.CreateOrderData{}, fmt.Errorf("failed to get book: %s", err.Error())
-			}
-
-			bookPrice = book.Price
-			mapBook[item.BookID] = bookPrice
+		book, err := o.getBook(ctx, item.BookID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return response.CreateOrderData{}, apperror.NotFound(fmt.Sprintf("book %d not found", item.BookID))
+		}
+		if err != nil {
+			return response.CreateOrderData{}, fmt.Errorf("failed to get book: %w", err)
 		}
 
 		//set total price & quantity
-		totalPrice += bookPrice * float64(item.Quantity)
+		totalPrice += book.Price * float64(item.Quantity)
 		totalQuantity += item.Quantity
+		lines = append(lines, emailtemplate.OrderItem{
+			Title:    book.Title,
+			Author:   book.Author,
+			Quantity: item.Quantity,
+			Price:    book.Price,
+		})
 
 		//create item
 		err = o.orderRepository.CreateItem(ctx, tx, model.Item{
@@ -153,7 +317,7 @@ func (o *orderService) CreateOrder(ctx context.Context, req request.CreateOrder)
 			CreatedAt: time.Now().UTC().Truncate(time.Minute),
 		})
 		if err != nil {
-			return response.CreateOrderData{}, fmt.Errorf("failed to create item: %s", err.Error())
+			return response.CreateOrderData{}, fmt.Errorf("failed to create item: %w", err)
 		}
 	}
 
@@ -165,20 +329,30 @@ func (o *orderService) CreateOrder(ctx context.Context, req request.CreateOrder)
 	//update order
 	err = o.orderRepository.UpdateOrderByOrderID(ctx, tx, order)
 	if err != nil {
-		return response.CreateOrderData{}, fmt.Errorf("failed to update order: %s", err.Error())
+		return response.CreateOrderData{}, fmt.Errorf("failed to update order: %w", err)
 	}
 
-	//send email
-	if config.CONFIG_EMAIL_SERVICE {
-		bodyEmail := fmt.Sprintf(model.OrderBodyEmailTemplate, customerEmail, orderID, totalQuantity, totalPrice, order.CustomerReference, order.OrderDate, order.AirwaybillNumber)
+	//queue the confirmation with the order, a rolled back order sends nothing
+	if o.cfg.Email.Enabled {
+		//the token may carry an email the customer has changed since
+		customer, err := o.customerRepository.GetCustomerByID(ctx, customerID)
+		if err != nil {
+			return response.CreateOrderData{}, fmt.Errorf("failed to get customer: %w", err)
+		}
 
-		payload := notification.EmailPayload{
-			To:      customerEmail,
-			Subject: "Order Confirmation",
-			Body:    bodyEmail,
+		if customer == nil {
+			return response.CreateOrderData{}, apperror.NotFound("customer not found")
 		}
 
-		go o.notificationService.SendNotification(payload)
+		msg, err := o.orderConfirmation(customer.Email, customer.Username, o.cfg.Notification.Locale, order, lines)
+		if err != nil {
+			return response.CreateOrderData{}, err
+		}
+
+		err = o.outboxService.Enqueue(ctx, tx, msg)
+		if err != nil {
+			return response.CreateOrderData{}, err
+		}
 	}
 
 	data := response.CreateOrderData{
@@ -188,30 +362,391 @@ func (o *orderService) CreateOrder(ctx context.Context, req request.CreateOrder)
 		OrderDate:         order.OrderDate.String(),
 	}
 
+	if idempotencyKey != "" {
+		stored, err := o.storeIdempotencyKey(ctx, tx, customerID, idempotencyKey, requestHash, data)
+		if err != nil {
+			return response.CreateOrderData{}, err
+		}
+
+		//a concurrent retry committed its order first, this one is rolled back
+		//with its email and the other one is returned
+		if !stored {
+			tx.Rollback()
+			data, replayed, err := o.replayOrder(ctx, customerID, idempotencyKey, requestHash)
+			if err == nil && !replayed {
+				err = errors.New("failed to store idempotency key")
+			}
+			return data, err
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
-		return response.CreateOrderData{}, fmt.Errorf("failed to commit transaction: %s", err.Error())
+		return response.CreateOrderData{}, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	metrics.OrdersCreated.Inc()
+	metrics.Revenue.Add(totalPrice)
+
 	return data, nil
 }
 
-func generateCustomerReference(orderDate time.Time) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	var seededRand = rand.New(rand.NewSource(orderDate.UnixNano()))
-	result := make([]byte, 8)
-	for i := range result {
-		result[i] = charset[seededRand.Intn(len(charset))]
+// PurgeIdempotencyKeys deletes the expired idempotency keys, they are only
+// replaced when a customer uses the same key again.
+func (o *orderService) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "orderService.PurgeIdempotencyKeys")
+	defer span.End()
+
+	count, err := o.idempotencyRepository.DeleteExpiredIdempotencyKeys(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return count, nil
+}
+
+// ValidateReference tells whether a customer reference or an airwaybill number
+// typed by support is well formed, it does not look the order up. References
+// of orders placed before they had a check symbol are not valid.
+func (o *orderService) ValidateReference(ctx context.Context, value string) response.ReferenceValidation {
+	_, span := tracing.Start(ctx, "orderService.ValidateReference")
+	defer span.End()
+
+	prefix, rest, found := strings.Cut(strings.TrimSpace(value), "-")
+	if found {
+		for _, shipper := range o.cfg.Order.Shippers {
+			if strings.EqualFold(shipper, prefix) {
+				normalized := reference.Normalize(rest)
+				return response.ReferenceValidation{
+					Reference: shipper + "-" + normalized,
+					Type:      response.ReferenceAirwaybillNumber,
+					Valid:     reference.Valid(normalized, airwaybillNumberLength),
+				}
+			}
+		}
+	}
+
+	normalized := reference.Normalize(value)
+	return response.ReferenceValidation{
+		Reference: normalized,
+		Type:      response.ReferenceCustomer,
+		Valid:     reference.Valid(normalized, customerReferenceLength),
+	}
+}
+
+// replayOrder returns the stored response of the key, and whether there was one.
+func (o *orderService) replayOrder(ctx context.Context, customerID uint, key, requestHash string) (response.CreateOrderData, bool, error) {
+	stored, err := o.idempotencyRepository.GetIdempotencyKey(ctx, customerID, key, time.Now().UTC())
+	if err != nil {
+		return response.CreateOrderData{}, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if stored == nil {
+		return response.CreateOrderData{}, false, nil
+	}
+
+	if stored.RequestHash != requestHash {
+		return response.CreateOrderData{}, false, apperror.Conflict("idempotency key was already used for another order")
+	}
+
+	var data response.CreateOrderData
+	err = json.Unmarshal(stored.Response, &data)
+	if err != nil {
+		return response.CreateOrderData{}, false, fmt.Errorf("failed to decode stored order response: %w", err)
+	}
+
+	return data, true, nil
+}
+
+// storeIdempotencyKey keeps the response with the key in the order transaction,
+// and reports false when a live key is already stored.
+func (o *orderService) storeIdempotencyKey(ctx context.Context, tx transactioner.TxxProvider, customerID uint, key, requestHash string, data response.CreateOrderData) (bool, error) {
+	resp, err := json.Marshal(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode order response: %w", err)
+	}
+
+	now := time.Now().UTC()
+	stored, err := o.idempotencyRepository.CreateIdempotencyKey(ctx, tx, model.IdempotencyKey{
+		CustomerID:  customerID,
+		Key:         key,
+		RequestHash: requestHash,
+		Response:    resp,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(o.cfg.Order.IdempotencyTTL),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+
+	return stored, nil
+}
+
+// hashRequest is the SHA-256 of the request as JSON, the same for a retry
+// whatever the field order or spacing of its body.
+func hashRequest(req request.CreateOrder) string {
+	b, _ := json.Marshal(req)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// ResendOrderConfirmation sends the confirmation email of an order again. It
+// waits for the email to be sent so the caller can report a failure.
+func (o *orderService) ResendOrderConfirmation(ctx context.Context, orderID uint) error {
+	ctx, span := tracing.Start(ctx, "orderService.ResendOrderConfirmation")
+	defer span.End()
+
+	if !o.cfg.Email.Enabled {
+		return errors.New("email notification is disabled")
+	}
+
+	msg, err := o.loadOrderConfirmation(ctx, orderID, o.cfg.Notification.Locale)
+	if err != nil {
+		return err
+	}
+
+	err = o.notificationService.SendNotification(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	return nil
+}
+
+// PreviewOrderConfirmation renders the confirmation email of an order without
+// sending it, in locale or the configured one when empty.
+func (o *orderService) PreviewOrderConfirmation(ctx context.Context, orderID uint, locale string) (response.EmailPreview, error) {
+	ctx, span := tracing.Start(ctx, "orderService.PreviewOrderConfirmation")
+	defer span.End()
+
+	if locale == "" {
+		locale = o.cfg.Notification.Locale
+	}
+
+	msg, err := o.loadOrderConfirmation(ctx, orderID, locale)
+	if errors.Is(err, emailtemplate.ErrUnknownLocale) {
+		return response.EmailPreview{}, apperror.Validation(err.Error(), apperror.FieldError{Field: "locale", Message: "has no email templates"})
 	}
-	return string(result)
+	if err != nil {
+		return response.EmailPreview{}, err
+	}
+
+	return response.EmailPreview{
+		To:      msg.To,
+		Subject: msg.Subject,
+		HTML:    msg.Body,
+		Text:    msg.Text,
+	}, nil
 }
 
-func generateAirwaybillNumber(shipper string) string {
-	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	var seededRand = rand.New(rand.NewSource(time.Now().UnixNano()))
-	result := make([]byte, 10)
-	for i := range result {
-		result[i] = charset[seededRand.Intn(len(charset))]
+// loadOrderConfirmation builds the confirmation email of a stored order.
+func (o *orderService) loadOrderConfirmation(ctx context.Context, orderID uint, locale string) (notification.Message, error) {
+	order, err := o.orderRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return notification.Message{}, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order == nil {
+		return notification.Message{}, apperror.NotFound("order not found")
+	}
+
+	customer, err := o.customerRepository.GetCustomerByID(ctx, order.CustomerID)
+	if err != nil {
+		return notification.Message{}, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	if customer == nil {
+		return notification.Message{}, apperror.NotFound("customer not found")
 	}
-	return shipper + "-" + string(result)
+
+	items, err := o.orderRepository.GetItemsByOrderID(ctx, order.ID)
+	if err != nil {
+		return notification.Message{}, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	books, err := o.getBooks(ctx, items)
+	if err != nil {
+		return notification.Message{}, fmt.Errorf("failed to get books: %w", err)
+	}
+
+	lines := make([]emailtemplate.OrderItem, 0, len(items))
+	for _, item := range items {
+		book := books[item.BookID]
+		lines = append(lines, emailtemplate.OrderItem{
+			Title:    book.Title,
+			Author:   book.Author,
+			Quantity: item.Quantity,
+			Price:    book.Price,
+		})
+	}
+
+	return o.orderConfirmation(customer.Email, customer.Username, locale, *order, lines)
+}
+
+func (o *orderService) orderConfirmation(email, name, locale string, order model.Order, items []emailtemplate.OrderItem) (notification.Message, error) {
+	content, err := emailtemplate.Render(emailtemplate.OrderConfirmation, locale, emailtemplate.Order{
+		Name:              name,
+		Currency:          o.cfg.Notification.Currency,
+		OrderID:           order.ID,
+		CustomerReference: order.CustomerReference,
+		OrderDate:         order.OrderDate,
+		ReceiverName:      order.ReceiverName,
+		Address:           order.Address,
+		City:              order.City,
+		District:          order.District,
+		PostalCode:        order.PostalCode,
+		Shipper:           order.Shipper,
+		AirwaybillNumber:  order.AirwaybillNumber,
+		Items:             items,
+		TotalItem:         order.TotalItem,
+		TotalPrice:        order.TotalPrice,
+	})
+	if err != nil {
+		return notification.Message{}, err
+	}
+
+	return notification.Message{
+		Event:   "order.confirmed",
+		To:      email,
+		Subject: content.Subject,
+		Body:    content.HTML,
+		Text:    content.Text,
+		Data: map[string]any{
+			"order_id":           order.ID,
+			"customer_reference": order.CustomerReference,
+			"total_item":         order.TotalItem,
+			"total_price":        order.TotalPrice,
+			"order_date":         order.OrderDate,
+			"shipper":            order.Shipper,
+			"airwaybill_number":  order.AirwaybillNumber,
+		},
+
+		CustomerID: order.CustomerID,
+		Category:   notification.CategoryTransactional,
+	}, nil
+}
+
+// getBook reads the book through the cache shared with the book service, a
+// missing book is sql.ErrNoRows.
+func (o *orderService) getBook(ctx context.Context, id uint) (model.Book, error) {
+	return o.bookCache.GetOrLoad(ctx, strconv.FormatUint(uint64(id), 10), func(ctx context.Context) (model.Book, error) {
+		return o.bookRepository.GetBookByID(ctx, id)
+	})
+}
+
+// getBooks returns the books of items by ID. Books missing from the cache are
+// read in one query, a book that does not exist is sql.ErrNoRows.
+func (o *orderService) getBooks(ctx context.Context, items []model.Item) (map[uint]model.Book, error) {
+	books := make(map[uint]model.Book)
+	var missing []uint
+	for _, item := range items {
+		if _, ok := books[item.BookID]; ok || slices.Contains(missing, item.BookID) {
+			continue
+		}
+
+		book, ok := o.bookCache.Get(ctx, strconv.FormatUint(uint64(item.BookID), 10))
+		if ok {
+			books[item.BookID] = book
+		} else {
+			missing = append(missing, item.BookID)
+		}
+	}
+
+	if len(missing) == 0 {
+		return books, nil
+	}
+
+	loaded, err := o.bookRepository.GetBooksByIDs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, book := range loaded {
+		books[book.ID] = book
+		o.bookCache.Set(ctx, strconv.FormatUint(uint64(book.ID), 10), book)
+	}
+
+	for _, id := range missing {
+		if _, ok := books[id]; !ok {
+			return nil, fmt.Errorf("book %d: %w", id, sql.ErrNoRows)
+		}
+	}
+
+	return books, nil
+}
+
+// orderHistoryQuery converts the filters of the request, the dates are whole
+// days in UTC.
+func orderHistoryQuery(req request.GetOrderHistory) (model.OrderHistoryQuery, error) {
+	q := model.OrderHistoryQuery{
+		Status: req.Status,
+		Search: strings.TrimSpace(req.Search),
+	}
+
+	if req.Cursor != "" {
+		var err error
+		q.AfterDate, q.AfterID, err = decodeCursor(req.Cursor)
+		if err != nil {
+			return q, apperror.Validation("invalid cursor", apperror.FieldError{Field: "cursor", Message: "must be the next_cursor of a previous page"})
+		}
+	}
+
+	if req.From != "" {
+		from, err := time.Parse(time.DateOnly, req.From)
+		if err != nil {
+			return q, apperror.Validation("invalid from date", apperror.FieldError{Field: "from", Message: "must be a date like 2006-01-02"})
+		}
+		q.From = from
+	}
+
+	if req.To != "" {
+		to, err := time.Parse(time.DateOnly, req.To)
+		if err != nil {
+			return q, apperror.Validation("invalid to date", apperror.FieldError{Field: "to", Message: "must be a date like 2006-01-02"})
+		}
+		q.To = to.AddDate(0, 0, 1)
+	}
+
+	return q, nil
+}
+
+// encodeCursor points after the order, the clients pass it back as is.
+func encodeCursor(order model.Order) string {
+	cursor := order.OrderDate.Format(time.RFC3339Nano) + "|" + strconv.FormatUint(uint64(order.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+func decodeCursor(cursor string) (time.Time, uint, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	date, id, ok := strings.Cut(string(decoded), "|")
+	if !ok {
+		return time.Time{}, 0, errors.New("missing order id")
+	}
+
+	orderDate, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	orderID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || orderID == 0 {
+		return time.Time{}, 0, errors.New("invalid order id")
+	}
+
+	return orderDate, uint(orderID), nil
+}
+
+// generateAirwaybillNumber prefixes a reference with the shipper code, e.g.
+// JNE-7K3QW9ZD2M4PX.
+func generateAirwaybillNumber(shipper string) (string, error) {
+	ref, err := reference.New(airwaybillNumberLength)
+	if err != nil {
+		return "", err
+	}
+
+	return shipper + "-" + ref, nil
 }
@@ -2,13 +2,25 @@ package order_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"ebookstore/internal/apperror"
 	"ebookstore/internal/model"
 	"ebookstore/internal/model/request"
 	"ebookstore/internal/model/response"
+	"ebookstore/internal/repository"
 	"ebookstore/internal/repository/mocks"
+	"ebookstore/internal/service"
 	mocksService "ebookstore/internal/service/mocks"
 	"ebookstore/internal/service/order"
+	"ebookstore/utils/cache"
+	"ebookstore/utils/config"
+	"ebookstore/utils/notification"
+	"ebookstore/utils/reference"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,43 +29,83 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// testConfig keeps email enabled so the notification paths are exercised.
+var testConfig = func() *config.Config {
+	cfg := config.Default()
+	cfg.Email.Enabled = true
+	return &cfg
+}()
+
+// currentCustomer returns the customer row, whose email differs from the
+// one in the token of the tests.
+func currentCustomer() *mocks.ICustomerRepository {
+	m := mocks.ICustomerRepository{}
+	m.On("GetCustomerByID", mock.Anything, uint(1)).Return(&model.Customer{ID: 1, Email: "current@mail.com", Username: "username"}, nil)
+	return &m
+}
+
 func Test_orderService_GetUserOrders(t *testing.T) {
 	ctx := context.Background()
 	id := uint(1)
 	ctx = context.WithValue(ctx, "id", id)
+	orderDate := time.Now().UTC().Truncate(time.Minute)
 
-	o := model.Order{
-		ID:                1,
-		CustomerID:        1,
-		CustomerReference: "customerReference",
-		ReceiverName:      "username",
-		OrderDate:         time.Now().UTC().Truncate(time.Minute),
-		Shipper:           "shipper",
-		AirwaybillNumber:  "AWBnumber",
-		TotalItem:         1,
-		TotalPrice:        1,
-		Address:           "address",
-		City:              "city",
-		District:          "district",
-		PostalCode:        "postalCode",
+	//newest first, as the repository sorts them
+	orders := []model.Order{
+		{
+			ID:                2,
+			CustomerID:        1,
+			CustomerReference: "newer",
+			ReceiverName:      "username",
+			OrderDate:         orderDate,
+			Shipper:           "shipper",
+			AirwaybillNumber:  "AWBnumber2",
+			TotalItem:         3,
+			TotalPrice:        40,
+		},
+		{
+			ID:                1,
+			CustomerID:        1,
+			CustomerReference: "customerReference",
+			ReceiverName:      "username",
+			OrderDate:         orderDate.Add(-time.Hour),
+			Shipper:           "shipper",
+			AirwaybillNumber:  "AWBnumber",
+			TotalItem:         1,
+			TotalPrice:        10,
+			Address:           "address",
+			City:              "city",
+			District:          "district",
+			PostalCode:        "postalCode",
+		},
 	}
 
-	item := model.Item{
-		ID:       1,
-		OrderID:  1,
-		BookID:   1,
-		Quantity: 1,
+	items := []model.Item{
+		{ID: 1, OrderID: 1, BookID: 1, Quantity: 1},
+		{ID: 2, OrderID: 2, BookID: 1, Quantity: 2},
+		{ID: 3, OrderID: 2, BookID: 2, Quantity: 1},
 	}
 
-	book := model.Book{
-		ID:         1,
-		Title:      "title",
-		Author:     "author",
-		Price:      10,
-		CategoryID: 1,
+	books := []model.Book{
+		{ID: 1, Title: "title", Author: "author", Price: 10, CategoryID: 1},
+		{ID: 2, Title: "title 2", Author: "author 2", Price: 20, CategoryID: 1},
 	}
 
-	orderData := []response.OrderData{
+	want := []response.OrderData{
+		{
+			OrderID:           2,
+			CustomerReference: "newer",
+			ReceiverName:      "username",
+			Shipper:           "shipper",
+			AirwaybillNumber:  "AWBnumber2",
+			OrderDate:         orderDate,
+			TotalPrice:        40,
+			TotalItem:         3,
+			Items: []response.Item{
+				{BookID: 1, Title: "title", Author: "author", Quantity: 2, Price: 10},
+				{BookID: 2, Title: "title 2", Author: "author 2", Quantity: 1, Price: 20},
+			},
+		},
 		{
 			OrderID:           1,
 			CustomerReference: "customerReference",
@@ -64,107 +116,146 @@ func Test_orderService_GetUserOrders(t *testing.T) {
 			PostalCode:        "postalCode",
 			Shipper:           "shipper",
 			AirwaybillNumber:  "AWBnumber",
-			OrderDate:         time.Now().UTC().Truncate(time.Minute),
-			TotalPrice:        1,
+			OrderDate:         orderDate.Add(-time.Hour),
+			TotalPrice:        10,
 			TotalItem:         1,
+			Items: []response.Item{
+				{BookID: 1, Title: "title", Author: "author", Quantity: 1, Price: 10},
+			},
 		},
 	}
 
 	type fields struct {
-		orderRepository     mocks.IOrderRepository
-		TransactionProvider mocks.ITransactionProvider
-		bookRepository      mocks.IBookRepository
-		notificationService mocksService.INotificationService
+		orderRepository *mocks.IOrderRepository
+		bookRepository  *mocks.IBookRepository
+		bookCache       cache.ICache[model.Book]
 	}
 
-	type args struct {
-		ctx context.Context
-	}
 	tests := []struct {
 		name    string
 		fields  fields
-		args    args
 		want    []response.OrderData
 		wantErr bool
 	}{
 		{
 			name: "best case",
 			fields: fields{
-				orderRepository: func() mocks.IOrderRepository {
+				orderRepository: func() *mocks.IOrderRepository {
 					m := mocks.IOrderRepository{}
-					m.On("GetOrderHistoryByCustomerID", mock.Anything, id).Return([]model.Order{o}, nil)
-					m.On("GetItemsByOrderID", mock.Anything, o.ID).Return([]model.Item{item}, nil)
-					orderData[0].Items = []response.Item{
-						{
-							BookID:   book.ID,
-							Title:    book.Title,
-							Author:   book.Author,
-							Quantity: item.Quantity,
-							Price:    book.Price,
-						},
-					}
-					return m
-				}(),
-				bookRepository: func() mocks.IBookRepository {
+					m.On("GetOrderHistoryByCustomerID", mock.Anything, id, model.OrderHistoryQuery{Limit: 21}).Return(orders, nil).Once()
+					m.On("GetItemsByOrderIDs", mock.Anything, []uint{2, 1}).Return(items, nil).Once()
+					return &m
+				}(),
+				bookRepository: func() *mocks.IBookRepository {
 					m := mocks.IBookRepository{}
-					m.On("GetBookByID", mock.Anything, item.BookID).Return(book, nil)
-					return m
+					m.On("GetBooksByIDs", mock.Anything, []uint{1, 2}).Return(books, nil).Once()
+					return &m
 				}(),
+				bookCache: newBookCache(),
 			},
-			args: args{
-				ctx: ctx,
+			want:    want,
+			wantErr: false,
+		},
+		{
+			name: "cached books are not read again",
+			fields: fields{
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("GetOrderHistoryByCustomerID", mock.Anything, id, model.OrderHistoryQuery{Limit: 21}).Return(orders, nil).Once()
+					m.On("GetItemsByOrderIDs", mock.Anything, []uint{2, 1}).Return(items, nil).Once()
+					return &m
+				}(),
+				bookRepository: func() *mocks.IBookRepository {
+					m := mocks.IBookRepository{}
+					m.On("GetBooksByIDs", mock.Anything, []uint{2}).Return(books[1:], nil).Once()
+					return &m
+				}(),
+				bookCache: func() cache.ICache[model.Book] {
+					c := newBookCache()
+					c.Set(context.Background(), "1", books[0])
+					return c
+				}(),
 			},
-			want:    orderData,
+			want:    want,
 			wantErr: false,
 		},
 		{
-			name: "GetOrderHistoryByCustomerID error",
+			name: "no orders",
 			fields: fields{
-				orderRepository: func() mocks.IOrderRepository {
+				orderRepository: func() *mocks.IOrderRepository {
 					m := mocks.IOrderRepository{}
-					m.On("GetOrderHistoryByCustomerID", mock.Anything, id).Return([]model.Order{}, errors.New("error"))
-					return m
+					m.On("GetOrderHistoryByCustomerID", mock.Anything, id, model.OrderHistoryQuery{Limit: 21}).Return(nil, nil)
+					return &m
 				}(),
+				bookRepository: &mocks.IBookRepository{},
+				bookCache:      newBookCache(),
 			},
-			args: args{
-				ctx: ctx,
+			want:    []response.OrderData{},
+			wantErr: false,
+		},
+		{
+			name: "GetOrderHistoryByCustomerID error",
+			fields: fields{
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("GetOrderHistoryByCustomerID", mock.Anything, id, model.OrderHistoryQuery{Limit: 21}).Return([]model.Order{}, errors.New("error"))
+					return &m
+				}(),
+				bookRepository: &mocks.IBookRepository{},
+				bookCache:      newBookCache(),
 			},
 			want:    nil,
 			wantErr: true,
 		},
 		{
-			name: "GetItemsByOrderID error",
+			name: "GetItemsByOrderIDs error",
 			fields: fields{
-				orderRepository: func() mocks.IOrderRepository {
+				orderRepository: func() *mocks.IOrderRepository {
 					m := mocks.IOrderRepository{}
-					m.On("GetOrderHistoryByCustomerID", mock.Anything, id).Return([]model.Order{o}, nil)
-					m.On("GetItemsByOrderID", mock.Anything, o.ID).Return([]model.Item{}, errors.New("error"))
-					return m
+					m.On("GetOrderHistoryByCustomerID", mock.Anything, id, model.OrderHistoryQuery{Limit: 21}).Return(orders, nil)
+					m.On("GetItemsByOrderIDs", mock.Anything, []uint{2, 1}).Return(nil, errors.New("error"))
+					return &m
 				}(),
-			},
-			args: args{
-				ctx: ctx,
+				bookRepository: &mocks.IBookRepository{},
+				bookCache:      newBookCache(),
 			},
 			want:    nil,
 			wantErr: true,
 		},
 		{
-			name: "GetBookByID error",
+			name: "GetBooksByIDs error",
 			fields: fields{
-				orderRepository: func() mocks.IOrderRepository {
+				orderRepository: func() *mocks.IOrderRepository {
 					m := mocks.IOrderRepository{}
-					m.On("GetOrderHistoryByCustomerID", mock.Anything, id).Return([]model.Order{o}, nil)
-					m.On("GetItemsByOrderID", mock.Anything, o.ID).Return([]model.Item{item}, nil)
-					return m
+					m.On("GetOrderHistoryByCustomerID", mock.Anything, id, model.OrderHistoryQuery{Limit: 21}).Return(orders, nil)
+					m.On("GetItemsByOrderIDs", mock.Anything, []uint{2, 1}).Return(items, nil)
+					return &m
 				}(),
-				bookRepository: func() mocks.IBookRepository {
+				bookRepository: func() *mocks.IBookRepository {
 					m := mocks.IBookRepository{}
-					m.On("GetBookByID", mock.Anything, item.BookID).Return(model.Book{}, errors.New("error"))
-					return m
+					m.On("GetBooksByIDs", mock.Anything, []uint{1, 2}).Return(nil, errors.New("error"))
+					return &m
 				}(),
+				bookCache: newBookCache(),
 			},
-			args: args{
-				ctx: ctx,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "book not found",
+			fields: fields{
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("GetOrderHistoryByCustomerID", mock.Anything, id, model.OrderHistoryQuery{Limit: 21}).Return(orders, nil)
+					m.On("GetItemsByOrderIDs", mock.Anything, []uint{2, 1}).Return(items, nil)
+					return &m
+				}(),
+				bookRepository: func() *mocks.IBookRepository {
+					m := mocks.IBookRepository{}
+					m.On("GetBooksByIDs", mock.Anything, []uint{1, 2}).Return(books[:1], nil)
+					return &m
+				}(),
+				bookCache: newBookCache(),
 			},
 			want:    nil,
 			wantErr: true,
@@ -172,10 +263,207 @@ func Test_orderService_GetUserOrders(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			o := order.NewOrderService(&tt.fields.orderRepository, &tt.fields.bookRepository, &tt.fields.TransactionProvider, &tt.fields.notificationService)
-			got, err := o.GetUserOrders(tt.args.ctx)
+			o := order.NewOrderService(tt.fields.orderRepository, &mocks.IAddressRepository{}, &mocks.ICustomerRepository{}, tt.fields.bookRepository, &mocks.IIdempotencyRepository{}, tt.fields.bookCache, &mocks.ITransactionProvider{}, &mocksService.INotificationService{}, &mocksService.IOutboxService{}, testConfig)
+			got, nextCursor, err := o.GetUserOrders(ctx, request.GetOrderHistory{Limit: 20})
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.want, got)
+			assert.Empty(t, nextCursor)
+			tt.fields.orderRepository.AssertExpectations(t)
+			tt.fields.bookRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_orderService_GetUserOrders_Pages(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	orderDate := time.Date(2024, 5, 1, 10, 30, 0, 123456000, time.UTC)
+	orders := []model.Order{
+		{ID: 3, CustomerID: 1, CustomerReference: "third", Status: model.OrderPlaced, OrderDate: orderDate},
+		{ID: 2, CustomerID: 1, CustomerReference: "second", Status: model.OrderShipped, OrderDate: orderDate.Add(-time.Hour)},
+	}
+
+	newService := func(orderRepository *mocks.IOrderRepository) service.IOrderService {
+		return order.NewOrderService(orderRepository, &mocks.IAddressRepository{}, &mocks.ICustomerRepository{}, &mocks.IBookRepository{}, &mocks.IIdempotencyRepository{}, newBookCache(), &mocks.ITransactionProvider{}, &mocksService.INotificationService{}, &mocksService.IOutboxService{}, testConfig)
+	}
+
+	t.Run("the cursor requests the next page", func(t *testing.T) {
+		m := &mocks.IOrderRepository{}
+		m.On("GetOrderHistoryByCustomerID", mock.Anything, uint(1), model.OrderHistoryQuery{Limit: 2}).Return(orders, nil).Once()
+		m.On("GetItemsByOrderIDs", mock.Anything, []uint{3}).Return([]model.Item{}, nil).Once()
+		m.On("GetOrderHistoryByCustomerID", mock.Anything, uint(1), model.OrderHistoryQuery{AfterDate: orderDate, AfterID: 3, Limit: 2}).Return(orders[1:], nil).Once()
+		m.On("GetItemsByOrderIDs", mock.Anything, []uint{2}).Return([]model.Item{}, nil).Once()
+		o := newService(m)
+
+		got, nextCursor, err := o.GetUserOrders(ctx, request.GetOrderHistory{Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "third", got[0].CustomerReference)
+		assert.NotEmpty(t, nextCursor)
+
+		got, nextCursor, err = o.GetUserOrders(ctx, request.GetOrderHistory{Cursor: nextCursor, Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "second", got[0].CustomerReference)
+		assert.Equal(t, model.OrderShipped, got[0].Status)
+		assert.Empty(t, nextCursor, "the last page has no cursor")
+		m.AssertExpectations(t)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		m := &mocks.IOrderRepository{}
+		m.On("GetOrderHistoryByCustomerID", mock.Anything, uint(1), model.OrderHistoryQuery{
+			From:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			To:     time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			Status: model.OrderShipped,
+			Search: "hobbit",
+			Limit:  11,
+		}).Return(nil, nil).Once()
+		o := newService(m)
+
+		got, nextCursor, err := o.GetUserOrders(ctx, request.GetOrderHistory{
+			From:   "2024-04-01",
+			To:     "2024-04-30",
+			Status: model.OrderShipped,
+			Search: " hobbit ",
+			Limit:  10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []response.OrderData{}, got)
+		assert.Empty(t, nextCursor)
+		m.AssertExpectations(t)
+	})
+
+	for _, tt := range []struct {
+		name  string
+		req   request.GetOrderHistory
+		field string
+	}{
+		{name: "invalid cursor", req: request.GetOrderHistory{Cursor: "not a cursor", Limit: 10}, field: "cursor"},
+		{name: "cursor without order id", req: request.GetOrderHistory{Cursor: "MjAyNC0wNS0wMVQxMDozMDowMFo", Limit: 10}, field: "cursor"},
+		{name: "invalid from date", req: request.GetOrderHistory{From: "2024-02-30", Limit: 10}, field: "from"},
+		{name: "invalid to date", req: request.GetOrderHistory{To: "2024-13-01", Limit: 10}, field: "to"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mocks.IOrderRepository{}
+			_, _, err := newService(m).GetUserOrders(ctx, tt.req)
+
+			assert.ErrorIs(t, err, apperror.ErrValidation)
+			var appErr *apperror.Error
+			if assert.ErrorAs(t, err, &appErr) {
+				assert.Equal(t, tt.field, appErr.Fields[0].Field)
+			}
+			m.AssertNotCalled(t, "GetOrderHistoryByCustomerID", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func Test_orderService_ExportOrders(t *testing.T) {
+	orders := []model.Order{{ID: 2, CustomerID: 1}, {ID: 1, CustomerID: 1}}
+
+	//items of every order and their books are read in one query each
+	orderRepository := mocks.IOrderRepository{}
+	orderRepository.On("GetOrderHistoryByCustomerID", mock.Anything, uint(1), model.OrderHistoryQuery{}).Return(orders, nil)
+	orderRepository.On("GetItemsByOrderIDs", mock.Anything, []uint{2, 1}).Return([]model.Item{
+		{OrderID: 1, BookID: 1, Quantity: 1},
+		{OrderID: 2, BookID: 2, Quantity: 3},
+	}, nil).Once()
+	bookRepository := mocks.IBookRepository{}
+	bookRepository.On("GetBooksByIDs", mock.Anything, []uint{1, 2}).Return([]model.Book{
+		{ID: 1, Title: "title", Price: 10},
+		{ID: 2, Title: "title 2", Price: 20},
+	}, nil).Once()
+
+	o := order.NewOrderService(&orderRepository, &mocks.IAddressRepository{}, &mocks.ICustomerRepository{}, &bookRepository, &mocks.IIdempotencyRepository{}, newBookCache(), &mocks.ITransactionProvider{}, &mocksService.INotificationService{}, &mocksService.IOutboxService{}, testConfig)
+	got, err := o.ExportOrders(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, []response.Item{{BookID: 2, Title: "title 2", Quantity: 3, Price: 20}}, got[0].Items)
+	assert.Equal(t, []response.Item{{BookID: 1, Title: "title", Quantity: 1, Price: 10}}, got[1].Items)
+	orderRepository.AssertExpectations(t)
+	bookRepository.AssertExpectations(t)
+}
+
+func Test_orderService_GetUserOrder(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	orderDate := time.Now().UTC().Truncate(time.Minute)
+	found := &model.Order{ID: 5, CustomerID: 1, CustomerReference: "Ab12Cd34", Status: model.OrderPlaced, OrderDate: orderDate, TotalItem: 2, TotalPrice: 20}
+
+	tests := []struct {
+		name            string
+		orderRepository *mocks.IOrderRepository
+		want            response.OrderData
+		wantErr         error
+	}{
+		{
+			name: "best case",
+			orderRepository: func() *mocks.IOrderRepository {
+				m := mocks.IOrderRepository{}
+				m.On("GetOrderByReference", mock.Anything, uint(1), "Ab12Cd34").Return(found, nil)
+				m.On("GetItemsByOrderIDs", mock.Anything, []uint{5}).Return([]model.Item{{ID: 1, OrderID: 5, BookID: 1, Quantity: 2}}, nil)
+				return &m
+			}(),
+			want: response.OrderData{
+				OrderID:           5,
+				CustomerReference: "Ab12Cd34",
+				Status:            model.OrderPlaced,
+				OrderDate:         orderDate,
+				TotalItem:         2,
+				TotalPrice:        20,
+				Items:             []response.Item{{BookID: 1, Title: "title", Author: "author", Quantity: 2, Price: 10}},
+			},
+		},
+		{
+			name: "not found",
+			orderRepository: func() *mocks.IOrderRepository {
+				m := mocks.IOrderRepository{}
+				m.On("GetOrderByReference", mock.Anything, uint(1), "Ab12Cd34").Return(nil, nil)
+				return &m
+			}(),
+			wantErr: apperror.NotFound("order not found"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookCache := newBookCache()
+			bookCache.Set(ctx, "1", model.Book{ID: 1, Title: "title", Author: "author", Price: 10})
+			o := order.NewOrderService(tt.orderRepository, &mocks.IAddressRepository{}, &mocks.ICustomerRepository{}, &mocks.IBookRepository{}, &mocks.IIdempotencyRepository{}, bookCache, &mocks.ITransactionProvider{}, &mocksService.INotificationService{}, &mocksService.IOutboxService{}, testConfig)
+
+			got, err := o.GetUserOrder(ctx, "Ab12Cd34")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("GetOrderByReference error", func(t *testing.T) {
+		m := &mocks.IOrderRepository{}
+		m.On("GetOrderByReference", mock.Anything, uint(1), "Ab12Cd34").Return(nil, errors.New("error"))
+		o := order.NewOrderService(m, &mocks.IAddressRepository{}, &mocks.ICustomerRepository{}, &mocks.IBookRepository{}, &mocks.IIdempotencyRepository{}, newBookCache(), &mocks.ITransactionProvider{}, &mocksService.INotificationService{}, &mocksService.IOutboxService{}, testConfig)
+
+		_, err := o.GetUserOrder(ctx, "Ab12Cd34")
+		assert.Error(t, err)
+	})
+
+	checked, _ := reference.New(10)
+	lookups := []struct {
+		name  string
+		typed string
+		want  string
+	}{
+		{name: "reference with check symbol", typed: checked, want: checked},
+		{name: "reference typed in lower case with a dash", typed: strings.ToLower(checked[:5] + "-" + checked[5:]), want: checked},
+		{name: "legacy reference", typed: "Ab12Cd34", want: "Ab12Cd34"},
+		{name: "legacy reference in lower case", typed: "ab12cd34", want: "ab12cd34"},
+	}
+	for _, tt := range lookups {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mocks.IOrderRepository{}
+			m.On("GetOrderByReference", mock.Anything, uint(1), tt.want).Return(nil, nil)
+			o := order.NewOrderService(m, &mocks.IAddressRepository{}, &mocks.ICustomerRepository{}, &mocks.IBookRepository{}, &mocks.IIdempotencyRepository{}, newBookCache(), &mocks.ITransactionProvider{}, &mocksService.INotificationService{}, &mocksService.IOutboxService{}, testConfig)
+
+			_, err := o.GetUserOrder(ctx, tt.typed)
+			assert.Equal(t, apperror.NotFound("order not found"), err)
+			m.AssertExpectations(t)
 		})
 	}
 }
@@ -196,6 +484,22 @@ func Test_orderService_CreateOrder(t *testing.T) {
 		ReceiverName: "username",
 	}
 
+	reqWithAddress := request.CreateOrder{
+		Items:     req.Items,
+		AddressID: 2,
+		Shipper:   "shipper",
+	}
+
+	address := model.Address{
+		ID:           2,
+		CustomerID:   1,
+		ReceiverName: "receiver",
+		Address:      "saved address",
+		City:         "saved city",
+		District:     "saved district",
+		PostalCode:   "12345",
+	}
+
 	o := model.Order{
 		ID:                1,
 		CustomerID:        1,
@@ -231,10 +535,11 @@ func Test_orderService_CreateOrder(t *testing.T) {
 	ctx = context.WithValue(ctx, "email", "mail@mail.com")
 
 	type fields struct {
-		orderRepository     mocks.IOrderRepository
-		TransactionProvider mocks.ITransactionProvider
-		bookRepository      mocks.IBookRepository
-		notificationService mocksService.INotificationService
+		orderRepository     *mocks.IOrderRepository
+		addressRepository   *mocks.IAddressRepository
+		TransactionProvider *mocks.ITransactionProvider
+		bookRepository      *mocks.IBookRepository
+		outboxService       *mocksService.IOutboxService
 	}
 
 	type args struct {
@@ -243,24 +548,25 @@ func Test_orderService_CreateOrder(t *testing.T) {
 	}
 
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    response.CreateOrderData
-		wantErr bool
+		name     string
+		fields   fields
+		args     args
+		want     response.CreateOrderData
+		wantErr  bool
+		wantKind error
 	}{
 		{
 			name: "best case",
 			fields: fields{
-				TransactionProvider: func() mocks.ITransactionProvider {
+				TransactionProvider: func() *mocks.ITransactionProvider {
 					m := mocks.ITransactionProvider{}
 					txProvide := mocks.TxxProvider{}
 					txProvide.On("Commit").Return(nil)
 					txProvide.On("Rollback").Return(nil)
 					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
-					return m
+					return &m
 				}(),
-				orderRepository: func() mocks.IOrderRepository {
+				orderRepository: func() *mocks.IOrderRepository {
 					m := mocks.IOrderRepository{}
 					m.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(o.ID, nil)
 					o.ID = 1
@@ -269,17 +575,19 @@ func Test_orderService_CreateOrder(t *testing.T) {
 					o.TotalPrice = 1
 					o.UpdatedAt = pq.NullTime{Time: time.Now().UTC().Truncate(time.Minute), Valid: true}
 					m.On("UpdateOrderByOrderID", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-					return m
+					return &m
 				}(),
-				bookRepository: func() mocks.IBookRepository {
+				bookRepository: func() *mocks.IBookRepository {
 					m := mocks.IBookRepository{}
 					m.On("GetBookByID", mock.Anything, item.BookID).Return(book, nil)
-					return m
+					return &m
 				}(),
-				notificationService: func() mocksService.INotificationService {
-					m := mocksService.INotificationService{}
-					m.On("SendNotification", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-					return m
+				outboxService: func() *mocksService.IOutboxService {
+					m := mocksService.IOutboxService{}
+					m.On("Enqueue", mock.Anything, mock.Anything, mock.MatchedBy(func(p notification.Message) bool {
+						return p.To == "current@mail.com" && strings.HasPrefix(p.Subject, "Order Confirmation") && strings.Contains(p.Text, "title by author")
+					})).Return(nil)
+					return &m
 				}(),
 			},
 			args: args{
@@ -297,10 +605,10 @@ func Test_orderService_CreateOrder(t *testing.T) {
 		{
 			name: "NewTransaction error",
 			fields: fields{
-				TransactionProvider: func() mocks.ITransactionProvider {
+				TransactionProvider: func() *mocks.ITransactionProvider {
 					m := mocks.ITransactionProvider{}
 					m.On("NewTransaction", mock.Anything).Return(nil, errors.New("error"))
-					return m
+					return &m
 				}(),
 			},
 			args: args{
@@ -313,18 +621,150 @@ func Test_orderService_CreateOrder(t *testing.T) {
 		{
 			name: "CreateOrder error",
 			fields: fields{
-				TransactionProvider: func() mocks.ITransactionProvider {
+				TransactionProvider: func() *mocks.ITransactionProvider {
 					m := mocks.ITransactionProvider{}
 					txProvide := mocks.TxxProvider{}
 					txProvide.On("Commit").Return(nil)
 					txProvide.On("Rollback").Return(nil)
 					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
-					return m
+					return &m
 				}(),
-				orderRepository: func() mocks.IOrderRepository {
+				orderRepository: func() *mocks.IOrderRepository {
 					m := mocks.IOrderRepository{}
 					m.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(uint(0), errors.New("error"))
-					return m
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: ctx,
+				req: req,
+			},
+			want:    response.CreateOrderData{},
+			wantErr: true,
+		},
+		{
+			name: "saved address",
+			fields: fields{
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressByID", mock.Anything, id, address.ID).Return(&address, nil)
+					return &m
+				}(),
+				TransactionProvider: func() *mocks.ITransactionProvider {
+					m := mocks.ITransactionProvider{}
+					txProvide := mocks.TxxProvider{}
+					txProvide.On("Commit").Return(nil)
+					txProvide.On("Rollback").Return(nil)
+					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+					return &m
+				}(),
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("CreateOrder", mock.Anything, mock.Anything, mock.MatchedBy(func(o model.Order) bool {
+						return o.ReceiverName == address.ReceiverName && o.Address == address.Address && o.City == address.City &&
+							o.District == address.District && o.PostalCode == address.PostalCode
+					})).Return(uint(1), nil)
+					m.On("CreateItem", mock.Anything, mock.Anything, item).Return(nil)
+					m.On("UpdateOrderByOrderID", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+					return &m
+				}(),
+				bookRepository: func() *mocks.IBookRepository {
+					m := mocks.IBookRepository{}
+					m.On("GetBookByID", mock.Anything, item.BookID).Return(book, nil)
+					return &m
+				}(),
+				outboxService: func() *mocksService.IOutboxService {
+					m := mocksService.IOutboxService{}
+					m.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: ctx,
+				req: reqWithAddress,
+			},
+			want: response.CreateOrderData{
+				AirwaybillNumber: "shipper",
+			},
+			wantErr: false,
+		},
+		{
+			name: "address not found",
+			fields: fields{
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressByID", mock.Anything, id, address.ID).Return(nil, nil)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: ctx,
+				req: reqWithAddress,
+			},
+			want:     response.CreateOrderData{},
+			wantErr:  true,
+			wantKind: apperror.ErrNotFound,
+		},
+		{
+			name: "book not found",
+			fields: fields{
+				TransactionProvider: func() *mocks.ITransactionProvider {
+					m := mocks.ITransactionProvider{}
+					txProvide := mocks.TxxProvider{}
+					txProvide.On("Rollback").Return(nil)
+					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+					return &m
+				}(),
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(uint(1), nil)
+					return &m
+				}(),
+				bookRepository: func() *mocks.IBookRepository {
+					m := mocks.IBookRepository{}
+					m.On("GetBookByID", mock.Anything, uint(404)).Return(model.Book{}, sql.ErrNoRows)
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: ctx,
+				req: request.CreateOrder{
+					Items:        []request.Item{{BookID: 404, Quantity: 1}},
+					Address:      "address",
+					Shipper:      "shipper",
+					ReceiverName: "username",
+				},
+			},
+			want:     response.CreateOrderData{},
+			wantErr:  true,
+			wantKind: apperror.ErrNotFound,
+		},
+		{
+			name: "Enqueue error rolls the order back",
+			fields: fields{
+				TransactionProvider: func() *mocks.ITransactionProvider {
+					m := mocks.ITransactionProvider{}
+					txProvide := mocks.TxxProvider{}
+					txProvide.On("Rollback").Return(nil)
+					m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+					return &m
+				}(),
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(uint(1), nil)
+					m.On("CreateItem", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+					m.On("UpdateOrderByOrderID", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+					return &m
+				}(),
+				bookRepository: func() *mocks.IBookRepository {
+					m := mocks.IBookRepository{}
+					m.On("GetBookByID", mock.Anything, item.BookID).Return(book, nil)
+					return &m
+				}(),
+				outboxService: func() *mocksService.IOutboxService {
+					m := mocksService.IOutboxService{}
+					m.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("error"))
+					return &m
 				}(),
 			},
 			args: args{
@@ -334,16 +774,517 @@ func Test_orderService_CreateOrder(t *testing.T) {
 			want:    response.CreateOrderData{},
 			wantErr: true,
 		},
+		{
+			name: "GetAddressByID error",
+			fields: fields{
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressByID", mock.Anything, id, address.ID).Return(nil, errors.New("error"))
+					return &m
+				}(),
+			},
+			args: args{
+				ctx: ctx,
+				req: reqWithAddress,
+			},
+			want:    response.CreateOrderData{},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			o := order.NewOrderService(&tt.fields.orderRepository, &tt.fields.bookRepository, &tt.fields.TransactionProvider, &tt.fields.notificationService)
-			got, err := o.CreateOrder(tt.args.ctx, tt.args.req)
+			o := order.NewOrderService(tt.fields.orderRepository, tt.fields.addressRepository, currentCustomer(), tt.fields.bookRepository, &mocks.IIdempotencyRepository{}, newBookCache(), tt.fields.TransactionProvider, &mocksService.INotificationService{}, tt.fields.outboxService, testConfig)
+			got, err := o.CreateOrder(tt.args.ctx, tt.args.req, "")
 			if err != nil {
 				println(err.Error())
 			}
 			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantKind != nil {
+				assert.ErrorIs(t, err, tt.wantKind)
+			}
 			assert.Contains(t, got.AirwaybillNumber, tt.want.AirwaybillNumber)
 		})
 	}
 }
+
+func Test_orderService_CreateOrder_IdempotencyKey(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	ctx = context.WithValue(ctx, "email", "mail@mail.com")
+	ctx = context.WithValue(ctx, "username", "username")
+
+	req := request.CreateOrder{
+		Items:        []request.Item{{BookID: 1, Quantity: 2}},
+		Address:      "address",
+		City:         "city",
+		District:     "district",
+		PostalCode:   "postalCode",
+		Shipper:      "shipper",
+		ReceiverName: "username",
+	}
+	body, _ := json.Marshal(req)
+	sum := sha256.Sum256(body)
+	requestHash := hex.EncodeToString(sum[:])
+
+	first := response.CreateOrderData{OrderID: 7, CustomerReference: "Ab12Cd34", AirwaybillNumber: "shipper-FIRST", OrderDate: "2026-10-19"}
+	firstResponse, _ := json.Marshal(first)
+	stored := &model.IdempotencyKey{CustomerID: 1, Key: "retry-1", RequestHash: requestHash, Response: firstResponse}
+
+	//placing the order, up to the idempotency key
+	orderRepository := func() *mocks.IOrderRepository {
+		m := mocks.IOrderRepository{}
+		m.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(uint(8), nil)
+		m.On("CreateItem", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		m.On("UpdateOrderByOrderID", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		return &m
+	}
+	bookRepository := func() *mocks.IBookRepository {
+		m := mocks.IBookRepository{}
+		m.On("GetBookByID", mock.Anything, uint(1)).Return(model.Book{ID: 1, Title: "title", Author: "author", Price: 10}, nil)
+		return &m
+	}
+	outboxService := func() *mocksService.IOutboxService {
+		m := mocksService.IOutboxService{}
+		m.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		return &m
+	}
+
+	tests := []struct {
+		name                  string
+		idempotencyRepository *mocks.IIdempotencyRepository
+		orderRepository       *mocks.IOrderRepository
+		bookRepository        *mocks.IBookRepository
+		outboxService         *mocksService.IOutboxService
+		wantCommit            bool
+		want                  func(t *testing.T, got response.CreateOrderData)
+		wantErr               bool
+		wantKind              error
+	}{
+		{
+			name: "first request stores the response",
+			idempotencyRepository: func() *mocks.IIdempotencyRepository {
+				m := mocks.IIdempotencyRepository{}
+				m.On("GetIdempotencyKey", mock.Anything, uint(1), "retry-1", mock.Anything).Return(nil, nil)
+				m.On("CreateIdempotencyKey", mock.Anything, mock.Anything, mock.MatchedBy(func(key model.IdempotencyKey) bool {
+					var data response.CreateOrderData
+					json.Unmarshal(key.Response, &data)
+					return key.CustomerID == 1 && key.Key == "retry-1" && key.RequestHash == requestHash &&
+						data.OrderID == 8 && key.ExpiresAt.Sub(key.CreatedAt) == testConfig.Order.IdempotencyTTL
+				})).Return(true, nil)
+				return &m
+			}(),
+			orderRepository: orderRepository(),
+			bookRepository:  bookRepository(),
+			outboxService:   outboxService(),
+			wantCommit:      true,
+			want: func(t *testing.T, got response.CreateOrderData) {
+				assert.Equal(t, uint(8), got.OrderID)
+			},
+		},
+		{
+			name: "retry replays the stored response",
+			idempotencyRepository: func() *mocks.IIdempotencyRepository {
+				m := mocks.IIdempotencyRepository{}
+				m.On("GetIdempotencyKey", mock.Anything, uint(1), "retry-1", mock.Anything).Return(stored, nil)
+				return &m
+			}(),
+			orderRepository: &mocks.IOrderRepository{},
+			bookRepository:  &mocks.IBookRepository{},
+			outboxService:   &mocksService.IOutboxService{},
+			want: func(t *testing.T, got response.CreateOrderData) {
+				assert.Equal(t, first, got)
+			},
+		},
+		{
+			name: "key reused for another order",
+			idempotencyRepository: func() *mocks.IIdempotencyRepository {
+				m := mocks.IIdempotencyRepository{}
+				m.On("GetIdempotencyKey", mock.Anything, uint(1), "retry-1", mock.Anything).Return(&model.IdempotencyKey{RequestHash: "other", Response: firstResponse}, nil)
+				return &m
+			}(),
+			orderRepository: &mocks.IOrderRepository{},
+			bookRepository:  &mocks.IBookRepository{},
+			outboxService:   &mocksService.IOutboxService{},
+			wantErr:         true,
+			wantKind:        apperror.ErrConflict,
+		},
+		{
+			name: "concurrent retry committed first",
+			idempotencyRepository: func() *mocks.IIdempotencyRepository {
+				m := mocks.IIdempotencyRepository{}
+				m.On("GetIdempotencyKey", mock.Anything, uint(1), "retry-1", mock.Anything).Return(nil, nil).Once()
+				m.On("CreateIdempotencyKey", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+				m.On("GetIdempotencyKey", mock.Anything, uint(1), "retry-1", mock.Anything).Return(stored, nil).Once()
+				return &m
+			}(),
+			orderRepository: orderRepository(),
+			bookRepository:  bookRepository(),
+			outboxService:   outboxService(),
+			want: func(t *testing.T, got response.CreateOrderData) {
+				assert.Equal(t, first, got)
+			},
+		},
+		{
+			name: "GetIdempotencyKey error",
+			idempotencyRepository: func() *mocks.IIdempotencyRepository {
+				m := mocks.IIdempotencyRepository{}
+				m.On("GetIdempotencyKey", mock.Anything, uint(1), "retry-1", mock.Anything).Return(nil, errors.New("error"))
+				return &m
+			}(),
+			orderRepository: &mocks.IOrderRepository{},
+			bookRepository:  &mocks.IBookRepository{},
+			outboxService:   &mocksService.IOutboxService{},
+			wantErr:         true,
+		},
+		{
+			name: "CreateIdempotencyKey error rolls the order back",
+			idempotencyRepository: func() *mocks.IIdempotencyRepository {
+				m := mocks.IIdempotencyRepository{}
+				m.On("GetIdempotencyKey", mock.Anything, uint(1), "retry-1", mock.Anything).Return(nil, nil)
+				m.On("CreateIdempotencyKey", mock.Anything, mock.Anything, mock.Anything).Return(false, errors.New("error"))
+				return &m
+			}(),
+			orderRepository: orderRepository(),
+			bookRepository:  bookRepository(),
+			outboxService:   outboxService(),
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &mocks.TxxProvider{}
+			tx.On("Commit").Return(nil)
+			tx.On("Rollback").Return(nil)
+			txProvider := &mocks.ITransactionProvider{}
+			txProvider.On("NewTransaction", mock.Anything).Return(tx, nil)
+
+			o := order.NewOrderService(tt.orderRepository, &mocks.IAddressRepository{}, currentCustomer(), tt.bookRepository, tt.idempotencyRepository, newBookCache(), txProvider, &mocksService.INotificationService{}, tt.outboxService, testConfig)
+			got, err := o.CreateOrder(ctx, req, "retry-1")
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantKind != nil {
+				assert.ErrorIs(t, err, tt.wantKind)
+			}
+			if tt.want != nil {
+				tt.want(t, got)
+			}
+
+			if tt.wantCommit {
+				tx.AssertCalled(t, "Commit")
+			} else {
+				tx.AssertNotCalled(t, "Commit")
+			}
+			tt.idempotencyRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_orderService_CreateOrder_ReferenceCollision(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	ctx = context.WithValue(ctx, "email", "mail@mail.com")
+	ctx = context.WithValue(ctx, "username", "username")
+	req := request.CreateOrder{
+		Items:   []request.Item{{BookID: 1, Quantity: 1}},
+		Address: "address",
+		Shipper: "JNE",
+	}
+
+	newService := func(orderRepository *mocks.IOrderRepository) service.IOrderService {
+		tx := &mocks.TxxProvider{}
+		tx.On("Commit").Return(nil)
+		tx.On("Rollback").Return(nil)
+		txProvider := &mocks.ITransactionProvider{}
+		txProvider.On("NewTransaction", mock.Anything).Return(tx, nil)
+		bookRepository := &mocks.IBookRepository{}
+		bookRepository.On("GetBookByID", mock.Anything, uint(1)).Return(model.Book{ID: 1, Title: "title", Author: "author", Price: 10}, nil)
+		outboxService := &mocksService.IOutboxService{}
+		outboxService.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		return order.NewOrderService(orderRepository, &mocks.IAddressRepository{}, currentCustomer(), bookRepository, &mocks.IIdempotencyRepository{}, newBookCache(), txProvider, &mocksService.INotificationService{}, outboxService, testConfig)
+	}
+
+	t.Run("retries with other references", func(t *testing.T) {
+		var references []string
+		m := &mocks.IOrderRepository{}
+		m.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			references = append(references, args.Get(2).(model.Order).CustomerReference)
+		}).Return(uint(0), repository.ErrDuplicateReference).Once()
+		m.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			references = append(references, args.Get(2).(model.Order).CustomerReference)
+		}).Return(uint(1), nil).Once()
+		m.On("CreateItem", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		m.On("UpdateOrderByOrderID", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		got, err := newService(m).CreateOrder(ctx, req, "")
+		assert.NoError(t, err)
+		assert.Len(t, references, 2)
+		assert.NotEqual(t, references[0], references[1])
+		assert.Equal(t, references[1], got.CustomerReference)
+		assert.True(t, reference.Valid(got.CustomerReference, 10), got.CustomerReference)
+		assert.True(t, strings.HasPrefix(got.AirwaybillNumber, "JNE-"), got.AirwaybillNumber)
+		assert.True(t, reference.Valid(strings.TrimPrefix(got.AirwaybillNumber, "JNE-"), 12), got.AirwaybillNumber)
+	})
+
+	t.Run("gives up after a few attempts", func(t *testing.T) {
+		m := &mocks.IOrderRepository{}
+		m.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(uint(0), repository.ErrDuplicateReference)
+
+		_, err := newService(m).CreateOrder(ctx, req, "")
+		assert.ErrorIs(t, err, repository.ErrDuplicateReference)
+		m.AssertNumberOfCalls(t, "CreateOrder", 5)
+	})
+}
+
+func Test_orderService_ValidateReference(t *testing.T) {
+	customerReference, _ := reference.New(10)
+	airwaybill, _ := reference.New(12)
+	typo := []byte(customerReference)
+	if typo[3] == 'A' {
+		typo[3] = 'B'
+	} else {
+		typo[3] = 'A'
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  response.ReferenceValidation
+	}{
+		{
+			name:  "customer reference",
+			value: customerReference,
+			want:  response.ReferenceValidation{Reference: customerReference, Type: response.ReferenceCustomer, Valid: true},
+		},
+		{
+			name:  "customer reference typed in lower case",
+			value: " " + strings.ToLower(customerReference) + " ",
+			want:  response.ReferenceValidation{Reference: customerReference, Type: response.ReferenceCustomer, Valid: true},
+		},
+		{
+			name:  "customer reference with a typo",
+			value: string(typo),
+			want:  response.ReferenceValidation{Reference: string(typo), Type: response.ReferenceCustomer, Valid: false},
+		},
+		{
+			name:  "reference of an older order",
+			value: "Ab12Cd34",
+			want:  response.ReferenceValidation{Reference: "AB12CD34", Type: response.ReferenceCustomer, Valid: false},
+		},
+		{
+			name:  "airwaybill number",
+			value: "sicepat-" + strings.ToLower(airwaybill),
+			want:  response.ReferenceValidation{Reference: "SiCepat-" + airwaybill, Type: response.ReferenceAirwaybillNumber, Valid: true},
+		},
+		{
+			name:  "airwaybill number of another length",
+			value: "JNE-" + customerReference,
+			want:  response.ReferenceValidation{Reference: "JNE-" + customerReference, Type: response.ReferenceAirwaybillNumber, Valid: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := order.NewOrderService(&mocks.IOrderRepository{}, &mocks.IAddressRepository{}, &mocks.ICustomerRepository{}, &mocks.IBookRepository{}, &mocks.IIdempotencyRepository{}, newBookCache(), &mocks.ITransactionProvider{}, &mocksService.INotificationService{}, &mocksService.IOutboxService{}, testConfig)
+			assert.Equal(t, tt.want, o.ValidateReference(context.Background(), tt.value))
+		})
+	}
+}
+
+func Test_orderService_ResendOrderConfirmation(t *testing.T) {
+	o := model.Order{
+		ID:                1,
+		CustomerID:        2,
+		CustomerReference: "customerReference",
+		AirwaybillNumber:  "AWBnumber",
+		TotalItem:         1,
+		TotalPrice:        10,
+	}
+
+	type fields struct {
+		orderRepository     *mocks.IOrderRepository
+		customerRepository  *mocks.ICustomerRepository
+		bookRepository      *mocks.IBookRepository
+		notificationService *mocksService.INotificationService
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		cfg     *config.Config
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("GetOrderByID", mock.Anything, uint(1)).Return(&o, nil)
+					m.On("GetItemsByOrderID", mock.Anything, uint(1)).Return([]model.Item{{BookID: 3, Quantity: 2}}, nil)
+					return &m
+				}(),
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(2)).Return(&model.Customer{ID: 2, Email: "mail@mail.com", Username: "reader"}, nil)
+					return &m
+				}(),
+				bookRepository: func() *mocks.IBookRepository {
+					m := mocks.IBookRepository{}
+					m.On("GetBooksByIDs", mock.Anything, []uint{3}).Return([]model.Book{{ID: 3, Title: "title", Price: 5}}, nil)
+					return &m
+				}(),
+				notificationService: func() *mocksService.INotificationService {
+					m := mocksService.INotificationService{}
+					m.On("SendNotification", mock.Anything, mock.MatchedBy(func(p notification.Message) bool {
+						return p.To == "mail@mail.com" && p.Subject == "Order Confirmation customerReference" &&
+							strings.Contains(p.Text, "Dear reader,") && strings.Contains(p.Text, "2 x IDR 5.00 = IDR 10.00") &&
+							p.CustomerID == 2 && p.Category == notification.CategoryTransactional
+					})).Return(nil)
+					return &m
+				}(),
+			},
+			cfg:     testConfig,
+			wantErr: false,
+		},
+		{
+			name: "email disabled",
+			fields: fields{
+				orderRepository:     &mocks.IOrderRepository{},
+				customerRepository:  &mocks.ICustomerRepository{},
+				notificationService: &mocksService.INotificationService{},
+			},
+			cfg: func() *config.Config {
+				cfg := config.Default()
+				return &cfg
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "order not found",
+			fields: fields{
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("GetOrderByID", mock.Anything, uint(1)).Return(nil, nil)
+					return &m
+				}(),
+				customerRepository:  &mocks.ICustomerRepository{},
+				notificationService: &mocksService.INotificationService{},
+			},
+			cfg:     testConfig,
+			wantErr: true,
+		},
+		{
+			name: "failed SendNotification",
+			fields: fields{
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("GetOrderByID", mock.Anything, uint(1)).Return(&o, nil)
+					m.On("GetItemsByOrderID", mock.Anything, uint(1)).Return([]model.Item{{BookID: 3, Quantity: 2}}, nil)
+					return &m
+				}(),
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, uint(2)).Return(&model.Customer{ID: 2, Email: "mail@mail.com", Username: "reader"}, nil)
+					return &m
+				}(),
+				bookRepository: func() *mocks.IBookRepository {
+					m := mocks.IBookRepository{}
+					m.On("GetBooksByIDs", mock.Anything, []uint{3}).Return([]model.Book{{ID: 3, Title: "title", Price: 5}}, nil)
+					return &m
+				}(),
+				notificationService: func() *mocksService.INotificationService {
+					m := mocksService.INotificationService{}
+					m.On("SendNotification", mock.Anything, mock.Anything).Return(errors.New("error"))
+					return &m
+				}(),
+			},
+			cfg:     testConfig,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := order.NewOrderService(tt.fields.orderRepository, &mocks.IAddressRepository{}, tt.fields.customerRepository, tt.fields.bookRepository, &mocks.IIdempotencyRepository{}, newBookCache(), &mocks.ITransactionProvider{}, tt.fields.notificationService, &mocksService.IOutboxService{}, tt.cfg)
+			err := s.ResendOrderConfirmation(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_orderService_PreviewOrderConfirmation(t *testing.T) {
+	o := model.Order{
+		ID:                1,
+		CustomerID:        2,
+		CustomerReference: "customerReference",
+		TotalItem:         2,
+		TotalPrice:        10,
+	}
+
+	orderRepository := func() *mocks.IOrderRepository {
+		m := mocks.IOrderRepository{}
+		m.On("GetOrderByID", mock.Anything, uint(1)).Return(&o, nil)
+		m.On("GetOrderByID", mock.Anything, uint(9)).Return(nil, nil)
+		m.On("GetItemsByOrderID", mock.Anything, uint(1)).Return([]model.Item{{BookID: 3, Quantity: 2}}, nil)
+		return &m
+	}
+	customerRepository := func() *mocks.ICustomerRepository {
+		m := mocks.ICustomerRepository{}
+		m.On("GetCustomerByID", mock.Anything, uint(2)).Return(&model.Customer{ID: 2, Email: "mail@mail.com", Username: "reader"}, nil)
+		return &m
+	}
+	bookRepository := func() *mocks.IBookRepository {
+		m := mocks.IBookRepository{}
+		m.On("GetBooksByIDs", mock.Anything, []uint{3}).Return([]model.Book{{ID: 3, Title: "<b>title</b>", Price: 5000}}, nil)
+		return &m
+	}
+
+	tests := []struct {
+		name        string
+		orderID     uint
+		locale      string
+		wantSubject string
+		wantHTML    string
+		wantErr     error
+	}{
+		{
+			name:        "configured locale",
+			orderID:     1,
+			wantSubject: "Order Confirmation customerReference",
+			wantHTML:    "&lt;b&gt;title&lt;/b&gt;",
+		},
+		{
+			name:        "requested locale",
+			orderID:     1,
+			locale:      "id",
+			wantSubject: "Konfirmasi Pesanan customerReference",
+			wantHTML:    "IDR 10.000,00",
+		},
+		{
+			name:    "unknown locale",
+			orderID: 1,
+			locale:  "fr",
+			wantErr: apperror.ErrValidation,
+		},
+		{
+			name:    "order not found",
+			orderID: 9,
+			wantErr: apperror.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := order.NewOrderService(orderRepository(), &mocks.IAddressRepository{}, customerRepository(), bookRepository(), &mocks.IIdempotencyRepository{}, newBookCache(), &mocks.ITransactionProvider{}, &mocksService.INotificationService{}, &mocksService.IOutboxService{}, testConfig)
+			got, err := s.PreviewOrderConfirmation(context.Background(), tt.orderID, tt.locale)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "mail@mail.com", got.To)
+			assert.Equal(t, tt.wantSubject, got.Subject)
+			assert.Contains(t, got.HTML, tt.wantHTML)
+			assert.NotEmpty(t, got.Text)
+		})
+	}
+}
+
+func newBookCache() cache.ICache[model.Book] {
+	return cache.NewMemory[model.Book]("books", cache.Options{Size: 10, Shards: 1, TTL: time.Minute})
+}
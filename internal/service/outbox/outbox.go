@@ -0,0 +1,229 @@
+package outbox
+
+import (
+	"context"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/repository"
+	"ebookstore/internal/service"
+	"ebookstore/utils/config"
+	"ebookstore/utils/notification"
+	"ebookstore/utils/transactioner"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// claimLease is how long a claimed message is hidden from other dispatchers.
+// It only has to outlast one batch, a dispatcher that dies mid-batch leaves
+// its messages to be picked up again once it runs out.
+const claimLease = 5 * time.Minute
+
+type outboxService struct {
+	outboxRepository    repository.IOutboxRepository
+	notificationService notification.INotificationService
+	cfg                 *config.Config
+}
+
+func NewOutboxService(outboxRepository repository.IOutboxRepository, notificationService notification.INotificationService, cfg *config.Config) service.IOutboxService {
+	return &outboxService{
+		outboxRepository:    outboxRepository,
+		notificationService: notificationService,
+		cfg:                 cfg,
+	}
+}
+
+// Enqueue queues the email in tx. It is only sent once tx is committed, and
+// is dropped with it on a rollback.
+func (s *outboxService) Enqueue(ctx context.Context, tx transactioner.TxxProvider, message notification.Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	_, err = s.outboxRepository.Enqueue(ctx, tx, model.OutboxMessage{
+		CustomerID:    message.CustomerID,
+		Payload:       data,
+		NextAttemptAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+
+	return nil
+}
+
+// Run dispatches the due messages every poll interval until ctx is done. A
+// batch that already started is finished, so no message is left claimed.
+func (s *outboxService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Outbox.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		//keep going while batches are full, there is more waiting
+		for {
+			sent, err := s.Dispatch(context.WithoutCancel(ctx))
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to dispatch notifications", "error", err)
+				break
+			}
+			if sent < s.cfg.Outbox.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends one batch of due messages and returns how many were claimed.
+// A failed send is retried with an exponential backoff until the attempts run
+// out, then the message is marked dead.
+func (s *outboxService) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	messages, err := s.outboxRepository.ClaimDue(ctx, now, now.Add(claimLease), s.cfg.Outbox.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	for _, msg := range messages {
+		err = s.send(ctx, msg)
+		if err == nil {
+			err = s.outboxRepository.MarkSent(ctx, msg.ID, time.Now().UTC())
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to mark notification sent", "outbox_id", msg.ID, "error", err)
+			}
+			continue
+		}
+
+		//channels that got it are not sent to again on the next attempt
+		var delivery *notification.DeliveryError
+		if errors.As(err, &delivery) {
+			msg.DeliveredChannels = append(msg.DeliveredChannels, delivery.Delivered...)
+		}
+
+		msg.Attempts++
+		msg.LastError = err.Error()
+		msg.UpdatedAt.Time, msg.UpdatedAt.Valid = time.Now().UTC(), true
+		if msg.Attempts >= s.cfg.Outbox.MaxAttempts {
+			msg.Status = model.OutboxDead
+			slog.ErrorContext(ctx, "notification is dead", "outbox_id", msg.ID, "attempts", msg.Attempts, "error", err)
+		} else {
+			msg.NextAttemptAt = msg.UpdatedAt.Time.Add(s.backoff(msg.Attempts))
+			slog.WarnContext(ctx, "failed to send notification", "outbox_id", msg.ID, "attempts", msg.Attempts, "error", err)
+		}
+
+		err = s.outboxRepository.MarkFailed(ctx, msg)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to mark notification failed", "outbox_id", msg.ID, "error", err)
+		}
+	}
+
+	return len(messages), nil
+}
+
+func (s *outboxService) send(ctx context.Context, msg model.OutboxMessage) error {
+	var payload notification.Message
+	err := json.Unmarshal(msg.Payload, &payload)
+	if err != nil {
+		return fmt.Errorf("failed to decode notification: %w", err)
+	}
+
+	payload.Delivered = msg.DeliveredChannels
+	return s.notificationService.SendNotification(ctx, payload)
+}
+
+// backoff is the delay before the next attempt, BaseBackoff doubled for every
+// failed attempt and capped at MaxBackoff.
+func (s *outboxService) backoff(attempts int) time.Duration {
+	delay := s.cfg.Outbox.BaseBackoff
+	for i := 1; i < attempts && delay < s.cfg.Outbox.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, s.cfg.Outbox.MaxBackoff)
+}
+
+func (s *outboxService) GetMessages(ctx context.Context, status string, limit int) ([]response.OutboxMessageData, error) {
+	messages, err := s.outboxRepository.GetMessages(ctx, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	resp := []response.OutboxMessageData{}
+	for _, msg := range messages {
+		//the body can be large and holds links with tokens, only list the envelope
+		var payload notification.Message
+		_ = json.Unmarshal(msg.Payload, &payload)
+
+		data := response.OutboxMessageData{
+			ID:            msg.ID,
+			To:            payload.To,
+			Subject:       payload.Subject,
+			Status:        msg.Status,
+			Attempts:      msg.Attempts,
+			LastError:     msg.LastError,
+			NextAttemptAt: msg.NextAttemptAt,
+			CreatedAt:     msg.CreatedAt,
+		}
+		if msg.SentAt.Valid {
+			data.SentAt = &msg.SentAt.Time
+		}
+
+		resp = append(resp, data)
+	}
+
+	return resp, nil
+}
+
+// Replay queues a dead message again with a fresh set of attempts.
+func (s *outboxService) Replay(ctx context.Context, id uint) error {
+	ok, err := s.outboxRepository.Replay(ctx, id, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to replay notification: %w", err)
+	}
+
+	if ok {
+		return nil
+	}
+
+	msg, err := s.outboxRepository.GetMessageByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get notification: %w", err)
+	}
+
+	if msg == nil {
+		return apperror.NotFound("notification not found")
+	}
+
+	return apperror.Conflict(fmt.Sprintf("only dead notifications can be replayed, this one is %s", msg.Status))
+}
+
+// DeleteCustomerMessages removes the messages to the customer in tx, together
+// with the rest of their personal data.
+func (s *outboxService) DeleteCustomerMessages(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error {
+	err := s.outboxRepository.DeleteByCustomerID(ctx, tx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete notifications: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeMessages deletes the sent and dead messages older than the retention
+// and returns how many were removed.
+func (s *outboxService) PurgeMessages(ctx context.Context) (int64, error) {
+	count, err := s.outboxRepository.DeleteFinished(ctx, time.Now().UTC().Add(-s.cfg.Outbox.Retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge notifications: %w", err)
+	}
+
+	return count, nil
+}
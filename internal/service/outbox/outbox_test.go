@@ -0,0 +1,276 @@
+package outbox_test
+
+import (
+	"context"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/repository/mocks"
+	mocksService "ebookstore/internal/service/mocks"
+	"ebookstore/internal/service/outbox"
+	"ebookstore/utils/config"
+	"ebookstore/utils/notification"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testConfig = func() *config.Config {
+	cfg := config.Default()
+	cfg.Outbox.MaxAttempts = 3
+	cfg.Outbox.BaseBackoff = time.Minute
+	cfg.Outbox.MaxBackoff = 3 * time.Minute
+	return &cfg
+}()
+
+const payload = `{"to":"mail@mail.com","subject":"Order Confirmation","body":"body"}`
+
+func Test_outboxService_Enqueue(t *testing.T) {
+	outboxRepository := mocks.IOutboxRepository{}
+	outboxRepository.On("Enqueue", mock.Anything, mock.Anything, mock.MatchedBy(func(msg model.OutboxMessage) bool {
+		return msg.CustomerID == 3 && string(msg.Payload) == `{"to":"mail@mail.com","subject":"Order Confirmation","body":"body","customer_id":3}` && !msg.NextAttemptAt.IsZero()
+	})).Return(uint(1), nil)
+
+	s := outbox.NewOutboxService(&outboxRepository, &mocksService.INotificationService{}, testConfig)
+	err := s.Enqueue(context.Background(), &mocks.TxxProvider{}, notification.Message{
+		To:         "mail@mail.com",
+		Subject:    "Order Confirmation",
+		Body:       "body",
+		CustomerID: 3,
+	})
+
+	assert.NoError(t, err)
+	outboxRepository.AssertExpectations(t)
+}
+
+func Test_outboxService_Dispatch(t *testing.T) {
+	msg := func(attempts int) model.OutboxMessage {
+		return model.OutboxMessage{ID: 1, Payload: []byte(payload), Status: model.OutboxPending, Attempts: attempts}
+	}
+
+	tests := []struct {
+		name                string
+		outboxRepository    *mocks.IOutboxRepository
+		notificationService *mocksService.INotificationService
+		want                int
+		wantErr             bool
+	}{
+		{
+			name: "sent",
+			outboxRepository: func() *mocks.IOutboxRepository {
+				m := mocks.IOutboxRepository{}
+				m.On("ClaimDue", mock.Anything, mock.Anything, mock.Anything, 20).Return([]model.OutboxMessage{msg(0)}, nil)
+				m.On("MarkSent", mock.Anything, uint(1), mock.Anything).Return(nil)
+				return &m
+			}(),
+			notificationService: func() *mocksService.INotificationService {
+				m := mocksService.INotificationService{}
+				m.On("SendNotification", mock.Anything, notification.Message{To: "mail@mail.com", Subject: "Order Confirmation", Body: "body"}).Return(nil)
+				return &m
+			}(),
+			want: 1,
+		},
+		{
+			name: "retried with backoff",
+			outboxRepository: func() *mocks.IOutboxRepository {
+				m := mocks.IOutboxRepository{}
+				m.On("ClaimDue", mock.Anything, mock.Anything, mock.Anything, 20).Return([]model.OutboxMessage{msg(1)}, nil)
+				m.On("MarkFailed", mock.Anything, mock.MatchedBy(func(got model.OutboxMessage) bool {
+					//second failure waits twice the base backoff
+					delay := got.NextAttemptAt.Sub(got.UpdatedAt.Time)
+					return got.Status == model.OutboxPending && got.Attempts == 2 && got.LastError == "smtp down" && delay == 2*time.Minute
+				})).Return(nil)
+				return &m
+			}(),
+			notificationService: func() *mocksService.INotificationService {
+				m := mocksService.INotificationService{}
+				m.On("SendNotification", mock.Anything, mock.Anything).Return(errors.New("smtp down"))
+				return &m
+			}(),
+			want: 1,
+		},
+		{
+			name: "dead after the last attempt",
+			outboxRepository: func() *mocks.IOutboxRepository {
+				m := mocks.IOutboxRepository{}
+				m.On("ClaimDue", mock.Anything, mock.Anything, mock.Anything, 20).Return([]model.OutboxMessage{msg(2)}, nil)
+				m.On("MarkFailed", mock.Anything, mock.MatchedBy(func(got model.OutboxMessage) bool {
+					return got.Status == model.OutboxDead && got.Attempts == 3
+				})).Return(nil)
+				return &m
+			}(),
+			notificationService: func() *mocksService.INotificationService {
+				m := mocksService.INotificationService{}
+				m.On("SendNotification", mock.Anything, mock.Anything).Return(errors.New("smtp down"))
+				return &m
+			}(),
+			want: 1,
+		},
+		{
+			name: "invalid payload is retried too",
+			outboxRepository: func() *mocks.IOutboxRepository {
+				m := mocks.IOutboxRepository{}
+				m.On("ClaimDue", mock.Anything, mock.Anything, mock.Anything, 20).Return([]model.OutboxMessage{{ID: 1, Payload: []byte("{")}}, nil)
+				m.On("MarkFailed", mock.Anything, mock.MatchedBy(func(got model.OutboxMessage) bool {
+					return got.Attempts == 1 && got.Status != model.OutboxDead
+				})).Return(nil)
+				return &m
+			}(),
+			notificationService: &mocksService.INotificationService{},
+			want:                1,
+		},
+		{
+			name: "ClaimDue error",
+			outboxRepository: func() *mocks.IOutboxRepository {
+				m := mocks.IOutboxRepository{}
+				m.On("ClaimDue", mock.Anything, mock.Anything, mock.Anything, 20).Return(nil, errors.New("error"))
+				return &m
+			}(),
+			notificationService: &mocksService.INotificationService{},
+			wantErr:             true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := outbox.NewOutboxService(tt.outboxRepository, tt.notificationService, testConfig)
+			got, err := s.Dispatch(context.Background())
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+			tt.outboxRepository.AssertExpectations(t)
+		})
+	}
+}
+
+// channel counts the messages it was asked to send and fails them all with err.
+type channel struct {
+	sent int
+	err  error
+}
+
+func (c *channel) SendNotification(ctx context.Context, msg notification.Message) error {
+	c.sent++
+	return c.err
+}
+
+func Test_outboxService_Dispatch_PerChannel(t *testing.T) {
+	healthy, failing := &channel{}, &channel{err: errors.New("connection refused")}
+	fanout := notification.NewFanout(nil,
+		notification.Channel{Name: "smtp", Service: healthy},
+		notification.Channel{Name: "webhook", Service: failing},
+	)
+
+	var failed model.OutboxMessage
+	outboxRepository := mocks.IOutboxRepository{}
+	outboxRepository.On("ClaimDue", mock.Anything, mock.Anything, mock.Anything, 20).
+		Return([]model.OutboxMessage{{ID: 1, Payload: []byte(payload), Status: model.OutboxPending}}, nil).Once()
+	outboxRepository.On("MarkFailed", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { failed = args.Get(1).(model.OutboxMessage) }).Return(nil)
+
+	s := outbox.NewOutboxService(&outboxRepository, fanout, testConfig)
+	_, err := s.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, pq.StringArray{"smtp"}, failed.DeliveredChannels)
+
+	//the retry claims the message as it was stored by MarkFailed
+	outboxRepository.On("ClaimDue", mock.Anything, mock.Anything, mock.Anything, 20).
+		Return([]model.OutboxMessage{failed}, nil).Once()
+
+	_, err = s.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, healthy.sent)
+	assert.Equal(t, 2, failing.sent)
+	assert.Equal(t, pq.StringArray{"smtp"}, failed.DeliveredChannels)
+}
+
+func Test_outboxService_GetMessages(t *testing.T) {
+	sentAt := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	outboxRepository := mocks.IOutboxRepository{}
+	outboxRepository.On("GetMessages", mock.Anything, model.OutboxSent, 10).Return([]model.OutboxMessage{
+		{ID: 1, Payload: []byte(payload), Status: model.OutboxSent, Attempts: 1, SentAt: pq.NullTime{Time: sentAt, Valid: true}},
+	}, nil)
+
+	s := outbox.NewOutboxService(&outboxRepository, &mocksService.INotificationService{}, testConfig)
+	got, err := s.GetMessages(context.Background(), model.OutboxSent, 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "mail@mail.com", got[0].To)
+	assert.Equal(t, "Order Confirmation", got[0].Subject)
+	assert.Equal(t, &sentAt, got[0].SentAt)
+}
+
+func Test_outboxService_Replay(t *testing.T) {
+	tests := []struct {
+		name             string
+		outboxRepository *mocks.IOutboxRepository
+		wantErr          bool
+		wantKind         error
+	}{
+		{
+			name: "best case",
+			outboxRepository: func() *mocks.IOutboxRepository {
+				m := mocks.IOutboxRepository{}
+				m.On("Replay", mock.Anything, uint(1), mock.Anything).Return(true, nil)
+				return &m
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "not found",
+			outboxRepository: func() *mocks.IOutboxRepository {
+				m := mocks.IOutboxRepository{}
+				m.On("Replay", mock.Anything, uint(1), mock.Anything).Return(false, nil)
+				m.On("GetMessageByID", mock.Anything, uint(1)).Return(nil, nil)
+				return &m
+			}(),
+			wantErr:  true,
+			wantKind: apperror.ErrNotFound,
+		},
+		{
+			name: "not dead",
+			outboxRepository: func() *mocks.IOutboxRepository {
+				m := mocks.IOutboxRepository{}
+				m.On("Replay", mock.Anything, uint(1), mock.Anything).Return(false, nil)
+				m.On("GetMessageByID", mock.Anything, uint(1)).Return(&model.OutboxMessage{ID: 1, Status: model.OutboxSent}, nil)
+				return &m
+			}(),
+			wantErr:  true,
+			wantKind: apperror.ErrConflict,
+		},
+		{
+			name: "Replay error",
+			outboxRepository: func() *mocks.IOutboxRepository {
+				m := mocks.IOutboxRepository{}
+				m.On("Replay", mock.Anything, uint(1), mock.Anything).Return(false, errors.New("error"))
+				return &m
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := outbox.NewOutboxService(tt.outboxRepository, &mocksService.INotificationService{}, testConfig)
+			err := s.Replay(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantKind != nil {
+				assert.ErrorIs(t, err, tt.wantKind)
+			}
+		})
+	}
+}
+
+func Test_outboxService_PurgeMessages(t *testing.T) {
+	outboxRepository := mocks.IOutboxRepository{}
+	outboxRepository.On("DeleteFinished", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= testConfig.Outbox.Retention && time.Since(before) < testConfig.Outbox.Retention+time.Minute
+	})).Return(int64(4), nil)
+
+	s := outbox.NewOutboxService(&outboxRepository, &mocksService.INotificationService{}, testConfig)
+	got, err := s.PurgeMessages(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), got)
+}
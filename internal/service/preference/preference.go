@@ -0,0 +1,236 @@
+package preference
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/repository"
+	"ebookstore/internal/service"
+	"ebookstore/utils/config"
+	"ebookstore/utils/notification"
+	"ebookstore/utils/tracing"
+	"ebookstore/utils/transactioner"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// categories customers can choose from, with the value used until they do.
+// Marketing needs an opt-in.
+var categories = []struct {
+	name    string
+	enabled bool
+}{
+	{name: notification.CategoryTransactional, enabled: true},
+	{name: notification.CategoryMarketing, enabled: false},
+}
+
+type preferenceService struct {
+	preferenceRepository repository.IPreferenceRepository
+	customerRepository   repository.ICustomerRepository
+	TransactionProvider  transactioner.ITransactionProvider
+	cfg                  *config.Config
+}
+
+func NewPreferenceService(preferenceRepository repository.IPreferenceRepository, customerRepository repository.ICustomerRepository, tx transactioner.ITransactionProvider, cfg *config.Config) service.IPreferenceService {
+	return &preferenceService{
+		preferenceRepository: preferenceRepository,
+		customerRepository:   customerRepository,
+		TransactionProvider:  tx,
+		cfg:                  cfg,
+	}
+}
+
+// GetPreferences returns every category on every configured channel.
+func (s *preferenceService) GetPreferences(ctx context.Context) ([]response.NotificationPreference, error) {
+	ctx, span := tracing.Start(ctx, "preferenceService.GetPreferences")
+	defer span.End()
+
+	return s.preferences(ctx, ctx.Value("id").(uint))
+}
+
+func (s *preferenceService) UpdatePreferences(ctx context.Context, req request.UpdateNotificationPreferences) ([]response.NotificationPreference, error) {
+	ctx, span := tracing.Start(ctx, "preferenceService.UpdatePreferences")
+	defer span.End()
+
+	customerID := ctx.Value("id").(uint)
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for _, preference := range req.Preferences {
+		err = s.preferenceRepository.SetPreference(ctx, tx, model.NotificationPreference{
+			CustomerID: customerID,
+			Category:   preference.Category,
+			Channel:    strings.ToLower(preference.Channel),
+			Enabled:    *preference.Enabled,
+			UpdatedAt:  now,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set preference: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.preferences(ctx, customerID)
+}
+
+// Unsubscribe turns off the category and channel of a signed unsubscribe
+// token. The token is the login, so it works from any mail client.
+func (s *preferenceService) Unsubscribe(ctx context.Context, token string) (response.UnsubscribeData, error) {
+	ctx, span := tracing.Start(ctx, "preferenceService.Unsubscribe")
+	defer span.End()
+
+	customerID, category, channel, ok := s.verify(token)
+	if !ok {
+		return response.UnsubscribeData{}, apperror.Validation("invalid unsubscribe link", apperror.FieldError{Field: "token", Message: "is invalid"})
+	}
+
+	customer, err := s.customerRepository.GetCustomerByID(ctx, customerID)
+	if err != nil {
+		return response.UnsubscribeData{}, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	if customer == nil {
+		return response.UnsubscribeData{}, apperror.NotFound("customer not found")
+	}
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return response.UnsubscribeData{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = s.preferenceRepository.SetPreference(ctx, tx, model.NotificationPreference{
+		CustomerID: customerID,
+		Category:   category,
+		Channel:    channel,
+		Enabled:    false,
+		UpdatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return response.UnsubscribeData{}, fmt.Errorf("failed to set preference: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return response.UnsubscribeData{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return response.UnsubscribeData{Category: category, Channel: channel}, nil
+}
+
+// Allowed tells the notification fan-out whether to send a category of
+// messages to a customer on a channel.
+func (s *preferenceService) Allowed(ctx context.Context, customerID uint, category, channel string) (bool, error) {
+	preferences, err := s.preferences(ctx, customerID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, preference := range preferences {
+		if preference.Category == category && preference.Channel == channel {
+			return preference.Enabled, nil
+		}
+	}
+
+	//a channel added to the configuration later starts with the defaults
+	for _, c := range categories {
+		if c.name == category {
+			return c.enabled, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (s *preferenceService) UnsubscribeURL(customerID uint, category, channel string) string {
+	return s.cfg.Server.BaseURL + "/api/customer/notifications/unsubscribe?token=" + url.QueryEscape(s.sign(customerID, category, channel))
+}
+
+func (s *preferenceService) preferences(ctx context.Context, customerID uint) ([]response.NotificationPreference, error) {
+	stored, err := s.preferenceRepository.GetPreferences(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	resp := []response.NotificationPreference{}
+	for _, category := range categories {
+		for _, channel := range s.cfg.Notification.Channels {
+			preference := response.NotificationPreference{
+				Category: category.name,
+				Channel:  channel,
+				Enabled:  category.enabled,
+			}
+
+			for _, p := range stored {
+				if p.Category == category.name && p.Channel == channel {
+					preference.Enabled = p.Enabled
+				}
+			}
+
+			resp = append(resp, preference)
+		}
+	}
+
+	return resp, nil
+}
+
+// sign builds the unsubscribe token, the payload and its HMAC. It does not
+// expire, an unsubscribe link has to work as long as the email is kept.
+func (s *preferenceService) sign(customerID uint, category, channel string) string {
+	payload := fmt.Sprintf("%d.%s.%s", customerID, category, channel)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+func (s *preferenceService) verify(token string) (uint, string, string, bool) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", "", false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return 0, "", "", false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.mac(string(payload))) {
+		return 0, "", "", false
+	}
+
+	parts := strings.Split(string(payload), ".")
+	if len(parts) != 3 {
+		return 0, "", "", false
+	}
+
+	customerID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", "", false
+	}
+
+	return uint(customerID), parts[1], parts[2], true
+}
+
+// mac keys the HMAC with the JWT secret, scoped so an unsubscribe signature
+// is never valid anywhere else.
+func (s *preferenceService) mac(payload string) []byte {
+	h := hmac.New(sha256.New, []byte(s.cfg.Auth.JWTSecret))
+	h.Write([]byte("unsubscribe:" + payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,226 @@
+package preference_test
+
+import (
+	"context"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/repository/mocks"
+	"ebookstore/internal/service/preference"
+	"ebookstore/utils/config"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testConfig = func() *config.Config {
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "secret"
+	cfg.Notification.Channels = []string{"smtp", "webhook"}
+	return &cfg
+}()
+
+func txProvider() *mocks.ITransactionProvider {
+	m := mocks.ITransactionProvider{}
+	txProvide := mocks.TxxProvider{}
+	txProvide.On("Commit").Return(nil)
+	txProvide.On("Rollback").Return(nil)
+	m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+	return &m
+}
+
+func Test_preferenceService_GetPreferences(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+
+	tests := []struct {
+		name                 string
+		preferenceRepository *mocks.IPreferenceRepository
+		want                 []response.NotificationPreference
+		wantErr              bool
+	}{
+		{
+			name: "defaults and changes",
+			preferenceRepository: func() *mocks.IPreferenceRepository {
+				m := mocks.IPreferenceRepository{}
+				m.On("GetPreferences", mock.Anything, uint(1)).Return([]model.NotificationPreference{
+					{CustomerID: 1, Category: "transactional", Channel: "webhook", Enabled: false},
+					{CustomerID: 1, Category: "marketing", Channel: "smtp", Enabled: true},
+				}, nil)
+				return &m
+			}(),
+			want: []response.NotificationPreference{
+				{Category: "transactional", Channel: "smtp", Enabled: true},
+				{Category: "transactional", Channel: "webhook", Enabled: false},
+				{Category: "marketing", Channel: "smtp", Enabled: true},
+				{Category: "marketing", Channel: "webhook", Enabled: false},
+			},
+		},
+		{
+			name: "failed GetPreferences",
+			preferenceRepository: func() *mocks.IPreferenceRepository {
+				m := mocks.IPreferenceRepository{}
+				m.On("GetPreferences", mock.Anything, uint(1)).Return(nil, errors.New("error"))
+				return &m
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := preference.NewPreferenceService(tt.preferenceRepository, &mocks.ICustomerRepository{}, &mocks.ITransactionProvider{}, testConfig)
+			got, err := s.GetPreferences(ctx)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_preferenceService_UpdatePreferences(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "id", uint(1))
+	enabled := true
+	req := request.UpdateNotificationPreferences{
+		Preferences: []request.NotificationPreference{{Category: "marketing", Channel: "SMTP", Enabled: &enabled}},
+	}
+
+	tests := []struct {
+		name                 string
+		preferenceRepository *mocks.IPreferenceRepository
+		wantErr              bool
+	}{
+		{
+			name: "best case",
+			preferenceRepository: func() *mocks.IPreferenceRepository {
+				m := mocks.IPreferenceRepository{}
+				m.On("SetPreference", mock.Anything, mock.Anything, mock.MatchedBy(func(p model.NotificationPreference) bool {
+					return p.CustomerID == 1 && p.Category == "marketing" && p.Channel == "smtp" && p.Enabled
+				})).Return(nil)
+				m.On("GetPreferences", mock.Anything, uint(1)).Return([]model.NotificationPreference{
+					{CustomerID: 1, Category: "marketing", Channel: "smtp", Enabled: true},
+				}, nil)
+				return &m
+			}(),
+		},
+		{
+			name: "failed SetPreference",
+			preferenceRepository: func() *mocks.IPreferenceRepository {
+				m := mocks.IPreferenceRepository{}
+				m.On("SetPreference", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("error"))
+				return &m
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := preference.NewPreferenceService(tt.preferenceRepository, &mocks.ICustomerRepository{}, txProvider(), testConfig)
+			got, err := s.UpdatePreferences(ctx, req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Contains(t, got, response.NotificationPreference{Category: "marketing", Channel: "smtp", Enabled: true})
+			}
+		})
+	}
+}
+
+func Test_preferenceService_Unsubscribe(t *testing.T) {
+	signer := preference.NewPreferenceService(&mocks.IPreferenceRepository{}, &mocks.ICustomerRepository{}, &mocks.ITransactionProvider{}, testConfig)
+	link, _ := url.Parse(signer.UnsubscribeURL(1, "marketing", "smtp"))
+	token := link.Query().Get("token")
+
+	tests := []struct {
+		name                 string
+		token                string
+		customerRepository   *mocks.ICustomerRepository
+		preferenceRepository *mocks.IPreferenceRepository
+		want                 response.UnsubscribeData
+		wantErr              error
+	}{
+		{
+			name:  "best case",
+			token: token,
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByID", mock.Anything, uint(1)).Return(&model.Customer{ID: 1}, nil)
+				return &m
+			}(),
+			preferenceRepository: func() *mocks.IPreferenceRepository {
+				m := mocks.IPreferenceRepository{}
+				m.On("SetPreference", mock.Anything, mock.Anything, mock.MatchedBy(func(p model.NotificationPreference) bool {
+					return p.CustomerID == 1 && p.Category == "marketing" && p.Channel == "smtp" && !p.Enabled
+				})).Return(nil)
+				return &m
+			}(),
+			want: response.UnsubscribeData{Category: "marketing", Channel: "smtp"},
+		},
+		{
+			name:                 "tampered token",
+			token:                strings.Replace(token, token[:2], "Mi", 1),
+			customerRepository:   &mocks.ICustomerRepository{},
+			preferenceRepository: &mocks.IPreferenceRepository{},
+			wantErr:              apperror.ErrValidation,
+		},
+		{
+			name:                 "signed with another secret",
+			token:                "MS5tYXJrZXRpbmcuc210cA.c2lnbmF0dXJl",
+			customerRepository:   &mocks.ICustomerRepository{},
+			preferenceRepository: &mocks.IPreferenceRepository{},
+			wantErr:              apperror.ErrValidation,
+		},
+		{
+			name:  "customer not found",
+			token: token,
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByID", mock.Anything, uint(1)).Return(nil, nil)
+				return &m
+			}(),
+			preferenceRepository: &mocks.IPreferenceRepository{},
+			wantErr:              apperror.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := preference.NewPreferenceService(tt.preferenceRepository, tt.customerRepository, txProvider(), testConfig)
+			got, err := s.Unsubscribe(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_preferenceService_Allowed(t *testing.T) {
+	preferenceRepository := &mocks.IPreferenceRepository{}
+	preferenceRepository.On("GetPreferences", mock.Anything, uint(1)).Return([]model.NotificationPreference{
+		{CustomerID: 1, Category: "transactional", Channel: "smtp", Enabled: false},
+	}, nil)
+
+	tests := []struct {
+		name     string
+		category string
+		channel  string
+		want     bool
+	}{
+		{name: "turned off", category: "transactional", channel: "smtp", want: false},
+		{name: "transactional by default", category: "transactional", channel: "webhook", want: true},
+		{name: "marketing needs an opt-in", category: "marketing", channel: "smtp", want: false},
+		{name: "channel not configured", category: "marketing", channel: "console", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := preference.NewPreferenceService(preferenceRepository, &mocks.ICustomerRepository{}, &mocks.ITransactionProvider{}, testConfig)
+			got, err := s.Allowed(context.Background(), 1, tt.category, tt.channel)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
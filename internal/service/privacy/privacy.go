@@ -0,0 +1,380 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/repository"
+	"ebookstore/internal/service"
+	"ebookstore/utils/config"
+	"ebookstore/utils/emailtemplate"
+	authentication "ebookstore/utils/middleware"
+	"ebookstore/utils/notification"
+	"ebookstore/utils/transactioner"
+	"encoding/json"
+	"fmt"
+)
+
+type privacyService struct {
+	customerRepository    repository.ICustomerRepository
+	mfaRepository         repository.IMFARepository
+	addressRepository     repository.IAddressRepository
+	orderRepository       repository.IOrderRepository
+	preferenceRepository  repository.IPreferenceRepository
+	idempotencyRepository repository.IIdempotencyRepository
+	TransactionProvider   transactioner.ITransactionProvider
+	customerService       service.ICustomerService
+	orderService          service.IOrderService
+	outboxService         service.IOutboxService
+	cfg                   *config.Config
+}
+
+func NewPrivacyService(customerRepository repository.ICustomerRepository, mfaRepository repository.IMFARepository, addressRepository repository.IAddressRepository, orderRepository repository.IOrderRepository, preferenceRepository repository.IPreferenceRepository, idempotencyRepository repository.IIdempotencyRepository, tx transactioner.ITransactionProvider, customerService service.ICustomerService, orderService service.IOrderService, outboxService service.IOutboxService, cfg *config.Config) service.IPrivacyService {
+	return &privacyService{
+		customerRepository:    customerRepository,
+		mfaRepository:         mfaRepository,
+		addressRepository:     addressRepository,
+		orderRepository:       orderRepository,
+		preferenceRepository:  preferenceRepository,
+		idempotencyRepository: idempotencyRepository,
+		TransactionProvider:   tx,
+		customerService:       customerService,
+		orderService:          orderService,
+		outboxService:         outboxService,
+		cfg:                   cfg,
+	}
+}
+
+// ExportData returns a zip archive with one JSON file per kind of personal
+// data held about the customer.
+func (s *privacyService) ExportData(ctx context.Context) ([]byte, error) {
+	customerID := ctx.Value("id").(uint)
+
+	customerDB, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	customerMFA, err := s.mfaRepository.GetMFAByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa status: %w", err)
+	}
+
+	identities, err := s.customerRepository.GetIdentitiesByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked identities: %w", err)
+	}
+
+	addresses, err := s.addressRepository.GetAddressesByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	orders, err := s.orderService.ExportOrders(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := s.preferenceRepository.GetPreferences(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", response.ProfileData{
+			ID:           customerDB.ID,
+			Username:     customerDB.Username,
+			Email:        customerDB.Email,
+			PendingEmail: customerDB.PendingEmail,
+			MFAEnabled:   customerMFA != nil && customerMFA.Enabled,
+		}},
+		{"mfa.json", exportMFA(customerMFA)},
+		{"identities.json", exportIdentities(identities)},
+		{"addresses.json", exportAddresses(addresses)},
+		{"orders.json", orders},
+		{"preferences.json", exportPreferences(preferences)},
+	}
+
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", file.name, err)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(file.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RequestAccountDeletion emails a confirmation token to customers without a
+// password, they have nothing else to confirm the deletion with.
+func (s *privacyService) RequestAccountDeletion(ctx context.Context) error {
+	customerID := ctx.Value("id").(uint)
+
+	customerDB, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	if customerDB.Password != "" {
+		return apperror.Conflict("confirm the deletion with your password")
+	}
+
+	if !s.cfg.Email.Enabled {
+		return apperror.Conflict("account deletion cannot be confirmed by email")
+	}
+
+	token, err := authentication.GenerateAccountDeletionToken(customerID)
+	if err != nil {
+		return fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+
+	content, err := emailtemplate.Render(emailtemplate.AccountDeletionConfirmation, s.cfg.Notification.Locale, emailtemplate.Confirmation{Name: customerDB.Username, Token: token})
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	msg := notification.Message{
+		Event:   "customer.deletion_requested",
+		To:      customerDB.Email,
+		Subject: content.Subject,
+		Body:    content.HTML,
+		Text:    content.Text,
+
+		CustomerID: customerID,
+		Category:   notification.CategoryAccount,
+	}
+
+	err = s.outboxService.Enqueue(ctx, tx, msg)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAccount anonymizes the customer and their orders in one transaction.
+// Orders keep their totals and items so accounting stays correct.
+func (s *privacyService) DeleteAccount(ctx context.Context, req request.DeleteAccount) error {
+	customerID := ctx.Value("id").(uint)
+
+	customerDB, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	err = s.confirmDeletion(customerDB, req)
+	if err != nil {
+		return err
+	}
+
+	//a stolen access token alone must not be enough to delete the account
+	err = s.customerService.VerifySecondFactor(ctx, customerID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.TransactionProvider.NewTransaction(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = s.orderRepository.AnonymizeOrdersByCustomerID(ctx, tx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize orders: %w", err)
+	}
+
+	err = s.addressRepository.DeleteAddressesByCustomerID(ctx, tx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete addresses: %w", err)
+	}
+
+	err = s.mfaRepository.DisableMFA(ctx, tx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete mfa: %w", err)
+	}
+
+	err = s.preferenceRepository.DeletePreferences(ctx, tx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification preferences: %w", err)
+	}
+
+	err = s.idempotencyRepository.DeleteIdempotencyKeysByCustomerID(ctx, tx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency keys: %w", err)
+	}
+
+	err = s.customerRepository.AnonymizeCustomer(ctx, tx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize customer: %w", err)
+	}
+
+	//queued and sent emails hold the name, address and order details
+	err = s.outboxService.DeleteCustomerMessages(ctx, tx, customerID)
+	if err != nil {
+		return err
+	}
+
+	//confirm to the address we are removing, the queued copy is all that is left of it
+	if s.cfg.Email.Enabled {
+		content, err := emailtemplate.Render(emailtemplate.AccountDeleted, s.cfg.Notification.Locale, emailtemplate.Account{Name: customerDB.Username})
+		if err != nil {
+			return err
+		}
+
+		msg := notification.Message{
+			Event:   "customer.deleted",
+			To:      customerDB.Email,
+			Subject: content.Subject,
+			Body:    content.HTML,
+			Text:    content.Text,
+			Data:    map[string]any{"customer_id": customerID},
+
+			CustomerID: customerID,
+			Category:   notification.CategoryAccount,
+		}
+
+		err = s.outboxService.Enqueue(ctx, tx, msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// confirmDeletion checks the password, or the emailed confirmation token for
+// accounts created through social login.
+func (s *privacyService) confirmDeletion(customerDB *model.Customer, req request.DeleteAccount) error {
+	if customerDB.Password != "" {
+		if !authentication.CompareHashedPassword(customerDB.Password, req.Password) {
+			return apperror.Forbidden("invalid password")
+		}
+
+		return nil
+	}
+
+	if req.ConfirmationToken == "" {
+		return apperror.Forbidden("confirmation token is required")
+	}
+
+	id, err := authentication.ParseAccountDeletionToken(req.ConfirmationToken)
+	if err != nil || id != customerDB.ID {
+		return apperror.Forbidden("invalid or expired confirmation token")
+	}
+
+	return nil
+}
+
+func (s *privacyService) getCustomer(ctx context.Context, customerID uint) (*model.Customer, error) {
+	customerDB, err := s.customerRepository.GetCustomerByID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	if customerDB == nil {
+		return nil, apperror.NotFound("customer not found")
+	}
+
+	return customerDB, nil
+}
+
+func exportMFA(customerMFA *model.CustomerMFA) response.ExportMFA {
+	if customerMFA == nil {
+		return response.ExportMFA{}
+	}
+
+	//the secret itself is never exported
+	data := response.ExportMFA{
+		Enabled:    customerMFA.Enabled,
+		EnrolledAt: &customerMFA.CreatedAt,
+	}
+	if customerMFA.EnabledAt.Valid {
+		data.EnabledAt = &customerMFA.EnabledAt.Time
+	}
+
+	return data
+}
+
+func exportIdentities(identities []model.CustomerIdentity) []response.ExportIdentity {
+	resp := []response.ExportIdentity{}
+	for _, identity := range identities {
+		resp = append(resp, response.ExportIdentity{
+			Issuer:   identity.Issuer,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+			LinkedAt: identity.CreatedAt,
+		})
+	}
+
+	return resp
+}
+
+func exportPreferences(preferences []model.NotificationPreference) []response.ExportPreference {
+	resp := []response.ExportPreference{}
+	for _, preference := range preferences {
+		resp = append(resp, response.ExportPreference{
+			Category:  preference.Category,
+			Channel:   preference.Channel,
+			Enabled:   preference.Enabled,
+			UpdatedAt: preference.UpdatedAt,
+		})
+	}
+
+	return resp
+}
+
+func exportAddresses(addresses []model.Address) []response.AddressData {
+	resp := []response.AddressData{}
+	for _, address := range addresses {
+		resp = append(resp, response.AddressData{
+			ID:           address.ID,
+			Label:        address.Label,
+			ReceiverName: address.ReceiverName,
+			Address:      address.Address,
+			City:         address.City,
+			District:     address.District,
+			PostalCode:   address.PostalCode,
+			IsDefault:    address.IsDefault,
+		})
+	}
+
+	return resp
+}
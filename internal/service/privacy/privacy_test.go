@@ -0,0 +1,465 @@
+package privacy_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/model/response"
+	"ebookstore/internal/repository/mocks"
+	"ebookstore/internal/service"
+	mocksService "ebookstore/internal/service/mocks"
+	"ebookstore/internal/service/privacy"
+	"ebookstore/utils/config"
+	authentication "ebookstore/utils/middleware"
+	"ebookstore/utils/notification"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testConfig keeps email enabled so the notification paths are exercised.
+var testConfig = func() *config.Config {
+	cfg := config.Default()
+	cfg.Email.Enabled = true
+	return &cfg
+}()
+
+const hashedPassword = "$2a$12$KptVrUIFh4qX5.b8fHNjK.n1U749q8q86DtGxUFbEwbSUymQ./zty"
+
+type fields struct {
+	customerRepository    *mocks.ICustomerRepository
+	mfaRepository         *mocks.IMFARepository
+	addressRepository     *mocks.IAddressRepository
+	orderRepository       *mocks.IOrderRepository
+	preferenceRepository  *mocks.IPreferenceRepository
+	idempotencyRepository *mocks.IIdempotencyRepository
+	TransactionProvider   *mocks.ITransactionProvider
+	customerService       *mocksService.ICustomerService
+	orderService          *mocksService.IOrderService
+	outboxService         *mocksService.IOutboxService
+}
+
+func newService(f fields) service.IPrivacyService {
+	return privacy.NewPrivacyService(f.customerRepository, f.mfaRepository, f.addressRepository, f.orderRepository, f.preferenceRepository, f.idempotencyRepository, f.TransactionProvider, f.customerService, f.orderService, f.outboxService, testConfig)
+}
+
+func Test_privacyService_ExportData(t *testing.T) {
+	id := uint(1)
+	ctx := context.WithValue(context.Background(), "id", id)
+
+	customer := &model.Customer{ID: id, Email: "mail@mail.com", Username: "username", Password: hashedPassword}
+	order := response.OrderData{
+		OrderID:      7,
+		ReceiverName: "receiver",
+		Address:      "address",
+		OrderDate:    time.Now().UTC(),
+		TotalItem:    2,
+		TotalPrice:   20,
+		Items:        []response.Item{{BookID: 3, Title: "title", Quantity: 2, Price: 10}},
+	}
+
+	tests := []struct {
+		name      string
+		fields    fields
+		wantFiles []string
+		wantErr   bool
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(customer, nil)
+					m.On("GetIdentitiesByCustomerID", mock.Anything, id).Return([]model.CustomerIdentity{{Issuer: "https://idp", Subject: "subject"}}, nil)
+					return &m
+				}(),
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, id).Return(&model.CustomerMFA{CustomerID: id, TOTPSecret: "SECRET", Enabled: true}, nil)
+					return &m
+				}(),
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressesByCustomerID", mock.Anything, id).Return([]model.Address{{ID: 1, Address: "address"}}, nil)
+					return &m
+				}(),
+				orderService: func() *mocksService.IOrderService {
+					m := mocksService.IOrderService{}
+					m.On("ExportOrders", mock.Anything, id).Return([]response.OrderData{order}, nil)
+					return &m
+				}(),
+				preferenceRepository: func() *mocks.IPreferenceRepository {
+					m := mocks.IPreferenceRepository{}
+					m.On("GetPreferences", mock.Anything, id).Return([]model.NotificationPreference{{CustomerID: id, Category: "marketing", Channel: "smtp"}}, nil)
+					return &m
+				}(),
+			},
+			wantFiles: []string{"profile.json", "mfa.json", "identities.json", "addresses.json", "orders.json", "preferences.json"},
+			wantErr:   false,
+		},
+		{
+			name: "customer not found",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(nil, nil)
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "ExportOrders error",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(customer, nil)
+					m.On("GetIdentitiesByCustomerID", mock.Anything, id).Return(nil, nil)
+					return &m
+				}(),
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("GetMFAByCustomerID", mock.Anything, id).Return(nil, nil)
+					return &m
+				}(),
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("GetAddressesByCustomerID", mock.Anything, id).Return(nil, nil)
+					return &m
+				}(),
+				orderService: func() *mocksService.IOrderService {
+					m := mocksService.IOrderService{}
+					m.On("ExportOrders", mock.Anything, id).Return(nil, errors.New("error"))
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newService(tt.fields).ExportData(ctx)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				return
+			}
+
+			archive, err := zip.NewReader(bytes.NewReader(got), int64(len(got)))
+			assert.NoError(t, err)
+
+			var names []string
+			contents := map[string][]byte{}
+			for _, f := range archive.File {
+				names = append(names, f.Name)
+				r, _ := f.Open()
+				contents[f.Name], _ = io.ReadAll(r)
+				r.Close()
+				assert.True(t, json.Valid(contents[f.Name]), f.Name)
+			}
+			assert.Equal(t, tt.wantFiles, names)
+			assert.NotContains(t, string(contents["mfa.json"]), "SECRET")
+			assert.NotContains(t, string(contents["profile.json"]), hashedPassword)
+			assert.Contains(t, string(contents["orders.json"]), `"title": "title"`)
+			assert.Contains(t, string(contents["preferences.json"]), `"category": "marketing"`)
+		})
+	}
+}
+
+func Test_privacyService_RequestAccountDeletion(t *testing.T) {
+	id := uint(1)
+	ctx := context.WithValue(context.Background(), "id", id)
+
+	txProvider := func() *mocks.ITransactionProvider {
+		m := mocks.ITransactionProvider{}
+		txProvide := mocks.TxxProvider{}
+		txProvide.On("Commit").Return(nil)
+		txProvide.On("Rollback").Return(nil)
+		m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+		return &m
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+	}{
+		{
+			name: "passwordless account",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(&model.Customer{ID: id, Email: "mail@mail.com", Username: "username"}, nil)
+					return &m
+				}(),
+				TransactionProvider: txProvider(),
+				outboxService: func() *mocksService.IOutboxService {
+					m := mocksService.IOutboxService{}
+					m.On("Enqueue", mock.Anything, mock.Anything, mock.MatchedBy(func(p notification.Message) bool {
+						return p.To == "mail@mail.com" && p.Event == "customer.deletion_requested"
+					})).Return(nil)
+					return &m
+				}(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "account with password",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(&model.Customer{ID: id, Email: "mail@mail.com", Password: hashedPassword}, nil)
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Enqueue error",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(&model.Customer{ID: id, Email: "mail@mail.com", Username: "username"}, nil)
+					return &m
+				}(),
+				TransactionProvider: txProvider(),
+				outboxService: func() *mocksService.IOutboxService {
+					m := mocksService.IOutboxService{}
+					m.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("error"))
+					return &m
+				}(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newService(tt.fields).RequestAccountDeletion(ctx)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_privacyService_DeleteAccount(t *testing.T) {
+	id := uint(1)
+	ctx := context.WithValue(context.Background(), "id", id)
+
+	customer := func() *model.Customer {
+		return &model.Customer{ID: id, Email: "mail@mail.com", Username: "username", Password: hashedPassword}
+	}
+
+	txProvider := func() *mocks.ITransactionProvider {
+		m := mocks.ITransactionProvider{}
+		txProvide := mocks.TxxProvider{}
+		txProvide.On("Commit").Return(nil)
+		txProvide.On("Rollback").Return(nil)
+		m.On("NewTransaction", mock.Anything).Return(&txProvide, nil)
+		return &m
+	}
+
+	passwordless := func() *model.Customer {
+		return &model.Customer{ID: id, Email: "mail@mail.com", Username: "username"}
+	}
+	confirmationToken, _ := authentication.GenerateAccountDeletionToken(id)
+	otherToken, _ := authentication.GenerateAccountDeletionToken(id + 1)
+
+	secondFactor := func(code string, err error) *mocksService.ICustomerService {
+		m := mocksService.ICustomerService{}
+		m.On("VerifySecondFactor", mock.Anything, id, code).Return(err)
+		return &m
+	}
+
+	deleted := func(customerDB *model.Customer, customerService *mocksService.ICustomerService) fields {
+		return fields{
+			TransactionProvider: txProvider(),
+			customerService:     customerService,
+			customerRepository: func() *mocks.ICustomerRepository {
+				m := mocks.ICustomerRepository{}
+				m.On("GetCustomerByID", mock.Anything, id).Return(customerDB, nil)
+				m.On("AnonymizeCustomer", mock.Anything, mock.Anything, id).Return(nil)
+				return &m
+			}(),
+			mfaRepository: func() *mocks.IMFARepository {
+				m := mocks.IMFARepository{}
+				m.On("DisableMFA", mock.Anything, mock.Anything, id).Return(nil)
+				return &m
+			}(),
+			preferenceRepository: func() *mocks.IPreferenceRepository {
+				m := mocks.IPreferenceRepository{}
+				m.On("DeletePreferences", mock.Anything, mock.Anything, id).Return(nil)
+				return &m
+			}(),
+			idempotencyRepository: func() *mocks.IIdempotencyRepository {
+				m := mocks.IIdempotencyRepository{}
+				m.On("DeleteIdempotencyKeysByCustomerID", mock.Anything, mock.Anything, id).Return(nil)
+				return &m
+			}(),
+			addressRepository: func() *mocks.IAddressRepository {
+				m := mocks.IAddressRepository{}
+				m.On("DeleteAddressesByCustomerID", mock.Anything, mock.Anything, id).Return(nil)
+				return &m
+			}(),
+			orderRepository: func() *mocks.IOrderRepository {
+				m := mocks.IOrderRepository{}
+				m.On("AnonymizeOrdersByCustomerID", mock.Anything, mock.Anything, id).Return(nil)
+				return &m
+			}(),
+			outboxService: func() *mocksService.IOutboxService {
+				m := mocksService.IOutboxService{}
+				m.On("DeleteCustomerMessages", mock.Anything, mock.Anything, id).Return(nil)
+				m.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				return &m
+			}(),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		req     request.DeleteAccount
+		wantErr bool
+	}{
+		{
+			name: "best case",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(customer(), nil)
+					m.On("AnonymizeCustomer", mock.Anything, mock.Anything, id).Return(nil)
+					return &m
+				}(),
+				mfaRepository: func() *mocks.IMFARepository {
+					m := mocks.IMFARepository{}
+					m.On("DisableMFA", mock.Anything, mock.Anything, id).Return(nil)
+					return &m
+				}(),
+				preferenceRepository: func() *mocks.IPreferenceRepository {
+					m := mocks.IPreferenceRepository{}
+					m.On("DeletePreferences", mock.Anything, mock.Anything, id).Return(nil)
+					return &m
+				}(),
+				idempotencyRepository: func() *mocks.IIdempotencyRepository {
+					m := mocks.IIdempotencyRepository{}
+					m.On("DeleteIdempotencyKeysByCustomerID", mock.Anything, mock.Anything, id).Return(nil)
+					return &m
+				}(),
+				addressRepository: func() *mocks.IAddressRepository {
+					m := mocks.IAddressRepository{}
+					m.On("DeleteAddressesByCustomerID", mock.Anything, mock.Anything, id).Return(nil)
+					return &m
+				}(),
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("AnonymizeOrdersByCustomerID", mock.Anything, mock.Anything, id).Return(nil)
+					return &m
+				}(),
+				TransactionProvider: txProvider(),
+				customerService:     secondFactor("", nil),
+				outboxService: func() *mocksService.IOutboxService {
+					m := mocksService.IOutboxService{}
+					deleted := m.On("DeleteCustomerMessages", mock.Anything, mock.Anything, id).Return(nil)
+					//the goodbye email is queued after the old messages are gone
+					m.On("Enqueue", mock.Anything, mock.Anything, mock.MatchedBy(func(p notification.Message) bool {
+						return p.To == "mail@mail.com"
+					})).Return(nil).NotBefore(deleted)
+					return &m
+				}(),
+			},
+			req:     request.DeleteAccount{Password: "password"},
+			wantErr: false,
+		},
+		{
+			name: "invalid password",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(customer(), nil)
+					return &m
+				}(),
+			},
+			req:     request.DeleteAccount{Password: "wrong"},
+			wantErr: true,
+		},
+		{
+			name:    "passwordless account with confirmation token",
+			fields:  deleted(passwordless(), secondFactor("", nil)),
+			req:     request.DeleteAccount{ConfirmationToken: confirmationToken},
+			wantErr: false,
+		},
+		{
+			name: "passwordless account without confirmation token",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(passwordless(), nil)
+					return &m
+				}(),
+			},
+			req:     request.DeleteAccount{},
+			wantErr: true,
+		},
+		{
+			name: "confirmation token of another customer",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(passwordless(), nil)
+					return &m
+				}(),
+			},
+			req:     request.DeleteAccount{ConfirmationToken: otherToken},
+			wantErr: true,
+		},
+		{
+			name:    "mfa code",
+			fields:  deleted(customer(), secondFactor("123456", nil)),
+			req:     request.DeleteAccount{Password: "password", Code: "123456"},
+			wantErr: false,
+		},
+		{
+			name: "invalid mfa code",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(customer(), nil)
+					return &m
+				}(),
+				customerService: secondFactor("000000", apperror.Unauthorized("invalid mfa code")),
+			},
+			req:     request.DeleteAccount{Password: "password", Code: "000000"},
+			wantErr: true,
+		},
+		{
+			name: "AnonymizeOrdersByCustomerID error",
+			fields: fields{
+				customerRepository: func() *mocks.ICustomerRepository {
+					m := mocks.ICustomerRepository{}
+					m.On("GetCustomerByID", mock.Anything, id).Return(customer(), nil)
+					return &m
+				}(),
+				orderRepository: func() *mocks.IOrderRepository {
+					m := mocks.IOrderRepository{}
+					m.On("AnonymizeOrdersByCustomerID", mock.Anything, mock.Anything, id).Return(errors.New("error"))
+					return &m
+				}(),
+				TransactionProvider: txProvider(),
+				customerService:     secondFactor("", nil),
+			},
+			req:     request.DeleteAccount{Password: "password"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newService(tt.fields).DeleteAccount(ctx, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
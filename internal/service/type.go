@@ -4,18 +4,88 @@ import (
 	"context"
 	"ebookstore/internal/model/request"
 	"ebookstore/internal/model/response"
+	"ebookstore/utils/notification"
+	"ebookstore/utils/transactioner"
 )
 
 type ICustomerService interface {
 	Register(ctx context.Context, customer request.Register) (string, error)
-	Login(ctx context.Context, customer request.Login) (string, error)
+	Login(ctx context.Context, customer request.Login) (response.LoginData, error)
+	VerifyMFALogin(ctx context.Context, req request.LoginMFA) (string, error)
+	OIDCLogin(ctx context.Context) (response.OIDCAuthorization, error)
+	OIDCCallback(ctx context.Context, req request.OIDCCallback) (response.LoginData, error)
+
+	GetProfile(ctx context.Context) (response.ProfileData, error)
+	UpdateProfile(ctx context.Context, req request.UpdateProfile) (response.ProfileData, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ChangePassword(ctx context.Context, req request.ChangePassword) error
+	CreateAdmin(ctx context.Context, req request.Register) (uint, error)
+	GetRole(ctx context.Context, id uint) (string, error)
+	IsActive(ctx context.Context, id uint) (bool, error)
+
+	EnrollMFA(ctx context.Context) (response.MFAEnrollmentData, error)
+	ActivateMFA(ctx context.Context, req request.MFACode) ([]string, error)
+	DisableMFA(ctx context.Context, req request.MFACode) error
+	VerifySecondFactor(ctx context.Context, customerID uint, code string) error
+}
+
+// IPreferenceService also implements notification.IPreferences, the
+// notification fan-out checks it before every send.
+type IPreferenceService interface {
+	GetPreferences(ctx context.Context) ([]response.NotificationPreference, error)
+	UpdatePreferences(ctx context.Context, req request.UpdateNotificationPreferences) ([]response.NotificationPreference, error)
+	Unsubscribe(ctx context.Context, token string) (response.UnsubscribeData, error)
+
+	Allowed(ctx context.Context, customerID uint, category, channel string) (bool, error)
+	UnsubscribeURL(customerID uint, category, channel string) string
+}
+
+type IHealthService interface {
+	Ready(ctx context.Context) (response.Readiness, bool)
+	SetShuttingDown()
+}
+
+type IPrivacyService interface {
+	ExportData(ctx context.Context) ([]byte, error)
+	RequestAccountDeletion(ctx context.Context) error
+	DeleteAccount(ctx context.Context, req request.DeleteAccount) error
+}
+
+type IAddressService interface {
+	GetAddresses(ctx context.Context) ([]response.AddressData, error)
+	CreateAddress(ctx context.Context, req request.Address) (response.AddressData, error)
+	UpdateAddress(ctx context.Context, id uint, req request.Address) (response.AddressData, error)
+	SetDefaultAddress(ctx context.Context, id uint) (response.AddressData, error)
+	DeleteAddress(ctx context.Context, id uint) error
 }
 
 type IOrderService interface {
-	CreateOrder(ctx context.Context, req request.CreateOrder) (response.CreateOrderData, error)
-	GetUserOrders(ctx context.Context) ([]response.OrderData, error)
+	CreateOrder(ctx context.Context, req request.CreateOrder, idempotencyKey string) (response.CreateOrderData, error)
+	PurgeIdempotencyKeys(ctx context.Context) (int64, error)
+	ValidateReference(ctx context.Context, value string) response.ReferenceValidation
+	GetUserOrders(ctx context.Context, req request.GetOrderHistory) ([]response.OrderData, string, error)
+	GetUserOrder(ctx context.Context, reference string) (response.OrderData, error)
+	ExportOrders(ctx context.Context, customerID uint) ([]response.OrderData, error)
+	ResendOrderConfirmation(ctx context.Context, orderID uint) error
+	PreviewOrderConfirmation(ctx context.Context, orderID uint, locale string) (response.EmailPreview, error)
 }
 
 type IBookService interface {
 	GetBooks(ctx context.Context) ([]response.Book, error)
+	SearchBooks(ctx context.Context, query string) ([]response.Book, error)
+	ImportBooks(ctx context.Context, books []request.ImportBook) (response.ImportBooksData, error)
+	ReindexSearch(ctx context.Context) (int64, error)
+	InvalidateCache(ctx context.Context, bookIDs ...uint)
+}
+
+type IOutboxService interface {
+	Enqueue(ctx context.Context, tx transactioner.TxxProvider, msg notification.Message) error
+	Run(ctx context.Context)
+	Dispatch(ctx context.Context) (int, error)
+
+	GetMessages(ctx context.Context, status string, limit int) ([]response.OutboxMessageData, error)
+	Replay(ctx context.Context, id uint) error
+
+	DeleteCustomerMessages(ctx context.Context, tx transactioner.TxxProvider, customerID uint) error
+	PurgeMessages(ctx context.Context) (int64, error)
 }
@@ -0,0 +1,183 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+var emailPattern = regexp.MustCompile(`^([a-zA-Z0-9_\-\.]+)@([a-zA-Z0-9-]+\.)+([a-zA-Z]{2,10})$`)
+
+// compiled regex params, tags are parsed once but rules get the raw param
+var patterns sync.Map
+
+func init() {
+	Register("required", required)
+	Register("required_without", requiredWithout)
+	Register("min", minimum)
+	Register("max", maximum)
+	Register("email", email)
+	Register("regex", match)
+	Register("password", password)
+}
+
+func required(f Field) string {
+	if isEmpty(f.Value) {
+		return "is required"
+	}
+
+	return ""
+}
+
+// requiredWithout makes the field required when the sibling field named in
+// the param is empty.
+func requiredWithout(f Field) string {
+	sibling, ok := f.Parent.Type().FieldByName(f.Param)
+	if !ok || !isEmpty(f.Parent.FieldByIndex(sibling.Index)) {
+		return ""
+	}
+
+	if isEmpty(f.Value) {
+		return fmt.Sprintf("is required when %s is empty", FieldName(sibling))
+	}
+
+	return ""
+}
+
+func minimum(f Field) string {
+	limit, err := strconv.ParseFloat(f.Param, 64)
+	if err != nil {
+		return "has an invalid min rule"
+	}
+
+	size, unit, ok := measure(f.Value)
+	if ok && size < limit {
+		return fmt.Sprintf("must be at least %s%s", f.Param, unit)
+	}
+
+	return ""
+}
+
+func maximum(f Field) string {
+	limit, err := strconv.ParseFloat(f.Param, 64)
+	if err != nil {
+		return "has an invalid max rule"
+	}
+
+	size, unit, ok := measure(f.Value)
+	if ok && size > limit {
+		return fmt.Sprintf("must be at most %s%s", f.Param, unit)
+	}
+
+	return ""
+}
+
+func email(f Field) string {
+	if f.Value.Kind() != reflect.String || !emailPattern.MatchString(f.Value.String()) {
+		return "must be a valid email address"
+	}
+
+	return ""
+}
+
+func match(f Field) string {
+	pattern, ok := patterns.Load(f.Param)
+	if !ok {
+		compiled, err := regexp.Compile(f.Param)
+		if err != nil {
+			return "has an invalid regex rule"
+		}
+		pattern, _ = patterns.LoadOrStore(f.Param, compiled)
+	}
+
+	if f.Value.Kind() != reflect.String || !pattern.(*regexp.Regexp).MatchString(f.Value.String()) {
+		return "has an invalid format"
+	}
+
+	return ""
+}
+
+// password requires at least 8 characters with a lowercase letter, an
+// uppercase letter, a digit and a special character.
+func password(f Field) string {
+	const message = "must be at least 8 characters in length and contain at least one lowercase letter, one uppercase letter, one digit, and one special character"
+
+	value := f.Value.String()
+	if utf8.RuneCountInString(value) < 8 {
+		return message
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSpecial bool
+	for _, c := range value {
+		switch {
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			hasSpecial = true
+		}
+	}
+
+	if !hasLower || !hasUpper || !hasDigit || !hasSpecial {
+		return message
+	}
+
+	return ""
+}
+
+// OneOf builds a rule accepting only the given values, compared case
+// insensitively.
+func OneOf(values ...string) Rule {
+	allowed := make(map[string]bool, len(values))
+	for _, value := range values {
+		allowed[strings.ToLower(value)] = true
+	}
+
+	message := "must be one of " + strings.Join(values, ", ")
+	return func(f Field) string {
+		if !allowed[strings.ToLower(f.Value.String())] {
+			return message
+		}
+
+		return ""
+	}
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// measure returns the length of strings and collections, or the number itself.
+func measure(v reflect.Value) (float64, string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters", true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), " items", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	default:
+		return 0, "", false
+	}
+}
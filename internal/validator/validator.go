@@ -0,0 +1,213 @@
+// Package validator checks request structs against the rules in their
+// validate tags, e.g.
+//
+//	Username string `json:"username" validate:"required,min=4,max=16,regex=^\w+$"`
+//
+// Rules run in order and the first violation of a field is reported. Every
+// field is checked, so the client gets all the problems at once. Structs and
+// slices of structs are checked recursively, their fields are reported as
+// items[0].quantity.
+//
+// omitempty skips the remaining rules of an empty field. regex takes the rest
+// of the tag as its pattern, so it has to come last.
+package validator
+
+import (
+	"ebookstore/internal/apperror"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Field is the value a rule checks.
+type Field struct {
+	Value reflect.Value
+	Param string
+	// Parent is the struct holding the field, for rules comparing fields.
+	Parent reflect.Value
+}
+
+// Rule returns the violation message, or an empty string when the value is valid.
+type Rule func(f Field) string
+
+var (
+	mu    sync.RWMutex
+	rules = map[string]Rule{}
+
+	// parsed tags by struct type
+	cache sync.Map
+)
+
+// Register adds or replaces the rule used for name in validate tags.
+func Register(name string, rule Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+	rules[name] = rule
+}
+
+func lookup(name string) (Rule, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	rule, ok := rules[name]
+	return rule, ok
+}
+
+type check struct {
+	name  string
+	param string
+}
+
+type structField struct {
+	index  int
+	name   string
+	checks []check
+}
+
+// Struct validates v, a struct or a pointer to one. It returns an
+// apperror.Validation listing every invalid field, or nil.
+func Struct(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validator: expected a struct, got %s", value.Kind())
+	}
+
+	var violations []apperror.FieldError
+	err := validateStruct(value, "", &violations)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.Field+" "+violation.Message)
+	}
+
+	return apperror.Validation(strings.Join(messages, "; "), violations...)
+}
+
+func validateStruct(value reflect.Value, prefix string, violations *[]apperror.FieldError) error {
+	for _, sf := range fields(value.Type()) {
+		fieldValue := value.Field(sf.index)
+		name := prefix + sf.name
+
+		message, err := runChecks(sf.checks, Field{Value: fieldValue, Parent: value})
+		if err != nil {
+			return err
+		}
+
+		if message != "" {
+			*violations = append(*violations, apperror.FieldError{Field: name, Message: message})
+			continue
+		}
+
+		err = validateNested(fieldValue, name, violations)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateNested(value reflect.Value, name string, violations *[]apperror.FieldError) error {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return validateNested(value.Elem(), name, violations)
+	case reflect.Struct:
+		return validateStruct(value, name+".", violations)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			err := validateNested(value.Index(i), fmt.Sprintf("%s[%d]", name, i), violations)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func runChecks(checks []check, f Field) (string, error) {
+	for _, c := range checks {
+		if c.name == "omitempty" {
+			if f.Value.IsZero() {
+				return "", nil
+			}
+			continue
+		}
+
+		rule, ok := lookup(c.name)
+		if !ok {
+			return "", fmt.Errorf("validator: unknown rule %q", c.name)
+		}
+
+		f.Param = c.param
+		if message := rule(f); message != "" {
+			return message, nil
+		}
+	}
+
+	return "", nil
+}
+
+// fields parses the validate tags of t once.
+func fields(t reflect.Type) []structField {
+	if cached, ok := cache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	var parsed []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		parsed = append(parsed, structField{
+			index:  i,
+			name:   FieldName(sf),
+			checks: parseTag(sf.Tag.Get("validate")),
+		})
+	}
+
+	cache.Store(t, parsed)
+	return parsed
+}
+
+func parseTag(tag string) []check {
+	var checks []check
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(part, "=")
+		checks = append(checks, check{name: strings.TrimSpace(name), param: param})
+	}
+
+	return checks
+}
+
+// FieldName is the name a struct field is reported under, the one the client
+// sent it with.
+func FieldName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "query"} {
+		name, _, _ := strings.Cut(sf.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return strings.ToLower(sf.Name)
+}
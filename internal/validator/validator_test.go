@@ -0,0 +1,141 @@
+package validator_test
+
+import (
+	"ebookstore/internal/apperror"
+	"ebookstore/internal/model/request"
+	"ebookstore/internal/validator"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStruct(t *testing.T) {
+	validator.Register("shipper", validator.OneOf("JNE", "SiCepat"))
+
+	tests := []struct {
+		name       string
+		value      interface{}
+		wantFields []apperror.FieldError
+	}{
+		{
+			name: "valid register",
+			value: request.Register{
+				Email:    "email@mail.com",
+				Password: "Passw0rd.",
+				Username: "user_name",
+			},
+		},
+		{
+			name:  "every violation is reported",
+			value: request.Register{Username: "a b"},
+			wantFields: []apperror.FieldError{
+				{Field: "email", Message: "is required"},
+				{Field: "password", Message: "is required"},
+				{Field: "username", Message: "must be at least 4 characters"},
+			},
+		},
+		{
+			name: "regex and password",
+			value: request.Register{
+				Email:    "email@mail",
+				Password: "password",
+				Username: "user-name",
+			},
+			wantFields: []apperror.FieldError{
+				{Field: "email", Message: "must be a valid email address"},
+				{Field: "password", Message: "must be at least 8 characters in length and contain at least one lowercase letter, one uppercase letter, one digit, and one special character"},
+				{Field: "username", Message: "has an invalid format"},
+			},
+		},
+		{
+			name: "nested slice",
+			value: request.CreateOrder{
+				AddressID: 1,
+				Shipper:   "jne",
+				Items: []request.Item{
+					{BookID: 1, Quantity: 1},
+					{Quantity: -1},
+				},
+			},
+			wantFields: []apperror.FieldError{
+				{Field: "items[1].book_id", Message: "is required"},
+				{Field: "items[1].quantity", Message: "must be at least 1"},
+			},
+		},
+		{
+			name: "required without a saved address",
+			value: request.CreateOrder{
+				Shipper: "DHL",
+				Items:   []request.Item{{BookID: 1, Quantity: 1}},
+				City:    "city",
+			},
+			wantFields: []apperror.FieldError{
+				{Field: "address", Message: "is required when address_id is empty"},
+				{Field: "district", Message: "is required when address_id is empty"},
+				{Field: "postal_code", Message: "is required when address_id is empty"},
+				{Field: "shipper", Message: "must be one of JNE, SiCepat"},
+			},
+		},
+		{
+			name:  "omitempty",
+			value: request.UpdateProfile{Email: "new@mail.com"},
+		},
+		{
+			name:  "one of two fields",
+			value: request.UpdateProfile{},
+			wantFields: []apperror.FieldError{
+				{Field: "username", Message: "is required when email is empty"},
+				{Field: "email", Message: "is required when username is empty"},
+			},
+		},
+		{
+			name:  "fields without json tag",
+			value: &request.ImportBook{Title: "title", Author: "author", Category: "category"},
+			wantFields: []apperror.FieldError{
+				{Field: "price", Message: "must be at least 0.01"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Struct(tt.value)
+			if tt.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var appErr *apperror.Error
+			assert.ErrorIs(t, err, apperror.ErrValidation)
+			assert.True(t, errors.As(err, &appErr))
+			assert.Equal(t, tt.wantFields, appErr.Fields)
+		})
+	}
+}
+
+func TestStruct_UnknownRule(t *testing.T) {
+	err := validator.Struct(struct {
+		Name string `validate:"nope"`
+	}{})
+
+	assert.EqualError(t, err, `validator: unknown rule "nope"`)
+	assert.NotErrorIs(t, err, apperror.ErrValidation)
+}
+
+func TestRegister(t *testing.T) {
+	validator.Register("even", func(f validator.Field) string {
+		if f.Value.Int()%2 != 0 {
+			return "must be even"
+		}
+		return ""
+	})
+
+	type req struct {
+		Count int    `json:"count" validate:"even"`
+		Code  string `json:"code" validate:"regex=^[A-Z]{2,3}$"`
+	}
+
+	err := validator.Struct(req{Count: 3, Code: "ABCD"})
+	assert.EqualError(t, err, "count must be even; code has an invalid format")
+	assert.NoError(t, validator.Struct(req{Count: 4, Code: "AB"}))
+}
@@ -2,36 +2,89 @@ package main
 
 import (
 	"context"
-	"ebookstore/internal/httpservice"
-	"ebookstore/internal/repository"
-	"ebookstore/utils/config"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"strings"
 
-	"github.com/gofiber/fiber/v2"
-	"github.com/tanimutomo/sqlfile"
+	"ebookstore/utils/config"
+	"ebookstore/utils/logger"
 )
 
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{"serve", "start the HTTP API (default)", serve},
+	{"migrate", "up | down [n] | status, manage the database schema", migrate},
+	{"seed", "insert the seed data, safe to run repeatedly", seed},
+	{"create-admin", "--email --username --password, create or promote an admin account", createAdmin},
+	{"import-books", "--file books.csv, import books from a CSV file", importBooks},
+	{"reindex-search", "rebuild the book search index", reindexSearch},
+	{"invalidate-cache", "[--book <id>], drop cached books and categories on every instance (redis cache)", invalidateCache},
+	{"resend-notification", "--order <id>, send the order confirmation email again", resendNotification},
+	{"purge-idempotency-keys", "delete the expired idempotency keys of orders", purgeIdempotencyKeys},
+	{"purge-outbox", "delete sent and dead notifications past their retention", purgeOutbox},
+}
+
 func main() {
-	app := fiber.New()
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s "+
-		"password=%s dbname=%s sslmode=disable", config.Host, config.Port, config.User, config.Password, config.Dbname)
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		flag.Usage()
+		os.Exit(2)
+	}
 
-	db, err := repository.ConnectPostgres(context.Background(), psqlInfo)
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		panic(err)
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
 	}
-	defer db.Close()
 
-	s := sqlfile.New()
-	s.Directory("./db/migrations")
-	s.Directory("./db/seeds")
-	_, err = s.Exec(db.DB)
+	err = logger.Setup(cfg.Log)
 	if err != nil {
-		log.Printf("Failed to apply migrations & seeder, run manually from sql scripts in ./db: %v", err)
+		slog.Error("failed to set up logging", "error", err)
+		os.Exit(1)
 	}
 
-	httpservice.InitRoutes(app, db)
+	err = cmd.run(context.Background(), cfg, args[1:])
+	if err != nil {
+		slog.Error("command failed", "command", cmd.name, "error", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	var b strings.Builder
+	b.WriteString("Usage: ebookstore [-config file] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-20s %s\n", cmd.name, cmd.summary)
+	}
+	b.WriteString("\nRun ebookstore <command> -h for the flags of a command.\n\n")
+
+	fmt.Fprint(flag.CommandLine.Output(), b.String())
+	flag.PrintDefaults()
+}
 
-	app.Listen(":8080")
+// newFlagSet returns the flag set of a subcommand. Parse errors exit with the
+// command usage, like the global flags do.
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("ebookstore "+name, flag.ExitOnError)
 }
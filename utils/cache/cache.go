@@ -0,0 +1,37 @@
+// Package cache keeps values read from the database for a while, so hot lookups
+// such as books and categories do not hit the database on every request.
+//
+// Values expire after a TTL, the least recently used values are evicted when
+// the cache is full, and the services invalidate what they change. Keys are
+// strings, usually the formatted ID of the value.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// ICache is safe for concurrent use.
+type ICache[V any] interface {
+	Get(ctx context.Context, key string) (V, bool)
+	Set(ctx context.Context, key string, value V)
+	// GetOrLoad returns the cached value of key, or calls load and caches its
+	// result. Concurrent misses on the same key share a single load, and
+	// errors are returned without being cached.
+	GetOrLoad(ctx context.Context, key string, load Loader[V]) (V, error)
+	// Delete invalidates keys. A load in flight for one of them is not cached.
+	Delete(ctx context.Context, keys ...string)
+	// Purge invalidates every key.
+	Purge(ctx context.Context)
+}
+
+// Loader reads the value of a missing key from the source of truth.
+type Loader[V any] func(ctx context.Context) (V, error)
+
+type Options struct {
+	// Size is the number of values kept, spread evenly over the shards.
+	Size int
+	// Shards splits the cache so concurrent requests rarely wait on the same lock.
+	Shards int
+	TTL    time.Duration
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"ebookstore/utils/metrics"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type memory[V any] struct {
+	name   string
+	ttl    time.Duration
+	shards []*shard[V]
+	group  singleflight.Group
+	// generation changes on every invalidation, so a load started before it
+	// does not cache what may already be stale
+	generation atomic.Uint64
+}
+
+type shard[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	// recent holds the entries, the most recently used first
+	recent *list.List
+}
+
+type entry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// NewMemory returns a sharded LRU cache kept in the memory of the process.
+// name labels its hit and miss metrics.
+func NewMemory[V any](name string, opts Options) ICache[V] {
+	shards := max(min(opts.Shards, opts.Size), 1)
+	capacity := max(opts.Size/shards, 1)
+
+	c := &memory[V]{
+		name:   name,
+		ttl:    opts.TTL,
+		shards: make([]*shard[V], shards),
+	}
+	for i := range c.shards {
+		c.shards[i] = &shard[V]{
+			capacity: capacity,
+			items:    make(map[string]*list.Element),
+			recent:   list.New(),
+		}
+	}
+
+	return c
+}
+
+func (c *memory[V]) Get(ctx context.Context, key string) (V, bool) {
+	value, ok := c.shard(key).get(key, time.Now())
+	if ok {
+		metrics.CacheRequests.WithLabelValues(c.name, "hit").Inc()
+	} else {
+		metrics.CacheRequests.WithLabelValues(c.name, "miss").Inc()
+	}
+
+	return value, ok
+}
+
+func (c *memory[V]) Set(ctx context.Context, key string, value V) {
+	c.shard(key).set(key, value, time.Now().Add(c.ttl))
+}
+
+func (c *memory[V]) GetOrLoad(ctx context.Context, key string, load Loader[V]) (V, error) {
+	if value, ok := c.Get(ctx, key); ok {
+		return value, nil
+	}
+
+	result, err, _ := c.group.Do(key, func() (any, error) {
+		generation := c.generation.Load()
+
+		//the callers waiting on this load must not fail because the first one went away
+		value, err := load(context.WithoutCancel(ctx))
+		if err != nil {
+			return value, err
+		}
+
+		if c.generation.Load() == generation {
+			c.Set(ctx, key, value)
+		}
+		return value, nil
+	})
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	return result.(V), nil
+}
+
+func (c *memory[V]) Delete(ctx context.Context, keys ...string) {
+	c.generation.Add(1)
+	for _, key := range keys {
+		c.group.Forget(key)
+		c.shard(key).delete(key)
+	}
+}
+
+func (c *memory[V]) Purge(ctx context.Context) {
+	c.generation.Add(1)
+	for _, s := range c.shards {
+		s.purge()
+	}
+}
+
+func (c *memory[V]) shard(key string) *shard[V] {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return c.shards[h.Sum32()%uint32(len(c.shards))]
+}
+
+func (s *shard[V]) get(key string, now time.Time) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var zero V
+	element, ok := s.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := element.Value.(*entry[V])
+	if !now.Before(e.expiresAt) {
+		s.remove(element)
+		return zero, false
+	}
+
+	s.recent.MoveToFront(element)
+	return e.value, true
+}
+
+func (s *shard[V]) set(key string, value V, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		e := element.Value.(*entry[V])
+		e.value = value
+		e.expiresAt = expiresAt
+		s.recent.MoveToFront(element)
+		return
+	}
+
+	s.items[key] = s.recent.PushFront(&entry[V]{key: key, value: value, expiresAt: expiresAt})
+	if s.recent.Len() > s.capacity {
+		s.remove(s.recent.Back())
+	}
+}
+
+func (s *shard[V]) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.remove(element)
+	}
+}
+
+func (s *shard[V]) purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string]*list.Element)
+	s.recent.Init()
+}
+
+func (s *shard[V]) remove(element *list.Element) {
+	s.recent.Remove(element)
+	delete(s.items, element.Value.(*entry[V]).key)
+}
@@ -0,0 +1,206 @@
+package cache_test
+
+import (
+	"context"
+	"ebookstore/utils/cache"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_GetSet(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemory[string]("test", cache.Options{Size: 10, Shards: 2, TTL: time.Minute})
+
+	_, ok := c.Get(ctx, "1")
+	assert.False(t, ok)
+
+	c.Set(ctx, "1", "Fiction")
+	value, ok := c.Get(ctx, "1")
+	assert.True(t, ok)
+	assert.Equal(t, "Fiction", value)
+
+	c.Set(ctx, "1", "Poetry")
+	value, _ = c.Get(ctx, "1")
+	assert.Equal(t, "Poetry", value)
+}
+
+func TestMemory_TTL(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemory[string]("test", cache.Options{Size: 10, Shards: 1, TTL: 20 * time.Millisecond})
+
+	c.Set(ctx, "1", "Fiction")
+	time.Sleep(40 * time.Millisecond)
+
+	_, ok := c.Get(ctx, "1")
+	assert.False(t, ok)
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemory[int]("test", cache.Options{Size: 2, Shards: 1, TTL: time.Minute})
+
+	c.Set(ctx, "1", 1)
+	c.Set(ctx, "2", 2)
+	c.Get(ctx, "1")
+	c.Set(ctx, "3", 3)
+
+	_, ok := c.Get(ctx, "2")
+	assert.False(t, ok, "least recently used value should be evicted")
+	_, ok = c.Get(ctx, "1")
+	assert.True(t, ok)
+	_, ok = c.Get(ctx, "3")
+	assert.True(t, ok)
+}
+
+func TestMemory_DeleteAndPurge(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemory[int]("test", cache.Options{Size: 10, Shards: 4, TTL: time.Minute})
+
+	for i := 1; i <= 3; i++ {
+		c.Set(ctx, strconv.Itoa(i), i)
+	}
+
+	c.Delete(ctx, "1", "2")
+	_, ok := c.Get(ctx, "1")
+	assert.False(t, ok)
+	_, ok = c.Get(ctx, "3")
+	assert.True(t, ok)
+
+	c.Purge(ctx)
+	_, ok = c.Get(ctx, "3")
+	assert.False(t, ok)
+}
+
+func TestMemory_GetOrLoad(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("loads once and caches", func(t *testing.T) {
+		c := cache.NewMemory[string]("test", cache.Options{Size: 10, Shards: 1, TTL: time.Minute})
+		var calls int
+		load := func(ctx context.Context) (string, error) {
+			calls++
+			return "Fiction", nil
+		}
+
+		for i := 0; i < 3; i++ {
+			value, err := c.GetOrLoad(ctx, "1", load)
+			assert.NoError(t, err)
+			assert.Equal(t, "Fiction", value)
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		c := cache.NewMemory[string]("test", cache.Options{Size: 10, Shards: 1, TTL: time.Minute})
+		var calls int
+		load := func(ctx context.Context) (string, error) {
+			calls++
+			return "", errors.New("error")
+		}
+
+		_, err := c.GetOrLoad(ctx, "1", load)
+		assert.Error(t, err)
+		_, err = c.GetOrLoad(ctx, "1", load)
+		assert.Error(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("concurrent misses share one load", func(t *testing.T) {
+		c := cache.NewMemory[string]("test", cache.Options{Size: 10, Shards: 1, TTL: time.Minute})
+		var calls atomic.Int32
+		release := make(chan struct{})
+		load := func(ctx context.Context) (string, error) {
+			calls.Add(1)
+			<-release
+			return "Fiction", nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := c.GetOrLoad(ctx, "1", load)
+				assert.NoError(t, err)
+				assert.Equal(t, "Fiction", value)
+			}()
+		}
+
+		//let the goroutines pile up on the load before it returns
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("load in flight during delete is not cached", func(t *testing.T) {
+		c := cache.NewMemory[string]("test", cache.Options{Size: 10, Shards: 1, TTL: time.Minute})
+		loading := make(chan struct{})
+		release := make(chan struct{})
+
+		go func() {
+			<-loading
+			c.Delete(ctx, "1")
+			close(release)
+		}()
+
+		value, err := c.GetOrLoad(ctx, "1", func(ctx context.Context) (string, error) {
+			close(loading)
+			<-release
+			return "stale", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "stale", value)
+
+		_, ok := c.Get(ctx, "1")
+		assert.False(t, ok)
+	})
+
+	t.Run("load outlives a cancelled caller", func(t *testing.T) {
+		c := cache.NewMemory[string]("test", cache.Options{Size: 10, Shards: 1, TTL: time.Minute})
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		value, err := c.GetOrLoad(cancelled, "1", func(ctx context.Context) (string, error) {
+			return "Fiction", ctx.Err()
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Fiction", value)
+	})
+}
+
+func TestMemory_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemory[int]("test", cache.Options{Size: 64, Shards: 8, TTL: time.Minute})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := strconv.Itoa((g*i + i) % 100)
+				switch i % 5 {
+				case 0:
+					c.Set(ctx, key, i)
+				case 1:
+					c.Delete(ctx, key)
+				case 2:
+					c.GetOrLoad(ctx, key, func(ctx context.Context) (int, error) { return i, nil })
+				default:
+					c.Get(ctx, key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	c.Purge(ctx)
+}
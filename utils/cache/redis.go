@@ -0,0 +1,290 @@
+package cache
+
+import (
+	"context"
+	"ebookstore/utils/metrics"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix = "ebookstore:cache:"
+	// invalidationChannel carries the keys deleted by any instance, so the
+	// others drop them from their memory.
+	invalidationChannel = "ebookstore:cache:invalidate"
+	// retryAfter is how long Redis is left alone after a failure, the values
+	// are read from the database meanwhile.
+	retryAfter = time.Second
+)
+
+// Redis shares the caches of every instance through a Redis compatible server.
+// Each instance keeps the values it reads in memory too, and drops them when
+// another instance publishes an invalidation. Start Listen to receive them.
+//
+// Redis is never required: when it cannot be reached the caches behave like
+// the memory cache and the values are loaded from the database.
+type Redis struct {
+	client redis.UniversalClient
+
+	mu sync.RWMutex
+	// locals are the memory caches of this instance by cache name
+	locals map[string]func(ctx context.Context, keys []string)
+
+	// downUntil is when Redis is tried again after a failure, in unix nanoseconds
+	downUntil atomic.Int64
+}
+
+type invalidation struct {
+	Cache string `json:"cache"`
+	// Keys is empty when every key of the cache is invalidated.
+	Keys []string `json:"keys,omitempty"`
+}
+
+type redisCache[V any] struct {
+	redis *Redis
+	name  string
+	ttl   time.Duration
+	local ICache[V]
+}
+
+func NewRedis(client redis.UniversalClient) *Redis {
+	return &Redis{
+		client: client,
+		locals: make(map[string]func(ctx context.Context, keys []string)),
+	}
+}
+
+// NewRedisCache returns the cache called name, stored in Redis and in memory.
+// Every instance has to use the same name for the same values.
+func NewRedisCache[V any](r *Redis, name string, opts Options) ICache[V] {
+	c := &redisCache[V]{
+		redis: r,
+		name:  name,
+		ttl:   opts.TTL,
+		local: NewMemory[V](name, opts),
+	}
+
+	r.mu.Lock()
+	r.locals[name] = c.dropLocal
+	r.mu.Unlock()
+
+	return c
+}
+
+// Listen applies the invalidations published by the other instances until ctx
+// is done. The memory caches are emptied whenever the subscription is
+// (re)established, as invalidations may have been missed meanwhile.
+func (r *Redis) Listen(ctx context.Context) {
+	pubsub := r.client.Subscribe(ctx, invalidationChannel)
+	//Receive does not watch ctx, closing the subscription unblocks it
+	context.AfterFunc(ctx, func() { pubsub.Close() })
+
+	for {
+		received, err := pubsub.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			r.fail(ctx, "failed to receive cache invalidations", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryAfter):
+			}
+			continue
+		}
+
+		switch msg := received.(type) {
+		case *redis.Subscription:
+			r.dropAll(ctx)
+		case *redis.Message:
+			var inv invalidation
+			err := json.Unmarshal([]byte(msg.Payload), &inv)
+			if err != nil {
+				slog.WarnContext(ctx, "invalid cache invalidation", "payload", msg.Payload, "error", err)
+				continue
+			}
+
+			r.mu.RLock()
+			drop, ok := r.locals[inv.Cache]
+			r.mu.RUnlock()
+			if ok {
+				drop(ctx, inv.Keys)
+			}
+		}
+	}
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+func (r *Redis) available() bool {
+	return time.Now().UnixNano() >= r.downUntil.Load()
+}
+
+func (r *Redis) fail(ctx context.Context, msg string, err error) {
+	r.downUntil.Store(time.Now().Add(retryAfter).UnixNano())
+	slog.WarnContext(ctx, msg, "error", err)
+}
+
+func (r *Redis) publish(ctx context.Context, inv invalidation) {
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode cache invalidation", "error", err)
+		return
+	}
+
+	err = r.client.Publish(ctx, invalidationChannel, payload).Err()
+	if err != nil {
+		r.fail(ctx, "failed to publish cache invalidation", err)
+	}
+}
+
+func (r *Redis) dropAll(ctx context.Context) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, drop := range r.locals {
+		drop(ctx, nil)
+	}
+}
+
+func (c *redisCache[V]) Get(ctx context.Context, key string) (V, bool) {
+	if value, ok := c.local.Get(ctx, key); ok {
+		return value, true
+	}
+
+	value, ok := c.getRemote(ctx, key)
+	if ok {
+		c.local.Set(ctx, key, value)
+	}
+
+	return value, ok
+}
+
+func (c *redisCache[V]) Set(ctx context.Context, key string, value V) {
+	c.local.Set(ctx, key, value)
+	c.setRemote(ctx, key, value)
+}
+
+func (c *redisCache[V]) GetOrLoad(ctx context.Context, key string, load Loader[V]) (V, error) {
+	//the memory cache makes concurrent misses of this instance share one load
+	return c.local.GetOrLoad(ctx, key, func(ctx context.Context) (V, error) {
+		if value, ok := c.getRemote(ctx, key); ok {
+			return value, nil
+		}
+
+		value, err := load(ctx)
+		if err != nil {
+			return value, err
+		}
+
+		c.setRemote(ctx, key, value)
+		return value, nil
+	})
+}
+
+func (c *redisCache[V]) Delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	//invalidations are rare and tried even while Redis is considered down
+	c.local.Delete(ctx, keys...)
+	remoteKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		remoteKeys = append(remoteKeys, c.key(key))
+	}
+
+	err := c.redis.client.Del(ctx, remoteKeys...).Err()
+	if err != nil {
+		c.redis.fail(ctx, "failed to delete cached values", err)
+	}
+
+	//the other instances drop their copy even when the shared one is left behind
+	c.redis.publish(ctx, invalidation{Cache: c.name, Keys: keys})
+}
+
+func (c *redisCache[V]) Purge(ctx context.Context) {
+	c.local.Purge(ctx)
+	iter := c.redis.client.Scan(ctx, 0, c.key("*"), 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	err := iter.Err()
+	if err == nil && len(keys) > 0 {
+		err = c.redis.client.Del(ctx, keys...).Err()
+	}
+	if err != nil {
+		c.redis.fail(ctx, "failed to purge cached values", err)
+	}
+
+	c.redis.publish(ctx, invalidation{Cache: c.name})
+}
+
+func (c *redisCache[V]) key(key string) string {
+	return keyPrefix + c.name + ":" + key
+}
+
+func (c *redisCache[V]) dropLocal(ctx context.Context, keys []string) {
+	if len(keys) == 0 {
+		c.local.Purge(ctx)
+		return
+	}
+
+	c.local.Delete(ctx, keys...)
+}
+
+func (c *redisCache[V]) getRemote(ctx context.Context, key string) (V, bool) {
+	var value V
+	if !c.redis.available() {
+		metrics.CacheRequests.WithLabelValues(c.name+"_redis", "error").Inc()
+		return value, false
+	}
+
+	payload, err := c.redis.client.Get(ctx, c.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		metrics.CacheRequests.WithLabelValues(c.name+"_redis", "miss").Inc()
+		return value, false
+	}
+	if err != nil {
+		metrics.CacheRequests.WithLabelValues(c.name+"_redis", "error").Inc()
+		c.redis.fail(ctx, "failed to get cached value", err)
+		return value, false
+	}
+
+	//e.g. written by a version with another shape, it is loaded again
+	err = json.Unmarshal(payload, &value)
+	if err != nil {
+		metrics.CacheRequests.WithLabelValues(c.name+"_redis", "miss").Inc()
+		return value, false
+	}
+
+	metrics.CacheRequests.WithLabelValues(c.name+"_redis", "hit").Inc()
+	return value, true
+}
+
+func (c *redisCache[V]) setRemote(ctx context.Context, key string, value V) {
+	if !c.redis.available() {
+		return
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode cached value", "cache", c.name, "error", err)
+		return
+	}
+
+	err = c.redis.client.Set(ctx, c.key(key), payload, c.ttl).Err()
+	if err != nil {
+		c.redis.fail(ctx, "failed to cache value", err)
+	}
+}
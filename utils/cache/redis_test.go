@@ -0,0 +1,197 @@
+package cache_test
+
+import (
+	"context"
+	"ebookstore/utils/cache"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	miniredisserver "github.com/alicebob/miniredis/v2/server"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+type book struct {
+	ID    uint
+	Title string
+	Price float64
+}
+
+var redisOptions = cache.Options{Size: 10, Shards: 1, TTL: time.Minute}
+
+// newInstance returns the book cache of one instance connected to server.
+func newInstance(t *testing.T, server *miniredis.Miniredis) (*cache.Redis, cache.ICache[book]) {
+	r := cache.NewRedis(redis.NewClient(&redis.Options{
+		Addr:        server.Addr(),
+		DialTimeout: 100 * time.Millisecond,
+		ReadTimeout: 100 * time.Millisecond,
+	}))
+	t.Cleanup(func() { r.Close() })
+
+	return r, cache.NewRedisCache[book](r, "books", redisOptions)
+}
+
+// listen starts the invalidation listener of r and waits until it is subscribed.
+func listen(t *testing.T, server *miniredis.Miniredis, r *cache.Redis) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Listen(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	assert.Eventually(t, func() bool {
+		return server.PubSubNumSub("ebookstore:cache:invalidate")["ebookstore:cache:invalidate"] > 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func loadBook(price float64) cache.Loader[book] {
+	return func(ctx context.Context) (book, error) {
+		return book{ID: 1, Title: "The Hobbit", Price: price}, nil
+	}
+}
+
+func failLoad(ctx context.Context) (book, error) {
+	return book{}, errors.New("database should not be read")
+}
+
+func TestRedis_SharesValuesBetweenInstances(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	_, first := newInstance(t, server)
+	_, second := newInstance(t, server)
+
+	value, err := first.GetOrLoad(ctx, "1", loadBook(20))
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, value.Price)
+
+	value, err = second.GetOrLoad(ctx, "1", failLoad)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, value.Price)
+
+	assert.True(t, server.Exists("ebookstore:cache:books:1"))
+	assert.Equal(t, time.Minute, server.TTL("ebookstore:cache:books:1"))
+}
+
+func TestRedis_InvalidatesEveryInstance(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	firstRedis, first := newInstance(t, server)
+	secondRedis, second := newInstance(t, server)
+	listen(t, server, firstRedis)
+	listen(t, server, secondRedis)
+
+	_, err := first.GetOrLoad(ctx, "1", loadBook(20))
+	assert.NoError(t, err)
+	_, err = second.GetOrLoad(ctx, "1", failLoad)
+	assert.NoError(t, err)
+
+	//the price changed in the database
+	first.Delete(ctx, "1")
+
+	assert.False(t, server.Exists("ebookstore:cache:books:1"))
+	assert.Eventually(t, func() bool {
+		value, err := second.GetOrLoad(ctx, "1", loadBook(25))
+		return err == nil && value.Price == 25
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestRedis_PurgeInvalidatesEveryInstance(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	_, first := newInstance(t, server)
+	secondRedis, second := newInstance(t, server)
+	listen(t, server, secondRedis)
+
+	second.Set(ctx, "1", book{ID: 1, Price: 20})
+	second.Set(ctx, "2", book{ID: 2, Price: 30})
+
+	first.Purge(ctx)
+
+	assert.Empty(t, server.Keys())
+	assert.Eventually(t, func() bool {
+		_, ok := second.Get(ctx, "2")
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestRedis_InvalidatesEveryInstanceWhenDeleteFails(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	_, first := newInstance(t, server)
+	secondRedis, second := newInstance(t, server)
+	listen(t, server, secondRedis)
+
+	//the second instance only has its memory copies left
+	second.Set(ctx, "1", book{ID: 1, Price: 20})
+	second.Set(ctx, "2", book{ID: 2, Price: 30})
+	server.FlushAll()
+
+	//deleting fails, the invalidation can still be published
+	server.Server().SetPreHook(func(c *miniredisserver.Peer, cmd string, args ...string) bool {
+		if cmd == "DEL" || cmd == "SCAN" {
+			c.WriteError("ERR unavailable")
+			return true
+		}
+		return false
+	})
+
+	first.Delete(ctx, "1")
+	assert.Eventually(t, func() bool {
+		_, ok := second.Get(ctx, "1")
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+
+	first.Purge(ctx)
+	assert.Eventually(t, func() bool {
+		_, ok := second.Get(ctx, "2")
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestRedis_FallsBackWhenUnavailable(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	_, c := newInstance(t, server)
+	server.Close()
+
+	var loads int
+	load := func(ctx context.Context) (book, error) {
+		loads++
+		return book{ID: 1, Price: 20}, nil
+	}
+
+	value, err := c.GetOrLoad(ctx, "1", load)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, value.Price)
+
+	//kept in memory until Redis is back
+	value, err = c.GetOrLoad(ctx, "1", load)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, value.Price)
+	assert.Equal(t, 1, loads)
+
+	c.Delete(ctx, "1")
+	_, ok := c.Get(ctx, "1")
+	assert.False(t, ok)
+}
+
+func TestRedis_ReloadsUndecodableValues(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	_, c := newInstance(t, server)
+	server.Set("ebookstore:cache:books:1", "not json")
+
+	value, err := c.GetOrLoad(ctx, "1", loadBook(20))
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, value.Price)
+
+	stored, _ := server.Get("ebookstore:cache:books:1")
+	assert.JSONEq(t, `{"ID":1,"Title":"The Hobbit","Price":20}`, stored)
+}
@@ -1,16 +1,562 @@
 package config
 
-const (
-	Host     = "postgres"
-	Port     = 5432
-	User     = "root"
-	Password = "password"
-	Dbname   = "book_db"
-
-	CONFIG_EMAIL_SERVICE = true
-	CONFIG_SMTP_HOST     = "smtp.gmail.com"
-	CONFIG_SMTP_PORT     = 587
-	CONFIG_SENDER_NAME   = "alfauzi.hamzah@gmail.com"
-	CONFIG_AUTH_EMAIL    = "alfauzi.hamzah@gmail.com"
-	CONFIG_AUTH_PASSWORD = "spyqlrbuvgxfaayj"
+import (
+	"ebookstore/utils/emailtemplate"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
+
+// Config holds every setting of the service. It is loaded once at startup by
+// Load and handed to the services that need it.
+type Config struct {
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Email    EmailConfig    `yaml:"email" toml:"email"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	OIDC     OIDCConfig     `yaml:"oidc" toml:"oidc"`
+	Tracing  TracingConfig  `yaml:"tracing" toml:"tracing"`
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	Order    OrderConfig    `yaml:"order" toml:"order"`
+	Outbox   OutboxConfig   `yaml:"outbox" toml:"outbox"`
+	Cache    CacheConfig    `yaml:"cache" toml:"cache"`
+
+	Notification NotificationConfig `yaml:"notification" toml:"notification"`
+}
+
+type ServerConfig struct {
+	Port    int    `yaml:"port" toml:"port" env:"SERVER_PORT"`
+	BaseURL string `yaml:"base_url" toml:"base_url" env:"BASE_URL"`
+	// ShutdownTimeout bounds how long a stopping server waits for in-flight
+	// requests, and then for the notification batch being sent.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// ShutdownDelay keeps serving with /readyz failing before the shutdown
+	// starts, so the load balancer has time to stop routing traffic here.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"ssl_mode" toml:"ssl_mode" env:"DB_SSL_MODE"`
+}
+
+type EmailConfig struct {
+	// Enabled turns every notification on, whatever the channels.
+	Enabled  bool   `yaml:"enabled" toml:"enabled" env:"EMAIL_ENABLED"`
+	SMTPHost string `yaml:"smtp_host" toml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort int    `yaml:"smtp_port" toml:"smtp_port" env:"SMTP_PORT"`
+	// SMTPTLS is starttls, implicit or none.
+	SMTPTLS    string `yaml:"smtp_tls" toml:"smtp_tls" env:"SMTP_TLS"`
+	SenderName string `yaml:"sender_name" toml:"sender_name" env:"SMTP_SENDER_NAME"`
+	// From defaults to AuthEmail. Without AuthEmail the server is used without login.
+	From         string `yaml:"from" toml:"from" env:"SMTP_FROM"`
+	AuthEmail    string `yaml:"auth_email" toml:"auth_email" env:"SMTP_AUTH_EMAIL"`
+	AuthPassword string `yaml:"auth_password" toml:"auth_password" env:"SMTP_AUTH_PASSWORD"`
+	// HealthCheck adds SMTP reachability to /readyz.
+	HealthCheck bool `yaml:"health_check" toml:"health_check" env:"SMTP_HEALTH_CHECK"`
+}
+
+type AuthConfig struct {
+	JWTSecret string `yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET"`
+	MFAIssuer string `yaml:"mfa_issuer" toml:"mfa_issuer" env:"MFA_ISSUER"`
+	// MFAMaxAttempts wrong codes in a row lock the second factor for MFALockout.
+	MFAMaxAttempts int           `yaml:"mfa_max_attempts" toml:"mfa_max_attempts" env:"MFA_MAX_ATTEMPTS"`
+	MFALockout     time.Duration `yaml:"mfa_lockout" toml:"mfa_lockout" env:"MFA_LOCKOUT"`
+}
+
+type OIDCConfig struct {
+	Enabled      bool   `yaml:"enabled" toml:"enabled" env:"OIDC_ENABLED"`
+	IssuerURL    string `yaml:"issuer_url" toml:"issuer_url" env:"OIDC_ISSUER_URL"`
+	ClientID     string `yaml:"client_id" toml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string `yaml:"redirect_url" toml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp.
+	Exporter    string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint    string `yaml:"endpoint" toml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME"`
+}
+
+type LogConfig struct {
+	// Level is debug, info, warn or error.
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// Format is json or text.
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+type OrderConfig struct {
+	// Shippers lists the accepted shipper codes, comma separated in the environment.
+	Shippers []string `yaml:"shippers" toml:"shippers" env:"ORDER_SHIPPERS"`
+	// IdempotencyTTL is how long a retried order with the same Idempotency-Key
+	// gets the response of the first one.
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl" toml:"idempotency_ttl" env:"ORDER_IDEMPOTENCY_TTL"`
+}
+
+type NotificationConfig struct {
+	// Channels lists where every notification goes: smtp, webhook, file or
+	// console, comma separated in the environment.
+	Channels   []string `yaml:"channels" toml:"channels" env:"NOTIFICATION_CHANNELS"`
+	WebhookURL string   `yaml:"webhook_url" toml:"webhook_url" env:"NOTIFICATION_WEBHOOK_URL"`
+	// WebhookSecret signs the webhook body with HMAC-SHA256 when set.
+	WebhookSecret string `yaml:"webhook_secret" toml:"webhook_secret" env:"NOTIFICATION_WEBHOOK_SECRET"`
+	FilePath      string `yaml:"file_path" toml:"file_path" env:"NOTIFICATION_FILE_PATH"`
+	// Locale picks the language of the emails, en or id.
+	Locale string `yaml:"locale" toml:"locale" env:"NOTIFICATION_LOCALE"`
+	// Currency is printed before the prices in the emails.
+	Currency string `yaml:"currency" toml:"currency" env:"NOTIFICATION_CURRENCY"`
+}
+
+// OutboxConfig tunes the dispatcher sending the queued notifications. A failed
+// send is retried after BaseBackoff, doubling up to MaxBackoff, until
+// MaxAttempts is reached and the message is marked dead.
+type OutboxConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"OUTBOX_POLL_INTERVAL"`
+	BatchSize    int           `yaml:"batch_size" toml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+	MaxAttempts  int           `yaml:"max_attempts" toml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" toml:"base_backoff" env:"OUTBOX_BASE_BACKOFF"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"OUTBOX_MAX_BACKOFF"`
+	// Retention is how long sent and dead messages are kept before
+	// purge-outbox deletes them, they hold the rendered emails.
+	Retention time.Duration `yaml:"retention" toml:"retention" env:"OUTBOX_RETENTION"`
+}
+
+// CacheConfig sizes the caches of books and categories. Each cache keeps up to
+// Size values in memory for at most TTL.
+type CacheConfig struct {
+	// Backend is memory, or redis to share the caches and their invalidations
+	// between instances.
+	Backend string        `yaml:"backend" toml:"backend" env:"CACHE_BACKEND"`
+	Size    int           `yaml:"size" toml:"size" env:"CACHE_SIZE"`
+	Shards  int           `yaml:"shards" toml:"shards" env:"CACHE_SHARDS"`
+	TTL     time.Duration `yaml:"ttl" toml:"ttl" env:"CACHE_TTL"`
+	// RedisURL is e.g. redis://:password@redis:6379/0.
+	RedisURL string `yaml:"redis_url" toml:"redis_url" env:"REDIS_URL"`
+	// RedisTimeout bounds every Redis call, past it the value is read from
+	// the database.
+	RedisTimeout time.Duration `yaml:"redis_timeout" toml:"redis_timeout" env:"REDIS_TIMEOUT"`
+}
+
+// Default returns the settings used when neither the file nor the environment
+// sets a value. Secrets have no default.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            8080,
+			BaseURL:         "http://localhost:8080",
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Host:    "postgres",
+			Port:    5432,
+			User:    "root",
+			Name:    "book_db",
+			SSLMode: "disable",
+		},
+		Email: EmailConfig{
+			SMTPHost: "smtp.gmail.com",
+			SMTPPort: 587,
+			SMTPTLS:  "starttls",
+		},
+		Auth: AuthConfig{
+			MFAIssuer:      "Ebookstore",
+			MFAMaxAttempts: 5,
+			MFALockout:     15 * time.Minute,
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:   "https://accounts.google.com",
+			RedirectURL: "http://localhost:8080/api/customer/oidc/callback",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "ebookstore",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Order: OrderConfig{
+			Shippers:       []string{"JNE", "JNT", "SiCepat", "AnterAja", "POS"},
+			IdempotencyTTL: 24 * time.Hour,
+		},
+		Outbox: OutboxConfig{
+			PollInterval: 5 * time.Second,
+			BatchSize:    20,
+			MaxAttempts:  8,
+			BaseBackoff:  30 * time.Second,
+			MaxBackoff:   time.Hour,
+			Retention:    30 * 24 * time.Hour,
+		},
+		Cache: CacheConfig{
+			Backend:      "memory",
+			Size:         10000,
+			Shards:       16,
+			TTL:          5 * time.Minute,
+			RedisTimeout: 100 * time.Millisecond,
+		},
+		Notification: NotificationConfig{
+			Channels: []string{"smtp"},
+			FilePath: "notifications.log",
+			Locale:   "en",
+			Currency: "IDR",
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the optional YAML or TOML
+// file at path and the environment, in that order, then validates it.
+//
+// Every setting can be overridden with the variable named in its env tag.
+// Secrets can also be read from a file by setting the same name with a _FILE
+// suffix, e.g. DB_PASSWORD_FILE=/run/secrets/db_password.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		err := loadFile(path, &cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := applyEnv(reflect.ValueOf(&cfg).Elem(), os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %s", err.Error())
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, cfg)
+	case ".toml":
+		err = toml.Unmarshal(content, cfg)
+	default:
+		return fmt.Errorf("unsupported config file type %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %s", path, err.Error())
+	}
+
+	return nil
+}
+
+// applyEnv walks the config struct and overrides every field that has an env
+// tag and a matching variable (or _FILE variable) set.
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			err := applyEnv(field, lookup)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		value, ok, err := lookupEnv(name, lookup)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		err = setField(field, value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func lookupEnv(name string, lookup func(string) (string, bool)) (string, bool, error) {
+	value, ok := lookup(name)
+	path, fileOK := lookup(name + "_FILE")
+
+	if ok && fileOK {
+		return "", false, fmt.Errorf("both %s and %s_FILE are set", name, name)
+	}
+
+	if fileOK {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read %s_FILE: %s", name, err.Error())
+		}
+
+		//secret files usually end with a newline
+		return strings.TrimRight(string(content), "\r\n"), true, nil
+	}
+
+	return value, ok, nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Kind())
+	}
+
+	return nil
+}
+
+// Validate reports every invalid setting at once so a broken deployment can be
+// fixed in one go.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		errs = append(errs, errors.New("server.port must be between 1 and 65535"))
+	}
+	if _, err := url.ParseRequestURI(c.Server.BaseURL); err != nil {
+		errs = append(errs, errors.New("server.base_url must be an absolute URL"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	if c.Server.ShutdownDelay < 0 {
+		errs = append(errs, errors.New("server.shutdown_delay cannot be negative"))
+	}
+
+	if c.Database.Host == "" {
+		errs = append(errs, errors.New("database.host is required"))
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		errs = append(errs, errors.New("database.port must be between 1 and 65535"))
+	}
+	if c.Database.User == "" {
+		errs = append(errs, errors.New("database.user is required"))
+	}
+	if c.Database.Name == "" {
+		errs = append(errs, errors.New("database.name is required"))
+	}
+
+	if c.Auth.JWTSecret == "" {
+		errs = append(errs, errors.New("auth.jwt_secret is required"))
+	}
+	if c.Auth.MFAIssuer == "" {
+		errs = append(errs, errors.New("auth.mfa_issuer is required"))
+	}
+	if c.Auth.MFAMaxAttempts < 1 {
+		errs = append(errs, errors.New("auth.mfa_max_attempts must be at least 1"))
+	}
+	if c.Auth.MFALockout <= 0 {
+		errs = append(errs, errors.New("auth.mfa_lockout must be positive"))
+	}
+
+	if !emailtemplate.HasLocale(c.Notification.Locale) {
+		errs = append(errs, fmt.Errorf("notification.locale has no email templates for %q", c.Notification.Locale))
+	}
+	if c.Email.Enabled {
+		errs = append(errs, c.validateNotification()...)
+	}
+
+	if c.OIDC.Enabled {
+		if c.OIDC.IssuerURL == "" {
+			errs = append(errs, errors.New("oidc.issuer_url is required when oidc is enabled"))
+		}
+		if c.OIDC.ClientID == "" {
+			errs = append(errs, errors.New("oidc.client_id is required when oidc is enabled"))
+		}
+		if c.OIDC.RedirectURL == "" {
+			errs = append(errs, errors.New("oidc.redirect_url is required when oidc is enabled"))
+		}
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if _, err := url.ParseRequestURI(c.Tracing.Endpoint); err != nil {
+			errs = append(errs, errors.New("tracing.endpoint must be an absolute URL when the otlp exporter is used"))
+		}
+	default:
+		errs = append(errs, errors.New("tracing.exporter must be none, stdout or otlp"))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, errors.New("log.level must be debug, info, warn or error"))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, errors.New("log.format must be json or text"))
+	}
+
+	if len(c.Order.Shippers) == 0 {
+		errs = append(errs, errors.New("order.shippers needs at least one shipper"))
+	}
+	if c.Order.IdempotencyTTL <= 0 {
+		errs = append(errs, errors.New("order.idempotency_ttl must be positive"))
+	}
+
+	if c.Outbox.PollInterval <= 0 {
+		errs = append(errs, errors.New("outbox.poll_interval must be positive"))
+	}
+	if c.Outbox.BatchSize < 1 {
+		errs = append(errs, errors.New("outbox.batch_size must be at least 1"))
+	}
+	if c.Outbox.MaxAttempts < 1 {
+		errs = append(errs, errors.New("outbox.max_attempts must be at least 1"))
+	}
+	if c.Outbox.BaseBackoff <= 0 {
+		errs = append(errs, errors.New("outbox.base_backoff must be positive"))
+	}
+	if c.Outbox.MaxBackoff < c.Outbox.BaseBackoff {
+		errs = append(errs, errors.New("outbox.max_backoff cannot be shorter than outbox.base_backoff"))
+	}
+	if c.Outbox.Retention <= 0 {
+		errs = append(errs, errors.New("outbox.retention must be positive"))
+	}
+
+	if c.Cache.Size < 1 {
+		errs = append(errs, errors.New("cache.size must be at least 1"))
+	}
+	if c.Cache.Shards < 1 {
+		errs = append(errs, errors.New("cache.shards must be at least 1"))
+	}
+	if c.Cache.TTL <= 0 {
+		errs = append(errs, errors.New("cache.ttl must be positive"))
+	}
+	switch c.Cache.Backend {
+	case "memory":
+	case "redis":
+		if _, err := url.Parse(c.Cache.RedisURL); err != nil || !strings.HasPrefix(c.Cache.RedisURL, "redis") {
+			errs = append(errs, errors.New("cache.redis_url must be a redis:// or rediss:// URL for the redis backend"))
+		}
+		if c.Cache.RedisTimeout <= 0 {
+			errs = append(errs, errors.New("cache.redis_timeout must be positive"))
+		}
+	default:
+		errs = append(errs, errors.New("cache.backend must be memory or redis"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+func (c *Config) validateNotification() []error {
+	var errs []error
+
+	if len(c.Notification.Channels) == 0 {
+		errs = append(errs, errors.New("notification.channels needs at least one channel when email is enabled"))
+	}
+
+	for _, channel := range c.Notification.Channels {
+		switch channel {
+		case "smtp":
+			if c.Email.SMTPHost == "" {
+				errs = append(errs, errors.New("email.smtp_host is required for the smtp channel"))
+			}
+			if c.Email.SMTPPort < 1 || c.Email.SMTPPort > 65535 {
+				errs = append(errs, errors.New("email.smtp_port must be between 1 and 65535"))
+			}
+			if c.Email.SMTPTLS != "starttls" && c.Email.SMTPTLS != "implicit" && c.Email.SMTPTLS != "none" {
+				errs = append(errs, errors.New("email.smtp_tls must be starttls, implicit or none"))
+			}
+			if c.Email.From == "" && c.Email.AuthEmail == "" {
+				errs = append(errs, errors.New("email.from or email.auth_email is required for the smtp channel"))
+			}
+			if c.Email.AuthEmail != "" && c.Email.AuthPassword == "" {
+				errs = append(errs, errors.New("email.auth_password is required when email.auth_email is set"))
+			}
+		case "webhook":
+			if _, err := url.ParseRequestURI(c.Notification.WebhookURL); err != nil {
+				errs = append(errs, errors.New("notification.webhook_url must be an absolute URL for the webhook channel"))
+			}
+		case "file":
+			if c.Notification.FilePath == "" {
+				errs = append(errs, errors.New("notification.file_path is required for the file channel"))
+			}
+		case "console":
+		default:
+			errs = append(errs, fmt.Errorf("notification.channels has unknown channel %q, use smtp, webhook, file or console", channel))
+		}
+	}
+
+	return errs
+}
+
+// DSN returns the Postgres connection URL with the credentials escaped.
+func (d DatabaseConfig) DSN() string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.User, d.Password),
+		Host:     fmt.Sprintf("%s:%d", d.Host, d.Port),
+		Path:     d.Name,
+		RawQuery: url.Values{"sslmode": {d.SSLMode}}.Encode(),
+	}
+
+	return dsn.String()
+}
@@ -0,0 +1,250 @@
+// Package emailtemplate renders the transactional emails from the templates
+// embedded in templates/. Every email has an HTML version, escaped with
+// html/template, and a plain text version, each wrapped in a layout shared by
+// all emails:
+//
+//	templates/layout.html, templates/layout.txt
+//	templates/<locale>/common.tmpl         greeting and signature
+//	templates/<locale>/<name>.html         defines "content"
+//	templates/<locale>/<name>.txt          defines "subject" and "content"
+//
+// Every locale has to provide every email. money and date format values the
+// way the locale writes them.
+package emailtemplate
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Email names.
+const (
+	CustomerRegistered          = "customer_registered"
+	EmailVerification           = "email_verification"
+	AccountDeletionConfirmation = "account_deletion_confirmation"
+	AccountDeleted              = "account_deleted"
+	OrderConfirmation           = "order_confirmation"
+)
+
+// DefaultLocale has every email and is used when no locale is given.
+const DefaultLocale = "en"
+
+var ErrUnknownLocale = errors.New("unknown email locale")
+
+//go:embed templates
+var files embed.FS
+
+type format struct {
+	thousands string
+	decimal   string
+	date      string
+}
+
+var formats = map[string]format{
+	"en": {thousands: ",", decimal: ".", date: "January 2, 2006 15:04 MST"},
+	"id": {thousands: ".", decimal: ",", date: "02/01/2006 15:04 MST"},
+}
+
+type templates struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// parsed by locale and email name
+var parsed = mustParse()
+
+// Email is a rendered email.
+type Email struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Account is the data of the emails about a customer account.
+type Account struct {
+	Name string
+}
+
+type Verification struct {
+	Name string
+	Link string
+}
+
+// Confirmation carries a token the customer sends back to confirm an action.
+type Confirmation struct {
+	Name  string
+	Token string
+}
+
+type Order struct {
+	Name              string
+	Currency          string
+	OrderID           uint
+	CustomerReference string
+	OrderDate         time.Time
+	ReceiverName      string
+	Address           string
+	City              string
+	District          string
+	PostalCode        string
+	Shipper           string
+	AirwaybillNumber  string
+	Items             []OrderItem
+	TotalItem         int
+	TotalPrice        float64
+}
+
+type OrderItem struct {
+	Title    string
+	Author   string
+	Quantity int
+	Price    float64
+}
+
+func (i OrderItem) Subtotal() float64 {
+	return i.Price * float64(i.Quantity)
+}
+
+// Render renders the email name in locale, or in DefaultLocale when locale is
+// empty.
+func Render(name, locale string, data any) (Email, error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+
+	emails, ok := parsed[locale]
+	if !ok {
+		return Email{}, fmt.Errorf("%w %q", ErrUnknownLocale, locale)
+	}
+
+	t, ok := emails[name]
+	if !ok {
+		return Email{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, html, text bytes.Buffer
+	err := t.text.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return Email{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+
+	err = t.html.ExecuteTemplate(&html, "layout", data)
+	if err != nil {
+		return Email{}, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+
+	err = t.text.ExecuteTemplate(&text, "layout", data)
+	if err != nil {
+		return Email{}, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+
+	return Email{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+// HasLocale reports whether the emails are available in locale.
+func HasLocale(locale string) bool {
+	_, ok := parsed[locale]
+	return ok
+}
+
+func mustParse() map[string]map[string]templates {
+	result, err := parse(files)
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+func parse(fsys fs.FS) (map[string]map[string]templates, error) {
+	names, err := fs.Glob(fsys, path.Join("templates", DefaultLocale, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	locales, err := fs.ReadDir(fsys, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]map[string]templates)
+	for _, entry := range locales {
+		if !entry.IsDir() {
+			continue
+		}
+
+		locale := entry.Name()
+		f, ok := formats[locale]
+		if !ok {
+			return nil, fmt.Errorf("emailtemplate: no format for locale %q", locale)
+		}
+
+		funcs := map[string]any{
+			"locale": func() string { return locale },
+			"money":  func(currency string, amount float64) string { return money(f, currency, amount) },
+			"date":   func(t time.Time) string { return t.Format(f.date) },
+		}
+
+		result[locale] = make(map[string]templates)
+		for _, file := range names {
+			name := strings.TrimSuffix(path.Base(file), ".html")
+			dir := path.Join("templates", locale)
+
+			html, err := htmltemplate.New(name).Funcs(funcs).ParseFS(fsys, "templates/layout.html", path.Join(dir, "common.tmpl"), path.Join(dir, name+".html"))
+			if err != nil {
+				return nil, fmt.Errorf("emailtemplate: %s/%s: %w", locale, name, err)
+			}
+
+			text, err := texttemplate.New(name).Funcs(funcs).ParseFS(fsys, "templates/layout.txt", path.Join(dir, "common.tmpl"), path.Join(dir, name+".txt"))
+			if err != nil {
+				return nil, fmt.Errorf("emailtemplate: %s/%s: %w", locale, name, err)
+			}
+
+			if text.Lookup("subject") == nil {
+				return nil, fmt.Errorf("emailtemplate: %s/%s.txt does not define a subject", locale, name)
+			}
+
+			result[locale][name] = templates{html: html, text: text}
+		}
+	}
+
+	return result, nil
+}
+
+// money formats an amount with two decimals and the separators of the locale,
+// e.g. IDR 1,250,000.00 or IDR 1.250.000,00.
+func money(f format, currency string, amount float64) string {
+	digits := strconv.FormatFloat(math.Abs(amount), 'f', 2, 64)
+	whole, fraction, _ := strings.Cut(digits, ".")
+
+	var b strings.Builder
+	if currency != "" {
+		b.WriteString(currency + " ")
+	}
+	if amount < 0 {
+		b.WriteString("-")
+	}
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(f.thousands)
+		}
+		b.WriteRune(digit)
+	}
+	b.WriteString(f.decimal + fraction)
+
+	return b.String()
+}
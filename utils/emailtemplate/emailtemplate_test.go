@@ -0,0 +1,127 @@
+package emailtemplate_test
+
+import (
+	"ebookstore/utils/emailtemplate"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var order = emailtemplate.Order{
+	Name:              "reader",
+	Currency:          "IDR",
+	OrderID:           42,
+	CustomerReference: "Ab12Cd34",
+	OrderDate:         time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC),
+	ReceiverName:      "Budi",
+	Address:           "Jl. Merdeka 1",
+	City:              "Jakarta",
+	District:          "Gambir",
+	PostalCode:        "10110",
+	Shipper:           "JNE",
+	AirwaybillNumber:  "JNE-ABCDEFGHIJ",
+	Items: []emailtemplate.OrderItem{
+		{Title: "<script>alert(1)</script>", Author: "Tom & Jerry", Quantity: 2, Price: 125000},
+		{Title: "Laskar Pelangi", Quantity: 1, Price: 1250000.5},
+	},
+	TotalItem:  3,
+	TotalPrice: 1500000.5,
+}
+
+func TestRender_OrderConfirmation(t *testing.T) {
+	tests := []struct {
+		name    string
+		locale  string
+		subject string
+		html    []string
+		text    []string
+	}{
+		{
+			name:    "default locale",
+			subject: "Order Confirmation Ab12Cd34",
+			html: []string{
+				`<html lang="en">`,
+				"<p>Dear reader,</p>",
+				"&lt;script&gt;alert(1)&lt;/script&gt;<br><small>Tom &amp; Jerry</small>",
+				"IDR 125,000.00",
+				"IDR 250,000.00",
+				"IDR 1,250,000.50",
+				"IDR 1,500,000.50",
+				"October 19, 2026 08:30 UTC",
+			},
+			text: []string{
+				"Dear reader,",
+				"- <script>alert(1)</script> by Tom & Jerry\n  2 x IDR 125,000.00 = IDR 250,000.00",
+				"- Laskar Pelangi\n  1 x IDR 1,250,000.50 = IDR 1,250,000.50",
+				"Total: 3 items, IDR 1,500,000.50",
+				"Best regards,\nThe Bookstore Team",
+			},
+		},
+		{
+			name:    "indonesian",
+			locale:  "id",
+			subject: "Konfirmasi Pesanan Ab12Cd34",
+			html: []string{
+				`<html lang="id">`,
+				"<p>Halo reader,</p>",
+				"IDR 1.500.000,50",
+				"19/10/2026 08:30 UTC",
+			},
+			text: []string{
+				"Total: 3 buku, IDR 1.500.000,50",
+				"Salam hangat,\nTim Bookstore",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, err := emailtemplate.Render(emailtemplate.OrderConfirmation, tt.locale, order)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.subject, email.Subject)
+			for _, want := range tt.html {
+				assert.Contains(t, email.HTML, want)
+			}
+			for _, want := range tt.text {
+				assert.Contains(t, email.Text, want)
+			}
+			assert.NotContains(t, email.HTML, "<script>")
+		})
+	}
+}
+
+func TestRender_EveryLocale(t *testing.T) {
+	data := map[string]any{
+		emailtemplate.CustomerRegistered:          emailtemplate.Account{Name: "reader"},
+		emailtemplate.EmailVerification:           emailtemplate.Verification{Name: "reader", Link: "http://localhost/verify?token=a&b"},
+		emailtemplate.AccountDeleted:              emailtemplate.Account{Name: "reader"},
+		emailtemplate.AccountDeletionConfirmation: emailtemplate.Confirmation{Name: "reader", Token: "token"},
+		emailtemplate.OrderConfirmation:           order,
+	}
+
+	for _, locale := range []string{"en", "id"} {
+		for name, value := range data {
+			email, err := emailtemplate.Render(name, locale, value)
+
+			assert.NoError(t, err, "%s/%s", locale, name)
+			assert.NotEmpty(t, email.Subject, "%s/%s", locale, name)
+			assert.Contains(t, email.HTML, "reader", "%s/%s", locale, name)
+			assert.Contains(t, email.Text, "reader", "%s/%s", locale, name)
+		}
+	}
+
+	email, _ := emailtemplate.Render(emailtemplate.EmailVerification, "en", data[emailtemplate.EmailVerification])
+	assert.Contains(t, email.HTML, `href="http://localhost/verify?token=a&amp;b"`)
+	assert.Contains(t, email.Text, "http://localhost/verify?token=a&b")
+}
+
+func TestRender_Unknown(t *testing.T) {
+	_, err := emailtemplate.Render(emailtemplate.CustomerRegistered, "fr", emailtemplate.Account{})
+	assert.ErrorIs(t, err, emailtemplate.ErrUnknownLocale)
+	assert.False(t, emailtemplate.HasLocale("fr"))
+	assert.True(t, emailtemplate.HasLocale("id"))
+
+	_, err = emailtemplate.Render("newsletter", "en", emailtemplate.Account{})
+	assert.EqualError(t, err, `unknown email template "newsletter"`)
+}
@@ -0,0 +1,198 @@
+// Package logger sets up the structured slog logger and the request ID
+// middleware. Records logged with a request context carry the request ID,
+// route, customer ID and trace ID.
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"ebookstore/utils/config"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength stops clients from filling the logs through the header.
+const maxRequestIDLength = 128
+
+type contextKey struct{}
+
+// requestInfo is shared by every context derived during a request. The route
+// is only known once Fiber matched it, so it is read from the request while
+// it runs and kept once it is done.
+type requestInfo struct {
+	id string
+
+	mu    sync.Mutex
+	c     *fiber.Ctx
+	route string
+}
+
+func (r *requestInfo) Route() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.c != nil {
+		return r.c.Route().Path
+	}
+	return r.route
+}
+
+func (r *requestInfo) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.route = r.c.Route().Path
+	r.c = nil
+}
+
+// Setup makes the configured JSON or text handler the default logger. The
+// standard log package writes through it too.
+func Setup(cfg config.LogConfig) error {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(cfg.Level))
+	if err != nil {
+		return fmt.Errorf("invalid log level %q", cfg.Level)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	case "text":
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	default:
+		return fmt.Errorf("invalid log format %q", cfg.Format)
+	}
+
+	slog.SetDefault(slog.New(NewContextHandler(handler)))
+	return nil
+}
+
+// RequestID returns the ID of the request ctx belongs to, or an empty string.
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// Middleware reuses the X-Request-ID header of the request or generates one,
+// echoes it in the response and logs every request once it is done.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		id := c.Get(HeaderRequestID)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(HeaderRequestID, id)
+
+		info := &requestInfo{id: id, c: c}
+		c.SetUserContext(context.WithValue(c.UserContext(), contextKey{}, info))
+
+		err := c.Next()
+		info.finish()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", c.IP()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		//the user context now also holds the customer and the trace
+		slog.LogAttrs(c.UserContext(), level, "request", attrs...)
+
+		return err
+	}
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ContextHandler adds the request attributes found in the context to every
+// record.
+type ContextHandler struct {
+	slog.Handler
+}
+
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		r.AddAttrs(
+			slog.String("request_id", info.id),
+			slog.String("route", info.Route()),
+		)
+	}
+
+	if customerID, ok := ctx.Value("id").(uint); ok && customerID != 0 {
+		r.AddAttrs(slog.Uint64("customer_id", uint64(customerID)))
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,94 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"ebookstore/utils/logger"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(logger.NewContextHandler(slog.NewJSONHandler(&buf, nil))))
+	t.Cleanup(func() { slog.SetDefault(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))) })
+
+	srv := fiber.New()
+	srv.Use(logger.Middleware())
+	srv.Get("/api/order/:id", func(c *fiber.Ctx) error {
+		//what the auth middleware does
+		ctx := context.WithValue(c.UserContext(), "id", uint(42))
+		c.SetUserContext(ctx)
+
+		slog.ErrorContext(ctx, "failed to get order")
+		return c.SendStatus(fiber.StatusInternalServerError)
+	})
+
+	tests := []struct {
+		name      string
+		requestID string
+		wantID    string
+	}{
+		{
+			name:      "propagates the request id",
+			requestID: "abc-123",
+			wantID:    "abc-123",
+		},
+		{
+			name:      "generates a request id",
+			requestID: "",
+		},
+		{
+			name:      "replaces an invalid request id",
+			requestID: "has spaces\tand tabs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+
+			req := httptest.NewRequest("GET", "/api/order/7", nil)
+			if tt.requestID != "" {
+				req.Header.Set(logger.HeaderRequestID, tt.requestID)
+			}
+
+			resp, err := srv.Test(req, 1000)
+			assert.NoError(t, err)
+
+			id := resp.Header.Get(logger.HeaderRequestID)
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, id)
+			} else {
+				assert.Len(t, id, 32)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			assert.Len(t, lines, 2)
+
+			var serviceLog, accessLog map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(lines[0]), &serviceLog))
+			assert.NoError(t, json.Unmarshal([]byte(lines[1]), &accessLog))
+
+			assert.Equal(t, "failed to get order", serviceLog["msg"])
+			assert.Equal(t, id, serviceLog["request_id"])
+			assert.Equal(t, "/api/order/:id", serviceLog["route"])
+			assert.Equal(t, float64(42), serviceLog["customer_id"])
+
+			assert.Equal(t, "request", accessLog["msg"])
+			assert.Equal(t, "ERROR", accessLog["level"])
+			assert.Equal(t, id, accessLog["request_id"])
+			assert.Equal(t, float64(500), accessLog["status"])
+			assert.Equal(t, float64(42), accessLog["customer_id"])
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	assert.Equal(t, "", logger.RequestID(context.Background()))
+}
@@ -0,0 +1,47 @@
+package metrics_test
+
+import (
+	"ebookstore/utils/metrics"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	srv := fiber.New()
+	srv.Use(metrics.Middleware())
+	srv.Get("/metrics", metrics.Handler())
+	srv.Get("/api/order/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+	srv.Get("/fail", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusBadRequest, "bad request")
+	})
+
+	tests := []struct {
+		target string
+		labels []string
+	}{
+		{target: "/api/order/1", labels: []string{"GET", "/api/order/:id", "201"}},
+		{target: "/api/order/2", labels: []string{"GET", "/api/order/:id", "201"}},
+		{target: "/fail", labels: []string{"GET", "/fail", "400"}},
+		{target: "/unknown/path", labels: []string{"GET", "unmatched", "404"}},
+	}
+	for _, tt := range tests {
+		_, err := srv.Test(httptest.NewRequest("GET", tt.target, nil), 1000)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/api/order/:id", "201")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/fail", "400")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "unmatched", "404")))
+
+	resp, err := srv.Test(httptest.NewRequest("GET", "/metrics", nil), 1000)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `ebookstore_http_request_duration_seconds_count{method="GET",route="/api/order/:id",status="201"} 2`)
+}
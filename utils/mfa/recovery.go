@@ -0,0 +1,61 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	RecoveryCodeCount = 10
+
+	recoveryCharset = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryHalfLen = 5
+)
+
+// GenerateRecoveryCodes returns one-time codes formatted as xxxxx-xxxxx.
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		symbols, err := randomSymbols(recoveryHalfLen * 2)
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, symbols[:recoveryHalfLen]+"-"+symbols[recoveryHalfLen:])
+	}
+
+	return codes, nil
+}
+
+// randomSymbols returns n crypto random symbols of recoveryCharset. 256 is not
+// a multiple of its 31 symbols, so the bytes of the last partial round are
+// dropped instead of favouring the first symbols.
+func randomSymbols(n int) (string, error) {
+	const limit = 256 - 256%len(recoveryCharset)
+
+	symbols := make([]byte, 0, n)
+	raw := make([]byte, n)
+	for len(symbols) < n {
+		if _, err := rand.Read(raw); err != nil {
+			return "", err
+		}
+
+		for _, b := range raw {
+			if int(b) < limit && len(symbols) < n {
+				symbols = append(symbols, recoveryCharset[int(b)%len(recoveryCharset)])
+			}
+		}
+	}
+
+	return string(symbols), nil
+}
+
+// HashRecoveryCode normalizes user input before hashing so codes typed in
+// upper case or without the dash still match.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package mfa_test
+
+import (
+	"ebookstore/utils/mfa"
+	"encoding/base32"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 appendix B vectors for SHA1, truncated to 6 digits.
+func TestGenerateCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "59", unix: 59, want: "287082"},
+		{name: "1111111109", unix: 1111111109, want: "081804"},
+		{name: "1234567890", unix: 1234567890, want: "005924"},
+		{name: "2000000000", unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mfa.GenerateCode(secret, time.Unix(tt.unix, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := mfa.GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	code, err := mfa.GenerateCode(secret, now)
+	assert.NoError(t, err)
+
+	step, ok := mfa.Validate(secret, code, now.Add(mfa.Period*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/mfa.Period, step)
+
+	_, ok = mfa.Validate(secret, code, now.Add(3*mfa.Period*time.Second))
+	assert.False(t, ok)
+
+	_, ok = mfa.Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	format := regexp.MustCompile(`^[a-hjkmnp-z2-9]{5}-[a-hjkmnp-z2-9]{5}$`)
+	seen := make(map[rune]int)
+	for i := 0; i < 100; i++ {
+		codes, err := mfa.GenerateRecoveryCodes(mfa.RecoveryCodeCount)
+		assert.NoError(t, err)
+		for _, code := range codes {
+			assert.Regexp(t, format, code)
+			for _, c := range code {
+				seen[c]++
+			}
+		}
+	}
+
+	//every symbol of the charset and the dash
+	assert.Len(t, seen, 32)
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	codes, err := mfa.GenerateRecoveryCodes(mfa.RecoveryCodeCount)
+	assert.NoError(t, err)
+	assert.Len(t, codes, mfa.RecoveryCodeCount)
+
+	assert.Equal(t, mfa.HashRecoveryCode(codes[0]), mfa.HashRecoveryCode(" "+codes[0][:5]+codes[0][6:]+" "))
+	assert.NotEqual(t, mfa.HashRecoveryCode(codes[0]), mfa.HashRecoveryCode(codes[1]))
+}
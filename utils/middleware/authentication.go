@@ -1,6 +1,9 @@
 package authentication
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -8,23 +11,43 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-var secretKey = "secret_key"
+var secretKey string
+
+// SetSecretKey sets the key used to sign and verify every token. It is called
+// once at startup with the configured secret.
+func SetSecretKey(key string) {
+	secretKey = key
+}
+
+const (
+	purposeMFA      = "mfa"
+	mfaChallengeTTL = 5 * time.Minute
+
+	purposeOIDC  = "oidc"
+	oidcStateTTL = 10 * time.Minute
+
+	purposeAccountDeletion = "account_deletion"
+	accountDeletionTTL     = 15 * time.Minute
+)
 
 type customClaims struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	ID       uint   `json:"id"`
+	Purpose  string `json:"purpose,omitempty"`
+	// Challenge ties an MFA challenge token to the one stored for the customer.
+	Challenge string `json:"challenge,omitempty"`
 	jwt.StandardClaims
 }
 
-func AuthMiddleware() fiber.Handler {
+// AuthMiddleware accepts access tokens of customers that still exist. The
+// lookup runs on every request, like the one in RoleMiddleware, so deleting an
+// account also ends its sessions.
+func AuthMiddleware(isActive func(ctx context.Context, id uint) (bool, error)) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		tokenString := c.Get("Authorization")[7:]
-		if tokenString == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
-				"status_code": fiber.StatusUnauthorized,
-				"message":     "insert token please",
-			})
+		tokenString, ok := strings.CutPrefix(c.Get("Authorization"), "Bearer ")
+		if !ok || tokenString == "" {
+			return fiber.NewError(fiber.StatusUnauthorized, "insert token please")
 		}
 
 		// verify and validate token
@@ -32,17 +55,53 @@ func AuthMiddleware() fiber.Handler {
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 			return []byte(secretKey), nil
 		})
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
-				"status_code": fiber.StatusUnauthorized,
-				"message":     "invalid token",
-			})
+		if err != nil || !token.Valid || claims.Purpose != "" {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid token")
+		}
+
+		active, err := isActive(c.UserContext(), claims.ID)
+		if err != nil {
+			return err
+		}
+
+		if !active {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid token")
 		}
 
 		c.Locals("username", claims.Username)
 		c.Locals("email", claims.Email)
 		c.Locals("id", claims.ID)
 
+		//handlers pass c.UserContext() to the services, which read the claims from it
+		ctx := c.UserContext()
+		ctx = context.WithValue(ctx, "username", claims.Username)
+		ctx = context.WithValue(ctx, "email", claims.Email)
+		ctx = context.WithValue(ctx, "id", claims.ID)
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
+
+// RoleMiddleware only lets customers with the role through. It runs after
+// AuthMiddleware and looks the role up on every request, so a revoked role
+// takes effect right away instead of when the token expires.
+func RoleMiddleware(role string, roleOf func(ctx context.Context, id uint) (string, error)) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, ok := c.Locals("id").(uint)
+		if !ok {
+			return fiber.NewError(fiber.StatusUnauthorized, "insert token please")
+		}
+
+		customerRole, err := roleOf(c.UserContext(), id)
+		if err != nil {
+			return err
+		}
+
+		if customerRole != role {
+			return fiber.NewError(fiber.StatusForbidden, "you are not allowed to access this resource")
+		}
+
 		return c.Next()
 	}
 }
@@ -68,6 +127,100 @@ func GenerateToken(username, email string, id uint) (string, error) {
 	return signedToken, nil
 }
 
+// GenerateMFAChallengeToken issues the short-lived token returned after a
+// successful password check for customers with MFA enabled. It can only be
+// exchanged for a real token, AuthMiddleware rejects it. The challenge is
+// stored server side so the token can only be used once.
+func GenerateMFAChallengeToken(username, email string, id uint, challenge string) (string, error) {
+	claims := jwt.MapClaims{
+		"id":        id,
+		"username":  username,
+		"email":     email,
+		"purpose":   purposeMFA,
+		"challenge": challenge,
+		"exp":       time.Now().Add(mfaChallengeTTL).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secretKey))
+}
+
+func ParseMFAChallengeToken(tokenString string) (username, email string, id uint, challenge string, err error) {
+	claims := &customClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secretKey), nil
+	})
+	if err != nil || !token.Valid || claims.Purpose != purposeMFA || claims.Challenge == "" {
+		return "", "", 0, "", errors.New("invalid or expired mfa token")
+	}
+
+	return claims.Username, claims.Email, claims.ID, claims.Challenge, nil
+}
+
+// GenerateAccountDeletionToken issues the token emailed to customers without a
+// password to confirm deleting their account. AuthMiddleware rejects it.
+func GenerateAccountDeletionToken(id uint) (string, error) {
+	claims := jwt.MapClaims{
+		"id":      id,
+		"purpose": purposeAccountDeletion,
+		"exp":     time.Now().Add(accountDeletionTTL).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secretKey))
+}
+
+func ParseAccountDeletionToken(tokenString string) (uint, error) {
+	claims := &customClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secretKey), nil
+	})
+	if err != nil || !token.Valid || claims.Purpose != purposeAccountDeletion {
+		return 0, errors.New("invalid or expired confirmation token")
+	}
+
+	return claims.ID, nil
+}
+
+// OIDCState is the per-login secret material of the authorization code flow,
+// kept client side in a signed cookie between the redirect and the callback.
+type OIDCState struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+type oidcStateClaims struct {
+	OIDCState
+	Purpose string `json:"purpose"`
+	jwt.StandardClaims
+}
+
+func GenerateOIDCStateToken(state OIDCState) (string, error) {
+	claims := oidcStateClaims{
+		OIDCState: state,
+		Purpose:   purposeOIDC,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(oidcStateTTL).Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secretKey))
+}
+
+func ParseOIDCStateToken(tokenString string) (OIDCState, error) {
+	claims := &oidcStateClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secretKey), nil
+	})
+	if err != nil || !token.Valid || claims.Purpose != purposeOIDC {
+		return OIDCState{}, errors.New("invalid or expired login state")
+	}
+
+	return claims.OIDCState, nil
+}
+
 func GenerateHashedPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	return string(bytes), err
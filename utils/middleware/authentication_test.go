@@ -0,0 +1,107 @@
+package authentication_test
+
+import (
+	"context"
+	authentication "ebookstore/utils/middleware"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	authentication.SetSecretKey("secret")
+	isActive := func(ctx context.Context, id uint) (bool, error) {
+		switch id {
+		case 1:
+			return true, nil
+		case 2:
+			return false, nil
+		default:
+			return false, errors.New("lookup error")
+		}
+	}
+
+	accessToken := func(id uint) string {
+		token, _ := authentication.GenerateToken("username", "mail@mail.com", id)
+		return token
+	}
+	challengeToken, _ := authentication.GenerateMFAChallengeToken("username", "mail@mail.com", 1, "challenge")
+	deletionToken, _ := authentication.GenerateAccountDeletionToken(1)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "active customer", token: accessToken(1), wantStatus: 200},
+		{name: "deleted customer", token: accessToken(2), wantStatus: 401},
+		{name: "lookup error", token: accessToken(3), wantStatus: 500},
+		{name: "mfa challenge token", token: challengeToken, wantStatus: 401},
+		{name: "account deletion token", token: deletionToken, wantStatus: 401},
+		{name: "no token", wantStatus: 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fiber.New()
+			srv.Get("/", authentication.AuthMiddleware(isActive), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			resp, _ := srv.Test(req, 1000)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestRoleMiddleware(t *testing.T) {
+	authentication.SetSecretKey("secret")
+	roles := map[uint]string{1: "admin", 2: "customer"}
+	roleOf := func(ctx context.Context, id uint) (string, error) {
+		role, ok := roles[id]
+		if !ok {
+			return "", errors.New("customer not found")
+		}
+		return role, nil
+	}
+
+	tests := []struct {
+		name       string
+		id         uint
+		noToken    bool
+		wantStatus int
+	}{
+		{name: "admin", id: 1, wantStatus: 200},
+		{name: "customer", id: 2, wantStatus: 403},
+		{name: "lookup error", id: 3, wantStatus: 500},
+		{name: "no token", noToken: true, wantStatus: 401},
+	}
+	isActive := func(ctx context.Context, id uint) (bool, error) {
+		return true, nil
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fiber.New()
+			srv.Get("/", authentication.AuthMiddleware(isActive), authentication.RoleMiddleware("admin", roleOf), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if !tt.noToken {
+				token, _ := authentication.GenerateToken("username", "mail@mail.com", tt.id)
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			resp, _ := srv.Test(req, 1000)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
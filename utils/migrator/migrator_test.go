@@ -0,0 +1,209 @@
+package migrator_test
+
+import (
+	"context"
+	"ebookstore/db"
+	"ebookstore/utils/migrator"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/2_add_column.up.sql":     {Data: []byte("ALTER TABLE a ADD COLUMN b INT;")},
+		"migrations/2_add_column.down.sql":   {Data: []byte("ALTER TABLE a DROP COLUMN b;")},
+		"migrations/1_create_table.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+		"migrations/1_create_table.down.sql": {Data: []byte("DROP TABLE a;")},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name         string
+		fsys         fstest.MapFS
+		wantVersions []int64
+		wantErr      string
+	}{
+		{
+			name:         "sorted by version",
+			fsys:         testFS(),
+			wantVersions: []int64{1, 2},
+		},
+		{
+			name: "missing down file",
+			fsys: func() fstest.MapFS {
+				fsys := testFS()
+				delete(fsys, "migrations/2_add_column.down.sql")
+				return fsys
+			}(),
+			wantErr: "2_add_column has no down file",
+		},
+		{
+			name: "invalid file name",
+			fsys: func() fstest.MapFS {
+				fsys := testFS()
+				fsys["migrations/3_seed.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+				return fsys
+			}(),
+			wantErr: "invalid migration file name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := migrator.Load(tt.fsys, "migrations")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			var versions []int64
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+				assert.NotEmpty(t, migration.Checksum)
+			}
+			assert.Equal(t, tt.wantVersions, versions)
+		})
+	}
+}
+
+// TestEmbeddedMigrations makes sure the files shipped in the binary follow the
+// naming rules and every up file has a down file.
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := migrator.Load(db.Migrations, "migrations")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+}
+
+func TestMigrator_Up(t *testing.T) {
+	migrations, _ := migrator.Load(testFS(), "migrations")
+	first := migrations[0]
+
+	tests := []struct {
+		name        string
+		applied     *sqlmock.Rows
+		expect      func(m sqlmock.Sqlmock)
+		wantApplied []int64
+		wantErr     string
+	}{
+		{
+			name:    "applies pending migrations",
+			applied: sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).AddRow(first.Version, first.Name, first.Checksum, time.Now()),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec(regexp.QuoteMeta("ALTER TABLE a ADD COLUMN b INT;")).WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)")).
+					WithArgs(int64(2), "add_column", migrations[1].Checksum).WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+			wantApplied: []int64{2},
+		},
+		{
+			name:    "modified migration",
+			applied: sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).AddRow(first.Version, first.Name, "other", time.Now()),
+			expect:  func(m sqlmock.Sqlmock) {},
+			wantErr: "1_create_table was modified after it was applied",
+		},
+		{
+			name:    "unknown applied migration",
+			applied: sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).AddRow(9, "gone", "x", time.Now()),
+			expect:  func(m sqlmock.Sqlmock) {},
+			wantErr: "9_gone is applied but its files are missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, m, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer conn.Close()
+
+			m.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+			m.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+			m.ExpectQuery(regexp.QuoteMeta("SELECT to_regclass('schema_migrations') IS NOT NULL")).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			m.ExpectQuery(regexp.QuoteMeta("SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")).WillReturnRows(tt.applied)
+			tt.expect(m)
+			m.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			mig, err := migrator.New(conn, testFS(), "migrations")
+			assert.NoError(t, err)
+
+			done, err := mig.Up(context.Background())
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var versions []int64
+			for _, migration := range done {
+				versions = append(versions, migration.Version)
+			}
+			assert.Equal(t, tt.wantApplied, versions)
+			assert.NoError(t, m.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	migrations, _ := migrator.Load(testFS(), "migrations")
+
+	conn, m, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer conn.Close()
+
+	rows := sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"})
+	for _, migration := range migrations {
+		rows.AddRow(migration.Version, migration.Name, migration.Checksum, time.Now())
+	}
+
+	m.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectQuery(regexp.QuoteMeta("SELECT to_regclass('schema_migrations') IS NOT NULL")).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	m.ExpectQuery(regexp.QuoteMeta("SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")).WillReturnRows(rows)
+	m.ExpectBegin()
+	m.ExpectExec(regexp.QuoteMeta("ALTER TABLE a DROP COLUMN b;")).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = $1")).WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectCommit()
+	m.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mig, err := migrator.New(conn, testFS(), "migrations")
+	assert.NoError(t, err)
+
+	done, err := mig.Down(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, done, 1)
+	assert.Equal(t, int64(2), done[0].Version)
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestMigrator_Status(t *testing.T) {
+	migrations, _ := migrator.Load(testFS(), "migrations")
+
+	conn, m, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer conn.Close()
+
+	m.ExpectQuery(regexp.QuoteMeta("SELECT to_regclass('schema_migrations') IS NOT NULL")).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	m.ExpectQuery(regexp.QuoteMeta("SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).AddRow(migrations[0].Version, migrations[0].Name, migrations[0].Checksum, time.Now()))
+
+	mig, err := migrator.New(conn, testFS(), "migrations")
+	assert.NoError(t, err)
+
+	statuses, err := mig.Status(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, migrator.StateApplied, statuses[0].State)
+	assert.Equal(t, migrator.StatePending, statuses[1].State)
+}
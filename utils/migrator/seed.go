@@ -0,0 +1,53 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Seed runs every .sql file in dir in name order inside one transaction. Seed
+// files must be idempotent since the command can be run any number of times.
+func Seed(ctx context.Context, db *sql.DB, fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seeds: %s", err.Error())
+	}
+
+	var names []string
+	err = WithLock(ctx, db, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %s", err.Error())
+		}
+		defer tx.Rollback()
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+				continue
+			}
+
+			content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %s", entry.Name(), err.Error())
+			}
+
+			_, err = tx.ExecContext(ctx, string(content))
+			if err != nil {
+				return fmt.Errorf("failed to run seed %s: %s", entry.Name(), err.Error())
+			}
+
+			names = append(names, entry.Name())
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"context"
+	"ebookstore/utils/metrics"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type Channel struct {
+	Name    string
+	Service INotificationService
+}
+
+type FanoutNotification struct {
+	preferences IPreferences
+	channels    []Channel
+}
+
+// DeliveryError is returned when some channels failed. Delivered names the
+// channels that got the message, so a retry can leave them out.
+type DeliveryError struct {
+	Delivered []string
+	Err       error
+}
+
+func (e *DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// NewFanout sends every message to all channels. A failure on one channel does
+// not stop the others. It is returned as a *DeliveryError naming the channels
+// that succeeded, and channels listed in Message.Delivered are skipped, so a
+// retry only goes to the channels that failed.
+//
+// Channels the customer turned the category of the message off for are
+// skipped. preferences can be nil to send everything.
+func NewFanout(preferences IPreferences, channels ...Channel) INotificationService {
+	return &FanoutNotification{preferences: preferences, channels: channels}
+}
+
+func (f *FanoutNotification) SendNotification(ctx context.Context, msg Message) error {
+	var errs []error
+	var delivered []string
+	for _, channel := range f.channels {
+		if slices.Contains(msg.Delivered, channel.Name) {
+			continue
+		}
+
+		msg := msg
+		if f.preferences != nil && msg.optional() {
+			allowed, err := f.preferences.Allowed(ctx, msg.CustomerID, msg.Category, channel.Name)
+			if err != nil {
+				metrics.NotificationsSent.WithLabelValues(channel.Name, "failure").Inc()
+				errs = append(errs, fmt.Errorf("%s: failed to check preferences: %w", channel.Name, err))
+				continue
+			}
+
+			if !allowed {
+				metrics.NotificationsSent.WithLabelValues(channel.Name, "skipped").Inc()
+				continue
+			}
+
+			msg.UnsubscribeURL = f.preferences.UnsubscribeURL(msg.CustomerID, msg.Category, channel.Name)
+		}
+
+		err := channel.Service.SendNotification(ctx, msg)
+		if err != nil {
+			metrics.NotificationsSent.WithLabelValues(channel.Name, "failure").Inc()
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name, err))
+			continue
+		}
+		metrics.NotificationsSent.WithLabelValues(channel.Name, "success").Inc()
+		delivered = append(delivered, channel.Name)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &DeliveryError{Delivered: delivered, Err: errors.Join(errs...)}
+}
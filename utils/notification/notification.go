@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"ebookstore/utils/config"
+	"fmt"
+	"os"
+)
+
+// Channel names used in the configuration.
+const (
+	ChannelSMTP    = "smtp"
+	ChannelWebhook = "webhook"
+	ChannelFile    = "file"
+	ChannelConsole = "console"
+)
+
+// New builds the channels listed in the configuration behind one fan-out
+// checking the preferences of the customers.
+func New(cfg *config.Config, preferences IPreferences) (INotificationService, error) {
+	var channels []Channel
+	for _, name := range cfg.Notification.Channels {
+		var service INotificationService
+		switch name {
+		case ChannelSMTP:
+			from := cfg.Email.From
+			if from == "" {
+				from = cfg.Email.AuthEmail
+			}
+
+			service = NewSMTPNotification(SMTPConfig{
+				Host:       cfg.Email.SMTPHost,
+				Port:       cfg.Email.SMTPPort,
+				Username:   cfg.Email.AuthEmail,
+				Password:   cfg.Email.AuthPassword,
+				From:       from,
+				SenderName: cfg.Email.SenderName,
+				TLS:        cfg.Email.SMTPTLS,
+			})
+		case ChannelWebhook:
+			service = NewWebhookNotification(cfg.Notification.WebhookURL, cfg.Notification.WebhookSecret)
+		case ChannelFile:
+			var err error
+			service, err = NewFileNotification(cfg.Notification.FilePath)
+			if err != nil {
+				return nil, err
+			}
+		case ChannelConsole:
+			service = NewConsoleNotification(os.Stdout)
+		default:
+			return nil, fmt.Errorf("unknown notification channel %q", name)
+		}
+
+		channels = append(channels, Channel{Name: name, Service: service})
+	}
+
+	return NewFanout(preferences, channels...), nil
+}
@@ -0,0 +1,176 @@
+package notification_test
+
+import (
+	"bytes"
+	"context"
+	"ebookstore/utils/notification"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingNotification struct{}
+
+// preferences turns off the channels listed in off, for every customer.
+type preferences struct {
+	off map[string]bool
+	err error
+}
+
+func (p preferences) Allowed(ctx context.Context, customerID uint, category, channel string) (bool, error) {
+	return !p.off[channel], p.err
+}
+
+func (p preferences) UnsubscribeURL(customerID uint, category, channel string) string {
+	return "http://localhost/unsubscribe?channel=" + channel
+}
+
+// recorder keeps the messages it was asked to send.
+type recorder struct {
+	sent []notification.Message
+}
+
+func (r *recorder) SendNotification(ctx context.Context, msg notification.Message) error {
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func (failingNotification) SendNotification(ctx context.Context, msg notification.Message) error {
+	return errors.New("connection refused")
+}
+
+func TestConsoleNotification(t *testing.T) {
+	var buf bytes.Buffer
+	err := notification.NewConsoleNotification(&buf).SendNotification(context.Background(), notification.Message{
+		Event:   "customer.registered",
+		To:      "mail@mail.com",
+		Subject: "Welcome",
+		Body:    "<p>hello</p>",
+		Text:    "hello",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "Event: customer.registered")
+	assert.Contains(t, buf.String(), "To: mail@mail.com")
+	assert.Contains(t, buf.String(), "\n\nhello\n")
+	assert.NotContains(t, buf.String(), "<p>")
+}
+
+func TestFileNotification(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	service, err := notification.NewFileNotification(path)
+	assert.NoError(t, err)
+
+	for _, subject := range []string{"first", "second"} {
+		err = service.SendNotification(context.Background(), notification.Message{To: "mail@mail.com", Subject: subject})
+		assert.NoError(t, err)
+	}
+
+	content, _ := os.ReadFile(path)
+	lines := bytes.Split(bytes.TrimSpace(content), []byte("\n"))
+	assert.Len(t, lines, 2)
+
+	var msg notification.Message
+	assert.NoError(t, json.Unmarshal(lines[1], &msg))
+	assert.Equal(t, "second", msg.Subject)
+}
+
+func TestFanout(t *testing.T) {
+	var buf bytes.Buffer
+	service := notification.NewFanout(
+		nil,
+		notification.Channel{Name: "webhook", Service: failingNotification{}},
+		notification.Channel{Name: "console", Service: notification.NewConsoleNotification(&buf)},
+	)
+
+	err := service.SendNotification(context.Background(), notification.Message{To: "mail@mail.com", Subject: "Welcome"})
+
+	assert.EqualError(t, err, "webhook: connection refused")
+	assert.Contains(t, buf.String(), "Subject: Welcome")
+
+	var delivery *notification.DeliveryError
+	assert.ErrorAs(t, err, &delivery)
+	assert.Equal(t, []string{"console"}, delivery.Delivered)
+}
+
+func TestFanout_Delivered(t *testing.T) {
+	smtp, webhook := &recorder{}, &recorder{}
+	service := notification.NewFanout(
+		nil,
+		notification.Channel{Name: "smtp", Service: smtp},
+		notification.Channel{Name: "webhook", Service: webhook},
+	)
+
+	err := service.SendNotification(context.Background(), notification.Message{To: "mail@mail.com", Delivered: []string{"smtp"}})
+
+	assert.NoError(t, err)
+	assert.Len(t, smtp.sent, 0)
+	assert.Len(t, webhook.sent, 1)
+}
+
+func TestFanout_Preferences(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences preferences
+		msg         notification.Message
+		wantErr     string
+		wantSMTP    int
+		wantWebhook int
+		wantURL     string
+	}{
+		{
+			name:        "channel turned off",
+			preferences: preferences{off: map[string]bool{"webhook": true}},
+			msg:         notification.Message{CustomerID: 1, Category: notification.CategoryTransactional},
+			wantSMTP:    1,
+			wantURL:     "http://localhost/unsubscribe?channel=smtp",
+		},
+		{
+			name:        "account messages ignore preferences",
+			preferences: preferences{off: map[string]bool{"smtp": true, "webhook": true}},
+			msg:         notification.Message{CustomerID: 1, Category: notification.CategoryAccount},
+			wantSMTP:    1,
+			wantWebhook: 1,
+		},
+		{
+			name:        "no customer",
+			preferences: preferences{off: map[string]bool{"smtp": true}},
+			msg:         notification.Message{Category: notification.CategoryMarketing},
+			wantSMTP:    1,
+			wantWebhook: 1,
+		},
+		{
+			name:        "preferences unavailable",
+			preferences: preferences{err: errors.New("connection refused")},
+			msg:         notification.Message{CustomerID: 1, Category: notification.CategoryMarketing},
+			wantErr:     "smtp: failed to check preferences: connection refused\nwebhook: failed to check preferences: connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			smtp, webhook := &recorder{}, &recorder{}
+			service := notification.NewFanout(
+				tt.preferences,
+				notification.Channel{Name: "smtp", Service: smtp},
+				notification.Channel{Name: "webhook", Service: webhook},
+			)
+
+			err := service.SendNotification(context.Background(), tt.msg)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Len(t, smtp.sent, tt.wantSMTP)
+			assert.Len(t, webhook.sent, tt.wantWebhook)
+			if tt.wantSMTP > 0 {
+				assert.Equal(t, tt.wantURL, smtp.sent[0].UnsubscribeURL)
+			}
+		})
+	}
+}
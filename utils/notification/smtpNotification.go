@@ -0,0 +1,149 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// TLS modes of the SMTP connection.
+const (
+	// TLSStartTLS upgrades a plain connection, usually on port 587, and fails
+	// when the server does not offer it.
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects with TLS right away, usually on port 465.
+	TLSImplicit = "implicit"
+	// TLSNone never encrypts, only meant for a relay on the same host.
+	TLSNone = "none"
+)
+
+const smtpTimeout = 30 * time.Second
+
+type SMTPConfig struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	From       string
+	SenderName string
+	TLS        string
+}
+
+type SMTPNotification struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPNotification sends email through any SMTP server. The login is
+// skipped when no username is set.
+func NewSMTPNotification(cfg SMTPConfig) INotificationService {
+	return &SMTPNotification{cfg: cfg}
+}
+
+func (s *SMTPNotification) SendNotification(ctx context.Context, msg Message) error {
+	m := gomail.NewMessage()
+	m.SetAddressHeader("From", s.cfg.From, s.cfg.SenderName)
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	//one-click unsubscribe of RFC 8058, the POST to the URL needs no login
+	if msg.UnsubscribeURL != "" {
+		m.SetHeader("List-Unsubscribe", "<"+msg.UnsubscribeURL+">")
+		m.SetHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	if msg.Text != "" {
+		m.SetBody("text/plain", msg.Text)
+		m.AddAlternative("text/html", msg.Body)
+	} else {
+		m.SetBody("text/html", msg.Body)
+	}
+
+	err := s.send(ctx, msg.To, m)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SMTPNotification) send(ctx context.Context, to string, m *gomail.Message) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.cfg.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("server does not support STARTTLS")
+		}
+
+		err = client.StartTLS(&tls.Config{ServerName: s.cfg.Host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.Username != "" {
+		//PlainAuth refuses to send the password over a plain connection to another host
+		err = client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(s.cfg.From)
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(to)
+	if err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = m.WriteTo(w)
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (s *SMTPNotification) dial(ctx context.Context) (net.Conn, error) {
+	address := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	if s.cfg.TLS == TLSImplicit {
+		dialer := tls.Dialer{Config: &tls.Config{ServerName: s.cfg.Host}}
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", address)
+}
@@ -0,0 +1,132 @@
+package notification_test
+
+import (
+	"bufio"
+	"context"
+	"ebookstore/utils/notification"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTP answers one SMTP session and returns the received DATA.
+func fakeSMTP(t *testing.T, extensions ...string) (host string, port int, data <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			switch command := strings.ToUpper(strings.Fields(line)[0]); command {
+			case "EHLO":
+				for _, extension := range extensions {
+					reply("250-" + extension)
+				}
+				reply("250 localhost")
+			case "DATA":
+				reply("354 go ahead")
+				var body strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					body.WriteString(line)
+				}
+				received <- body.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	address := listener.Addr().(*net.TCPAddr)
+	return address.IP.String(), address.Port, received
+}
+
+func TestSMTPNotification(t *testing.T) {
+	msg := notification.Message{
+		To:      "mail@mail.com",
+		Subject: "Order Confirmation",
+		Body:    "<p>thanks</p>",
+		Text:    "thanks",
+	}
+
+	t.Run("plain relay", func(t *testing.T) {
+		host, port, data := fakeSMTP(t)
+		service := notification.NewSMTPNotification(notification.SMTPConfig{
+			Host:       host,
+			Port:       port,
+			From:       "noreply@mail.com",
+			SenderName: "Ebookstore",
+			TLS:        notification.TLSNone,
+		})
+
+		err := service.SendNotification(context.Background(), msg)
+		assert.NoError(t, err)
+
+		body := <-data
+		assert.Contains(t, body, "Subject: Order Confirmation")
+		assert.Contains(t, body, `From: "Ebookstore" <noreply@mail.com>`)
+		assert.Contains(t, body, "multipart/alternative")
+		assert.Contains(t, body, "text/plain")
+		assert.Contains(t, body, "<p>thanks</p>")
+	})
+
+	t.Run("one-click unsubscribe", func(t *testing.T) {
+		host, port, data := fakeSMTP(t)
+		service := notification.NewSMTPNotification(notification.SMTPConfig{
+			Host: host,
+			Port: port,
+			From: "noreply@mail.com",
+			TLS:  notification.TLSNone,
+		})
+
+		unsubscribe := msg
+		unsubscribe.UnsubscribeURL = "http://localhost/api/customer/notifications/unsubscribe?token=abc"
+		err := service.SendNotification(context.Background(), unsubscribe)
+		assert.NoError(t, err)
+
+		body := <-data
+		assert.Contains(t, body, "List-Unsubscribe: <http://localhost/api/customer/notifications/unsubscribe?token=abc>")
+		assert.Contains(t, body, "List-Unsubscribe-Post: List-Unsubscribe=One-Click")
+	})
+
+	t.Run("starttls required", func(t *testing.T) {
+		host, port, _ := fakeSMTP(t)
+		service := notification.NewSMTPNotification(notification.SMTPConfig{
+			Host: host,
+			Port: port,
+			From: "noreply@mail.com",
+			TLS:  notification.TLSStartTLS,
+		})
+
+		err := service.SendNotification(context.Background(), msg)
+		assert.ErrorContains(t, err, "server does not support STARTTLS")
+	})
+
+}
@@ -1,5 +1,55 @@
 package notification
 
+import "context"
+
+// Categories of messages. Customers can turn transactional and marketing
+// messages off per channel, account messages about the security of the
+// account are always sent.
+const (
+	CategoryAccount       = "account"
+	CategoryTransactional = "transactional"
+	CategoryMarketing     = "marketing"
+)
+
 type INotificationService interface {
-	SendNotification(payload EmailPayload) error
+	SendNotification(ctx context.Context, msg Message) error
+}
+
+// IPreferences holds what customers agreed to receive.
+type IPreferences interface {
+	Allowed(ctx context.Context, customerID uint, category, channel string) (bool, error)
+	// UnsubscribeURL is a link turning the category off on the channel
+	// without logging in.
+	UnsubscribeURL(customerID uint, category, channel string) string
+}
+
+// Message is a notification to one recipient. Every channel picks the parts
+// it can deliver: email sends the HTML body with the text as alternative,
+// webhooks get the whole message including Data, the console prints the text.
+type Message struct {
+	// Event names what happened, e.g. order.confirmed, so receivers can route on it.
+	Event   string `json:"event,omitempty"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	// Body is the HTML content.
+	Body string `json:"body"`
+	// Text is the plain text content, optional.
+	Text string `json:"text,omitempty"`
+	// Data is the structured content for machine receivers.
+	Data map[string]any `json:"data,omitempty"`
+
+	// CustomerID and Category select the preferences checked before sending.
+	// Messages without them, or in the account category, are always sent.
+	CustomerID uint   `json:"customer_id,omitempty"`
+	Category   string `json:"category,omitempty"`
+	// UnsubscribeURL is set per channel when the message is sent.
+	UnsubscribeURL string `json:"unsubscribe_url,omitempty"`
+	// Delivered names the channels that got the message on an earlier
+	// attempt, the fan-out does not send it to them again.
+	Delivered []string `json:"-"`
+}
+
+// optional reports whether the customer can turn the message off.
+func (m Message) optional() bool {
+	return m.CustomerID != 0 && (m.Category == CategoryTransactional || m.Category == CategoryMarketing)
 }
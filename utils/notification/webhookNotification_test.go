@@ -0,0 +1,65 @@
+package notification_test
+
+import (
+	"context"
+	"ebookstore/utils/notification"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotification(t *testing.T) {
+	msg := notification.Message{
+		Event:   "order.confirmed",
+		To:      "mail@mail.com",
+		Subject: "Order Confirmation",
+		Body:    "<p>thanks</p>",
+		Data:    map[string]any{"reference": "ORD-1"},
+	}
+
+	t.Run("signed", func(t *testing.T) {
+		var got notification.Message
+		var signature, event string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &got)
+			event = r.Header.Get("X-Ebookstore-Event")
+			signature = r.Header.Get(notification.SignatureHeader)
+			assert.Equal(t, notification.Sign("secret", body), signature)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		err := notification.NewWebhookNotification(srv.URL, "secret").SendNotification(context.Background(), msg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "order.confirmed", event)
+		assert.Contains(t, signature, "sha256=")
+		assert.Equal(t, msg.Subject, got.Subject)
+		assert.Equal(t, "ORD-1", got.Data["reference"])
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get(notification.SignatureHeader))
+		}))
+		defer srv.Close()
+
+		err := notification.NewWebhookNotification(srv.URL, "").SendNotification(context.Background(), msg)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer srv.Close()
+
+		err := notification.NewWebhookNotification(srv.URL, "secret").SendNotification(context.Background(), msg)
+		assert.EqualError(t, err, "webhook answered 502 Bad Gateway")
+	})
+}
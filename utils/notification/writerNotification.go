@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// WriterNotification writes messages instead of delivering them, for
+// development. The file sink writes one JSON object per line, the console sink
+// a readable block.
+type WriterNotification struct {
+	mu       sync.Mutex
+	w        io.Writer
+	jsonLine bool
+}
+
+func NewConsoleNotification(w io.Writer) INotificationService {
+	return &WriterNotification{w: w}
+}
+
+// NewFileNotification appends to the file at path, creating it if needed.
+func NewFileNotification(path string) (INotificationService, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification file: %w", err)
+	}
+
+	return &WriterNotification{w: f, jsonLine: true}, nil
+}
+
+func (n *WriterNotification) SendNotification(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.jsonLine {
+		return json.NewEncoder(n.w).Encode(msg)
+	}
+
+	content := msg.Text
+	if content == "" {
+		content = msg.Body
+	}
+
+	_, err := fmt.Fprintf(n.w, "--- notification %s\nEvent: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().UTC().Format(time.RFC3339), msg.Event, msg.To, msg.Subject, content)
+	return err
+}
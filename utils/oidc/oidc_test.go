@@ -0,0 +1,160 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"ebookstore/utils/oidc"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockIssuer is a minimal OpenID Connect provider that issues RS256 ID tokens
+// and enforces PKCE on the token endpoint.
+type mockIssuer struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T, clientID string) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockIssuer{key: key, clientID: clientID}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                m.server.URL,
+			"authorization_endpoint":                m.server.URL + "/authorize",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   m.server.URL,
+			"aud":   m.clientID,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": m.nonce,
+		}
+		for k, v := range m.claims {
+			claims[k] = v
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		idToken, _ := token.SignedString(key)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     idToken,
+		})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	issuer := newMockIssuer(t, "client")
+	issuer.claims = jwt.MapClaims{"sub": "subject", "email": "mail@mail.com", "email_verified": true}
+
+	tests := []struct {
+		name     string
+		verifier string
+		nonce    string
+		want     oidc.Claims
+		wantErr  bool
+	}{
+		{
+			name:     "best case",
+			verifier: "verifier-0123456789-0123456789-0123456789",
+			nonce:    "nonce",
+			want:     oidc.Claims{Subject: "subject", Email: "mail@mail.com", EmailVerified: true},
+			wantErr:  false,
+		},
+		{
+			name:     "wrong code verifier",
+			verifier: "another-verifier-0123456789-0123456789",
+			nonce:    "nonce",
+			wantErr:  true,
+		},
+		{
+			name:     "nonce mismatch",
+			verifier: "verifier-0123456789-0123456789-0123456789",
+			nonce:    "other",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := oidc.NewProvider(oidc.Config{
+				IssuerURL:   issuer.server.URL,
+				ClientID:    "client",
+				RedirectURL: "http://localhost/callback",
+			})
+
+			authURL, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier-0123456789-0123456789-0123456789")
+			assert.NoError(t, err)
+
+			parsed, _ := url.Parse(authURL)
+			assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+			assert.Equal(t, "state", parsed.Query().Get("state"))
+			issuer.challenge = parsed.Query().Get("code_challenge")
+			issuer.nonce = parsed.Query().Get("nonce")
+
+			got, err := p.Exchange(context.Background(), "code", tt.verifier, tt.nonce)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProvider_AuthCodeURL_discoveryError(t *testing.T) {
+	p := oidc.NewProvider(oidc.Config{IssuerURL: "http://127.0.0.1:1", ClientID: "client"})
+
+	_, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	assert.Error(t, err)
+}
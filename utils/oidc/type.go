@@ -0,0 +1,9 @@
+package oidc
+
+import "context"
+
+type IProvider interface {
+	Issuer() string
+	AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (Claims, error)
+}
@@ -0,0 +1,69 @@
+// Package reference generates the public identifiers of orders: random
+// symbols of the Crockford base32 alphabet followed by a check symbol, so a
+// mistyped reference is detected instead of pointing at another order.
+package reference
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// alphabet leaves out I, L, O and U: I and L are read as 1, O as 0, and U is
+// never generated.
+const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var normalizer = strings.NewReplacer("-", "", " ", "", "O", "0", "I", "1", "L", "1")
+
+// New returns n crypto random symbols and their check symbol.
+func New(n int) (string, error) {
+	raw := make([]byte, n)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+
+	symbols := make([]byte, n, n+1)
+	for i := range raw {
+		//256 is a multiple of 32, every symbol is as likely
+		symbols[i] = alphabet[raw[i]%32]
+	}
+
+	return string(append(symbols, checkSymbol(symbols))), nil
+}
+
+// Normalize reads a reference the way a person may have typed it: in lower
+// case, with dashes or spaces, or with O, I and L instead of 0 and 1.
+func Normalize(s string) string {
+	return normalizer.Replace(strings.ToUpper(strings.TrimSpace(s)))
+}
+
+// Valid reports whether the normalized s is n symbols of the alphabet followed
+// by their check symbol.
+func Valid(s string, n int) bool {
+	if len(s) != n+1 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(alphabet, s[i]) < 0 {
+			return false
+		}
+	}
+
+	return checkSymbol([]byte(s[:n])) == s[n]
+}
+
+// checkSymbol is the Luhn mod 32 check of the symbols. It catches any single
+// wrong symbol and most swaps of neighbouring symbols.
+func checkSymbol(symbols []byte) byte {
+	const base = len(alphabet)
+	factor := 2
+	sum := 0
+	for i := len(symbols) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(alphabet, symbols[i])
+		sum += addend/base + addend%base
+		factor = 3 - factor
+	}
+
+	return alphabet[(base-sum%base)%base]
+}
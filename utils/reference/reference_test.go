@@ -0,0 +1,48 @@
+package reference_test
+
+import (
+	"ebookstore/utils/reference"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		ref, err := reference.New(10)
+		assert.NoError(t, err)
+		assert.Len(t, ref, 11)
+		assert.True(t, reference.Valid(ref, 10), ref)
+		assert.False(t, seen[ref], "duplicate reference %s", ref)
+		seen[ref] = true
+	}
+}
+
+func TestValid_DetectsTypos(t *testing.T) {
+	ref, err := reference.New(10)
+	assert.NoError(t, err)
+
+	//every single wrong symbol
+	for i := 0; i < len(ref); i++ {
+		for _, symbol := range "0123456789ABCDEFGHJKMNPQRSTVWXYZ" {
+			if byte(symbol) == ref[i] {
+				continue
+			}
+
+			typo := ref[:i] + string(symbol) + ref[i+1:]
+			assert.False(t, reference.Valid(typo, 10), "%s accepted for %s", typo, ref)
+		}
+	}
+
+	assert.False(t, reference.Valid(ref[:10], 10), "missing symbol")
+	assert.False(t, reference.Valid(ref+"0", 10), "extra symbol")
+	assert.False(t, reference.Valid(strings.ToLower(ref), 10), "not normalized")
+	assert.False(t, reference.Valid("Ab12Cd34", 7))
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "01ABC1", reference.Normalize(" oiabc-l "))
+	assert.Equal(t, "7K3QW9ZD2M4", reference.Normalize("7k3q w9zd-2m4"))
+}
@@ -0,0 +1,50 @@
+package tracing_test
+
+import (
+	"context"
+	"ebookstore/utils/config"
+	"ebookstore/utils/tracing"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	_, err := tracing.Setup(context.Background(), config.TracingConfig{Exporter: "none"})
+	assert.NoError(t, err)
+
+	var handlerTraceID trace.TraceID
+	srv := fiber.New()
+	srv.Use(tracing.Middleware())
+	srv.Get("/api/order/:id", func(c *fiber.Ctx) error {
+		ctx, span := tracing.Start(c.UserContext(), "orderService.GetOrder")
+		defer span.End()
+
+		handlerTraceID = trace.SpanContextFromContext(ctx).TraceID()
+		return c.SendStatus(fiber.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/api/order/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, err = srv.Test(req, 1000)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handlerTraceID.String())
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "orderService.GetOrder", spans[0].Name())
+	assert.Equal(t, "GET /api/order/:id", spans[1].Name())
+	assert.Equal(t, "00f067aa0ba902b7", spans[1].Parent().SpanID().String())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, "Error", spans[1].Status().Code.String())
+}